@@ -73,6 +73,7 @@ func (c *Client) BroadcastTx(txBytes []byte, waitForTx bool) (*coretypes.ResultB
 		}
 		// Update the tx code and log after waiting for the tx to be committed
 		res.Code = resAfterWait.TxResult.Code
+		res.Codespace = resAfterWait.TxResult.Codespace
 		res.Log = resAfterWait.TxResult.Log
 		return res, nil
 	}
@@ -152,3 +153,33 @@ func (c Client) WaitForBlockHeight(ctx context.Context, h int64) error {
 		}
 	}
 }
+
+// MaxBlockGas returns the max gas of a block from the consensus params, -1 when it is unlimited.
+func (c Client) MaxBlockGas(ctx context.Context) (int64, error) {
+	resp, err := c.Client.ConsensusParams(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	return resp.ConsensusParams.Block.MaxGas, nil
+}
+
+// BlockGas is the gas of the txs of a block
+type BlockGas struct {
+	Used   int64
+	Wanted int64
+	Txs    int
+}
+
+// BlockGas returns the gas the txs of the block at height used and asked for.
+func (c Client) BlockGas(ctx context.Context, height int64) (BlockGas, error) {
+	resp, err := c.Client.BlockResults(ctx, &height)
+	if err != nil {
+		return BlockGas{}, err
+	}
+	gas := BlockGas{Txs: len(resp.TxsResults)}
+	for _, tx := range resp.TxsResults {
+		gas.Used += tx.GasUsed
+		gas.Wanted += tx.GasWanted
+	}
+	return gas, nil
+}
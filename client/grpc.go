@@ -0,0 +1,124 @@
+package client
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	coretypes "github.com/cometbft/cometbft/rpc/core/types"
+	"github.com/cosmos/cosmos-sdk/codec"
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
+	txtypes "github.com/cosmos/cosmos-sdk/types/tx"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+type GRPCClient struct {
+	Conn *grpc.ClientConn
+}
+
+var (
+	grpcClients    = make(map[string]*GRPCClient)
+	grpcClientsMux sync.RWMutex
+)
+
+// GetGRPCClient returns the connection to a gRPC endpoint, created once per endpoint.
+// Messages are encoded with the gogoproto codec the chain types are generated with.
+func GetGRPCClient(grpcEndpoint string) (*GRPCClient, error) {
+	grpcClientsMux.RLock()
+	if client, exists := grpcClients[grpcEndpoint]; exists {
+		grpcClientsMux.RUnlock()
+		return client, nil
+	}
+	grpcClientsMux.RUnlock()
+
+	grpcClientsMux.Lock()
+	defer grpcClientsMux.Unlock()
+
+	if client, exists := grpcClients[grpcEndpoint]; exists {
+		return client, nil
+	}
+
+	protoCodec := codec.NewProtoCodec(codectypes.NewInterfaceRegistry())
+	conn, err := grpc.NewClient(grpcEndpoint,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultCallOptions(grpc.ForceCodec(protoCodec.GRPCCodec())),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	client := &GRPCClient{
+		Conn: conn,
+	}
+
+	grpcClients[grpcEndpoint] = client
+	return client, nil
+}
+
+// BroadcastTx broadcasts through the tx service. The response is shaped like the one of the RPC
+// so callers handle both transports alike.
+func (c *GRPCClient) BroadcastTx(txBytes []byte, waitForTx bool) (*coretypes.ResultBroadcastTx, error) {
+	ctx := context.Background()
+
+	res, err := txtypes.NewServiceClient(c.Conn).BroadcastTx(ctx, &txtypes.BroadcastTxRequest{
+		TxBytes: txBytes,
+		Mode:    txtypes.BroadcastMode_BROADCAST_MODE_SYNC,
+	})
+	if err != nil {
+		return nil, err
+	}
+	hash, err := hex.DecodeString(res.TxResponse.TxHash)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode tx hash '%s': %w", res.TxResponse.TxHash, err)
+	}
+	result := &coretypes.ResultBroadcastTx{
+		Code:      res.TxResponse.Code,
+		Codespace: res.TxResponse.Codespace,
+		Log:       res.TxResponse.RawLog,
+		Hash:      hash,
+	}
+
+	if result.Code != 0 {
+		return result, fmt.Errorf("broadcast error code %d: %s", result.Code, result.Log)
+	}
+
+	if waitForTx {
+		resAfterWait, err := c.WaitForTx(ctx, res.TxResponse.TxHash)
+		if err != nil {
+			return nil, fmt.Errorf("failed to wait for transaction: %w", err)
+		}
+		// Update the tx code and log after waiting for the tx to be committed
+		result.Code = resAfterWait.TxResponse.Code
+		result.Codespace = resAfterWait.TxResponse.Codespace
+		result.Log = resAfterWait.TxResponse.RawLog
+		return result, nil
+	}
+
+	return result, nil
+}
+
+// WaitForTx requests the tx from hash every second until it is found. Returns an error if ctx is canceled.
+func (c *GRPCClient) WaitForTx(ctx context.Context, hash string) (*txtypes.GetTxResponse, error) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		resp, err := txtypes.NewServiceClient(c.Conn).GetTx(ctx, &txtypes.GetTxRequest{Hash: hash})
+		if err == nil {
+			return resp, nil
+		}
+		if status.Code(err) != codes.NotFound {
+			return nil, fmt.Errorf("fetching tx '%s': %w", hash, err)
+		}
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("timeout exceeded waiting for tx: %w", ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
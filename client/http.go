@@ -2,15 +2,20 @@ package client
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"io"
-	"log"
+	"math/rand"
 	"net"
 	"net/http"
+	"net/url"
+	"sync"
 	"time"
+
+	"github.com/rs/zerolog/log"
 )
 
 var httpClient = &http.Client{
-	Timeout: 10 * time.Second, // Adjusted timeout to 10 seconds
 	Transport: &http.Transport{
 		MaxIdleConns:        100,              // Increased maximum idle connections
 		MaxIdleConnsPerHost: 10,               // Increased maximum idle connections per host
@@ -19,9 +24,280 @@ var httpClient = &http.Client{
 	},
 }
 
+// Backoff between retries never exceeds this, whatever the number of retries
+const maxRetryBackoff = 10 * time.Second
+
+// HTTPOptions tune the REST requests of every endpoint
+type HTTPOptions struct {
+	// Timeout of an attempt when the context of the request has no deadline
+	Timeout time.Duration
+	// Attempts after the first one for timeouts, transport errors and overloaded endpoints
+	Retries int
+	// Base of the exponential backoff between retries, fully jittered
+	RetryBackoff time.Duration
+	// Failures in a row opening the circuit of an endpoint, 0 never opens it
+	BreakerFailures int
+	// Time an open circuit rejects requests before letting one through to probe the endpoint
+	BreakerCooldown time.Duration
+}
+
+// DefaultHTTPOptions are used until SetHTTPOptions is called: a timeout, no retries and no circuit
+func DefaultHTTPOptions() HTTPOptions {
+	return HTTPOptions{
+		Timeout: 10 * time.Second,
+	}
+}
+
+var (
+	httpOptions    = DefaultHTTPOptions()
+	httpOptionsMux sync.RWMutex
+)
+
+// SetHTTPOptions replaces the options of all following requests
+func SetHTTPOptions(opts HTTPOptions) {
+	httpOptionsMux.Lock()
+	defer httpOptionsMux.Unlock()
+	httpOptions = opts
+}
+
+func getHTTPOptions() HTTPOptions {
+	httpOptionsMux.RLock()
+	defer httpOptionsMux.RUnlock()
+	return httpOptions
+}
+
+// StatusError is a response with a status other than 200 OK
+type StatusError struct {
+	URL        string
+	StatusCode int
+	Body       []byte
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("request to %s failed with status %d: %s", e.URL, e.StatusCode, e.Body)
+}
+
+// Only an overloaded or unreachable endpoint is worth another attempt. Other statuses are the
+// answer of the node, such as 500 for an error of the chain or 501 for an unknown route.
+func (e *StatusError) retryable() bool {
+	switch e.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// TimeoutError is a request that did not complete before its deadline
+type TimeoutError struct {
+	URL string
+	Err error
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("request to %s timed out: %v", e.URL, e.Err)
+}
+
+func (e *TimeoutError) Unwrap() error {
+	return e.Err
+}
+
+// CircuitOpenError is a request rejected without being sent because its endpoint keeps failing
+type CircuitOpenError struct {
+	Endpoint string
+	Until    time.Time
+}
+
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("circuit of %s is open until %s", e.Endpoint, e.Until.Format(time.RFC3339))
+}
+
+// Whether another attempt may succeed where this one failed
+func isRetryable(err error) bool {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.retryable()
+	}
+	var circuitErr *CircuitOpenError
+	return !errors.As(err, &circuitErr)
+}
+
+// HTTPStats are the request metrics of an endpoint
+type HTTPStats struct {
+	Requests     int64
+	Successes    int64
+	Retries      int64
+	Timeouts     int64
+	StatusErrors int64
+	Rejected     int64
+	Latency      time.Duration
+}
+
+// MeanLatency of the requests sent, rejected ones excluded
+func (s HTTPStats) MeanLatency() time.Duration {
+	if s.Requests == 0 {
+		return 0
+	}
+	return s.Latency / time.Duration(s.Requests)
+}
+
+// endpoint holds the circuit and the metrics of a scheme and host
+type endpoint struct {
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+	probing   bool
+	stats     HTTPStats
+}
+
+var (
+	endpoints    = make(map[string]*endpoint)
+	endpointsMux sync.Mutex
+)
+
+func getEndpoint(name string) *endpoint {
+	endpointsMux.Lock()
+	defer endpointsMux.Unlock()
+	e, exists := endpoints[name]
+	if !exists {
+		e = &endpoint{}
+		endpoints[name] = e
+	}
+	return e
+}
+
+// Whether a request may be sent. Once the cooldown of an open circuit passes, one request at a
+// time probes the endpoint.
+func (e *endpoint) allow(opts HTTPOptions, now time.Time) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if opts.BreakerFailures <= 0 || e.failures < opts.BreakerFailures {
+		return true
+	}
+	if now.Before(e.openUntil) || e.probing {
+		e.stats.Rejected++
+		return false
+	}
+	e.probing = true
+	return true
+}
+
+func (e *endpoint) record(opts HTTPOptions, name string, latency time.Duration, err error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.stats.Requests++
+	e.stats.Latency += latency
+	e.probing = false
+
+	var timeoutErr *TimeoutError
+	var statusErr *StatusError
+	switch {
+	case err == nil:
+		e.stats.Successes++
+	case errors.As(err, &timeoutErr):
+		e.stats.Timeouts++
+	case errors.As(err, &statusErr):
+		e.stats.StatusErrors++
+	}
+
+	// Answers of the node, even errors, show it is up
+	if err == nil || !isRetryable(err) {
+		e.failures = 0
+		return
+	}
+	e.failures++
+	if opts.BreakerFailures > 0 && e.failures >= opts.BreakerFailures {
+		e.openUntil = time.Now().Add(opts.BreakerCooldown)
+		log.Warn().Err(err).Msgf("%d requests in a row to %s failed, opening its circuit for %s", e.failures, name, opts.BreakerCooldown)
+	}
+}
+
+func (e *endpoint) until() time.Time {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.openUntil
+}
+
+func (e *endpoint) addRetry() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.stats.Retries++
+}
+
+// GetHTTPStats returns the request metrics of every endpoint queried, by scheme and host
+func GetHTTPStats() map[string]HTTPStats {
+	endpointsMux.Lock()
+	defer endpointsMux.Unlock()
+	stats := make(map[string]HTTPStats, len(endpoints))
+	for name, e := range endpoints {
+		e.mu.Lock()
+		stats[name] = e.stats
+		e.mu.Unlock()
+	}
+	return stats
+}
+
+// LogHTTPStats logs the request metrics of every endpoint queried
+func LogHTTPStats() {
+	for name, stats := range GetHTTPStats() {
+		log.Info().Msgf("HTTP requests to %s: %d sent, %d succeeded, %d retried, %d timed out, %d failed with a status, %d rejected by the circuit, mean latency %s",
+			name, stats.Requests, stats.Successes, stats.Retries, stats.Timeouts, stats.StatusErrors, stats.Rejected, stats.MeanLatency())
+	}
+}
+
+func endpointName(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return rawURL
+	}
+	return u.Scheme + "://" + u.Host
+}
+
+// HTTPGet gets the body of a url with the timeout of the options
 func HTTPGet(url string) ([]byte, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+	return HTTPGetContext(context.Background(), url)
+}
+
+// HTTPGetContext gets the body of a url, retrying timeouts, transport errors and overloaded
+// endpoints. Every attempt has the timeout of the options unless ctx has a deadline, in which
+// case the deadline bounds all attempts.
+func HTTPGetContext(ctx context.Context, url string) ([]byte, error) {
+	opts := getHTTPOptions()
+	name := endpointName(url)
+	e := getEndpoint(name)
+
+	var err error
+	for attempt := 0; attempt <= opts.Retries; attempt++ {
+		if attempt > 0 {
+			e.addRetry()
+			if err := sleepBackoff(ctx, opts.RetryBackoff, attempt); err != nil {
+				return nil, err
+			}
+		}
+		if !e.allow(opts, time.Now()) {
+			return nil, &CircuitOpenError{Endpoint: name, Until: e.until()}
+		}
+
+		var body []byte
+		start := time.Now()
+		body, err = httpGetOnce(ctx, opts, url)
+		e.record(opts, name, time.Since(start), err)
+		if err == nil {
+			return body, nil
+		}
+		if !isRetryable(err) || ctx.Err() != nil {
+			return nil, err
+		}
+		log.Debug().Err(err).Msgf("Request to %s failed, attempt %d of %d", url, attempt+1, opts.Retries+1)
+	}
+	return nil, err
+}
+
+func httpGetOnce(ctx context.Context, opts HTTPOptions, url string) ([]byte, error) {
+	if _, ok := ctx.Deadline(); !ok && opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
@@ -30,18 +306,44 @@ func HTTPGet(url string) ([]byte, error) {
 
 	resp, err := httpClient.Do(req)
 	if err != nil {
-		netErr, ok := err.(net.Error)
-		if ok && netErr.Timeout() {
-			log.Printf("Request to %s timed out, continuing...", url)
-			return nil, nil
-		}
-		return nil, err
+		return nil, asTimeout(url, err)
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, err
+		return nil, asTimeout(url, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, &StatusError{URL: url, StatusCode: resp.StatusCode, Body: body}
 	}
 	return body, nil
 }
+
+func asTimeout(url string, err error) error {
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return &TimeoutError{URL: url, Err: err}
+	}
+	return err
+}
+
+// Sleep a random time up to the exponential backoff of the attempt, or until ctx is done
+func sleepBackoff(ctx context.Context, base time.Duration, attempt int) error {
+	if base <= 0 {
+		return ctx.Err()
+	}
+	backoff := base << (attempt - 1)
+	// The shift overflows to zero or less past 63 doublings
+	if backoff <= 0 || backoff > maxRetryBackoff {
+		backoff = maxRetryBackoff
+	}
+	timer := time.NewTimer(time.Duration(rand.Int63n(int64(backoff) + 1)))
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
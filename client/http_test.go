@@ -0,0 +1,110 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func withHTTPOptions(t *testing.T, opts HTTPOptions) {
+	t.Helper()
+	previous := getHTTPOptions()
+	SetHTTPOptions(opts)
+	t.Cleanup(func() { SetHTTPOptions(previous) })
+}
+
+func TestHTTPGetErrors(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		switch r.URL.Path {
+		case "/flaky":
+			if n%2 == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.Write([]byte("ok"))
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte("not found"))
+		case "/slow":
+			time.Sleep(200 * time.Millisecond)
+			w.Write([]byte("late"))
+		}
+	}))
+	defer server.Close()
+	withHTTPOptions(t, HTTPOptions{Timeout: 50 * time.Millisecond, Retries: 1})
+
+	calls.Store(0)
+	body, err := HTTPGet(server.URL + "/flaky")
+	if err != nil || string(body) != "ok" || calls.Load() != 2 {
+		t.Errorf("expected ok after a retry, got %q and %v after %d calls", body, err, calls.Load())
+	}
+
+	calls.Store(0)
+	_, err = HTTPGet(server.URL + "/missing")
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusNotFound || string(statusErr.Body) != "not found" {
+		t.Errorf("expected a 404 status error, got %v", err)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("expected a 404 not to be retried, got %d calls", calls.Load())
+	}
+
+	_, err = HTTPGet(server.URL + "/slow")
+	var timeoutErr *TimeoutError
+	if !errors.As(err, &timeoutErr) {
+		t.Errorf("expected a timeout error, got %v", err)
+	}
+
+	// The deadline of the context replaces the timeout of the options
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if body, err := HTTPGetContext(ctx, server.URL+"/slow"); err != nil || string(body) != "late" {
+		t.Errorf("expected the context deadline to apply, got %q and %v", body, err)
+	}
+
+	stats := GetHTTPStats()[server.URL]
+	if stats.Retries < 2 || stats.Timeouts < 2 || stats.StatusErrors != 2 || stats.Successes != 2 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	var calls atomic.Int32
+	var healthy atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+	withHTTPOptions(t, HTTPOptions{Timeout: time.Second, BreakerFailures: 2, BreakerCooldown: 100 * time.Millisecond})
+
+	for i := 0; i < 2; i++ {
+		if _, err := HTTPGet(server.URL); err == nil {
+			t.Fatalf("expected the bad gateway to fail")
+		}
+	}
+	_, err := HTTPGet(server.URL)
+	var circuitErr *CircuitOpenError
+	if !errors.As(err, &circuitErr) || calls.Load() != 2 {
+		t.Fatalf("expected the open circuit to reject the request, got %v after %d calls", err, calls.Load())
+	}
+
+	healthy.Store(true)
+	time.Sleep(150 * time.Millisecond)
+	if body, err := HTTPGet(server.URL); err != nil || string(body) != "ok" {
+		t.Errorf("expected the probe after the cooldown to close the circuit, got %q and %v", body, err)
+	}
+	if _, err := HTTPGet(server.URL); err != nil {
+		t.Errorf("expected the closed circuit to let requests through, got %v", err)
+	}
+}
@@ -5,10 +5,12 @@ import (
 	"math/rand"
 	"os"
 
+	"github.com/allora-network/allora-simulator/client"
 	"github.com/allora-network/allora-simulator/lib"
 	"github.com/allora-network/allora-simulator/lib/logger"
 	"github.com/allora-network/allora-simulator/types"
 	"github.com/allora-network/allora-simulator/workloads/basic_activity"
+	"github.com/allora-network/allora-simulator/workloads/common"
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	"github.com/rs/zerolog/log"
 )
@@ -32,6 +34,11 @@ func main() {
 		log.Fatal().Err(err).Msgf("Failed to read seed phrase: %v", err)
 	}
 
+	if err := lib.ValidateNodesConfig(config.Nodes); err != nil {
+		log.Fatal().Err(err).Msgf("Invalid nodes config: %v", err)
+	}
+	lib.ConfigureHTTPClient(config.Nodes.HTTP)
+
 	// Set Bech32 prefixes and seal the configuration once
 	sdkConfig := sdk.GetConfig()
 	sdkConfig.SetBech32PrefixForAccount(config.Prefix, config.Prefix+"pub")
@@ -39,17 +46,28 @@ func main() {
 	sdkConfig.SetBech32PrefixForConsensusNode(config.Prefix+"valcons", config.Prefix+"valconspub")
 	sdkConfig.Seal()
 
-	// Set initial gas price before sending any transactions
-	gasPrice, err := lib.GetGasPrice(&config)
+	if err := common.ValidateFeeConfig(config.Fees); err != nil {
+		log.Fatal().Err(err).Msgf("Invalid fees config: %v", err)
+	}
+
+	apiVersion, err := lib.NegotiateAPIVersion(&config)
 	if err != nil {
+		log.Fatal().Err(err).Msgf("Failed to negotiate the emissions API version: %v", err)
+	}
+	log.Info().Msgf("Using emissions API %s", apiVersion)
+
+	// Set initial gas price before sending any transactions
+	gasPrice := lib.NewGasPriceOracle(&config)
+	if _, err := gasPrice.Refresh(); err != nil {
 		log.Fatal().Err(err).Msgf("Error getting base fee: %v", err)
 	}
-	lib.SetCurrentGasPrice(gasPrice)
 
 	rnd := rand.New(rand.NewSource(config.BasicActivity.RandWalletSeed))
-	state := basic_activity.CreateAndFundActors(&config, mnemonic, rnd)
+	state := basic_activity.CreateAndFundActors(&config, gasPrice, mnemonic, rnd)
 
-	if err := basic_activity.Start(&config, state); err != nil {
+	err = basic_activity.Start(&config, state)
+	client.LogHTTPStats()
+	if err != nil {
 		log.Fatal().Err(err).Msg("An error occured running basic activity simulation")
 	}
 }
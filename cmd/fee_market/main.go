@@ -0,0 +1,80 @@
+package main
+
+import (
+	"encoding/json"
+	"math/rand"
+	"os"
+	"time"
+
+	"github.com/allora-network/allora-simulator/client"
+	"github.com/allora-network/allora-simulator/lib"
+	"github.com/allora-network/allora-simulator/lib/logger"
+	"github.com/allora-network/allora-simulator/types"
+	"github.com/allora-network/allora-simulator/workloads/common"
+	"github.com/allora-network/allora-simulator/workloads/fee_market"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/rs/zerolog/log"
+)
+
+func main() {
+	logger.InitLogger()
+	log.Info().Msgf("Starting fee market simulation...")
+
+	config := types.Config{}
+	data, err := os.ReadFile("config.json")
+	if err != nil {
+		log.Fatal().Err(err).Msgf("Failed to read config file: %v", err)
+	}
+
+	if err := json.Unmarshal(data, &config); err != nil {
+		log.Fatal().Err(err).Msgf("Failed to parse config: %v", err)
+	}
+
+	mnemonic, err := os.ReadFile("scripts/seedphrase")
+	if err != nil {
+		log.Fatal().Err(err).Msgf("Failed to read seed phrase: %v", err)
+	}
+
+	if err := lib.ValidateNodesConfig(config.Nodes); err != nil {
+		log.Fatal().Err(err).Msgf("Invalid nodes config: %v", err)
+	}
+	lib.ConfigureHTTPClient(config.Nodes.HTTP)
+
+	// Set Bech32 prefixes and seal the configuration once
+	sdkConfig := sdk.GetConfig()
+	sdkConfig.SetBech32PrefixForAccount(config.Prefix, config.Prefix+"pub")
+	sdkConfig.SetBech32PrefixForValidator(config.Prefix+"valoper", config.Prefix+"valoperpub")
+	sdkConfig.SetBech32PrefixForConsensusNode(config.Prefix+"valcons", config.Prefix+"valconspub")
+	sdkConfig.Seal()
+
+	if err := common.ValidateFeeConfig(config.Fees); err != nil {
+		log.Fatal().Err(err).Msgf("Invalid fees config: %v", err)
+	}
+	if err := fee_market.ValidateFeeMarketConfig(config.FeeMarket); err != nil {
+		log.Fatal().Err(err).Msgf("Invalid fee market config: %v", err)
+	}
+
+	apiVersion, err := lib.NegotiateAPIVersion(&config)
+	if err != nil {
+		log.Fatal().Err(err).Msgf("Failed to negotiate the emissions API version: %v", err)
+	}
+	log.Info().Msgf("Using emissions API %s", apiVersion)
+
+	// Set initial gas price before sending any transactions
+	gasPrice := lib.NewGasPriceOracle(&config)
+	if _, err := gasPrice.Refresh(); err != nil {
+		log.Fatal().Err(err).Msgf("Error getting base fee: %v", err)
+	}
+
+	state, err := fee_market.CreateAndFundActors(&config, gasPrice, mnemonic, rand.New(rand.NewSource(time.Now().UnixNano())))
+	if err != nil {
+		log.Fatal().Err(err).Msgf("Failed to create the load actors: %v", err)
+	}
+
+	path, err := fee_market.Start(&config, state)
+	client.LogHTTPStats()
+	if err != nil {
+		log.Fatal().Err(err).Msgf("An error occured running the fee market simulation: %v", err)
+	}
+	log.Info().Msgf("Fee dynamics report written to %s", path)
+}
@@ -6,9 +6,11 @@ import (
 	"os"
 	"time"
 
+	"github.com/allora-network/allora-simulator/client"
 	"github.com/allora-network/allora-simulator/lib"
 	"github.com/allora-network/allora-simulator/lib/logger"
 	"github.com/allora-network/allora-simulator/types"
+	"github.com/allora-network/allora-simulator/workloads/common"
 	"github.com/allora-network/allora-simulator/workloads/research"
 	sdk "github.com/cosmos/cosmos-sdk/types"
 
@@ -34,6 +36,11 @@ func main() {
 		log.Fatal().Err(err).Msgf("Failed to read seed phrase: %v", err)
 	}
 
+	if err := lib.ValidateNodesConfig(config.Nodes); err != nil {
+		log.Fatal().Err(err).Msgf("Invalid nodes config: %v", err)
+	}
+	lib.ConfigureHTTPClient(config.Nodes.HTTP)
+
 	// Set Bech32 prefixes and seal the configuration once
 	sdkConfig := sdk.GetConfig()
 	sdkConfig.SetBech32PrefixForAccount(config.Prefix, config.Prefix+"pub")
@@ -41,24 +48,53 @@ func main() {
 	sdkConfig.SetBech32PrefixForConsensusNode(config.Prefix+"valcons", config.Prefix+"valconspub")
 	sdkConfig.Seal()
 
-	// Calculate total number of actors
-	totalActors := config.InferersPerTopic + config.ForecastersPerTopic + config.ReputersPerTopic
+	// Resolve the topics to simulate and calculate total number of actors
+	if err := research.ValidateResearchArms(config.Research.Arms); err != nil {
+		log.Fatal().Err(err).Msgf("Invalid research arms: %v", err)
+	}
+	topics := research.GetResearchTopics(&config)
+	totalActors := research.GetNumActors(topics, config.Research.SharedActors)
+	// Spare actors are funded up front and join topics later through churn
+	numSpareActors := 0
+	if config.Churn.Enabled {
+		numSpareActors = config.Churn.SpareActors
+	}
+	// Delegators stake upon the reputers of every topic
+	if err := common.ValidateStakingConfig(config.Staking); err != nil {
+		log.Fatal().Err(err).Msgf("Invalid staking config: %v", err)
+	}
+	numDelegators := 0
+	if config.Staking.Enabled {
+		numDelegators = config.Staking.Delegators
+	}
 
-	// Set initial gas price before sending any transactions
-	gasPrice, err := lib.GetGasPrice(&config)
+	if err := common.ValidateFeeConfig(config.Fees); err != nil {
+		log.Fatal().Err(err).Msgf("Invalid fees config: %v", err)
+	}
+
+	apiVersion, err := lib.NegotiateAPIVersion(&config)
 	if err != nil {
+		log.Fatal().Err(err).Msgf("Failed to negotiate the emissions API version: %v", err)
+	}
+	log.Info().Msgf("Using emissions API %s", apiVersion)
+
+	// Set initial gas price before sending any transactions
+	gasPrice := lib.NewGasPriceOracle(&config)
+	if _, err := gasPrice.Refresh(); err != nil {
 		log.Fatal().Err(err).Msgf("Error getting base fee: %v", err)
 	}
-	lib.SetCurrentGasPrice(gasPrice)
 
-	log.Info().Msgf("Creating and funding %d actors...", totalActors)
+	log.Info().Msgf("Creating and funding %d actors...", totalActors+numDelegators+numSpareActors)
 	faucet, simulationData := research.CreateAndFundActors(
 		&config,
+		gasPrice,
 		mnemonic,
-		totalActors,
-		config.Research.Topic.EpochLength,
+		totalActors+numDelegators+numSpareActors,
+		topics[0].Config.Topic.EpochLength,
 		rand.New(rand.NewSource(time.Now().UnixNano())),
 	)
+	simulationData.Delegators = simulationData.Actors[totalActors : totalActors+numDelegators]
+	simulationData.SpareActors = simulationData.Actors[totalActors+numDelegators:]
 	log.Info().Msgf("Successfully created and funded all actors")
 
 	// Configure chain global parameters
@@ -67,75 +103,41 @@ func main() {
 		log.Fatal().Err(err).Msgf("Failed to configure chain parameters: %v", err)
 	}
 
-	log.Info().Msgf("Creating research topic...")
-	topicId, err := research.CreateAndFundResearchTopic(faucet, &config)
-	if err != nil {
-		log.Fatal().Err(err).Msgf("Failed to create research topic: %v", err)
-	}
-	log.Info().Msgf("Successfully created research topic with ID: %d", topicId)
-
 	log.Info().Msgf("Dividing actors into their respective roles...")
-	// Divide actors into their roles
-	startIdx := 0
-	inferers := simulationData.Actors[startIdx : startIdx+config.InferersPerTopic]
-	startIdx += config.InferersPerTopic
-
-	forecasters := simulationData.Actors[startIdx : startIdx+config.ForecastersPerTopic]
-	startIdx += config.ForecastersPerTopic
-
-	reputers := simulationData.Actors[startIdx : startIdx+config.ReputersPerTopic]
-	log.Info().Msgf("Actor roles assigned - Inferers: %d, Forecasters: %d, Reputers: %d",
-		len(inferers), len(forecasters), len(reputers))
-
-	// Register actors with delays between registrations
-	time.Sleep(20 * time.Second)
-	log.Info().Msgf("Starting reputer registration process (%d reputers)...", len(reputers))
-	err = research.RegisterReputersAndStake(
-		reputers,
-		topicId,
-		simulationData,
-		config.ReputersPerTopic,
-	)
-	if err != nil {
-		log.Fatal().Err(err).Msgf("Error registering reputers: %v", err)
-	}
-	log.Info().Msgf("Successfully registered all reputers")
+	topicActors := research.AssignTopicActors(simulationData.Actors[:totalActors], topics, config.Research.SharedActors)
 
-	time.Sleep(20 * time.Second)
-	log.Info().Msgf("Starting inferer registration process (%d inferers)...", len(inferers))
-	err = research.RegisterWorkers(
-		inferers,
-		topicId,
-		simulationData,
-		config.InferersPerTopic,
-		true,
-	)
+	topicIds, err := research.SetupResearchTopics(faucet, &config, simulationData, topics, topicActors)
 	if err != nil {
-		log.Fatal().Err(err).Msgf("Error registering inferers: %v", err)
+		log.Fatal().Err(err).Msgf("Failed to set up research topics: %v", err)
 	}
-	log.Info().Msgf("Successfully registered all inferers")
 
-	time.Sleep(20 * time.Second)
-	log.Info().Msgf("Starting forecaster registration process (%d forecasters)...", len(forecasters))
-	err = research.RegisterWorkers(
-		forecasters,
-		topicId,
-		simulationData,
-		config.ForecastersPerTopic,
-		false,
-	)
+	// Per-epoch results of this run are written to their own directory
+	resultsWriter, err := research.NewResultsWriter(research.GetResultsDir(&config))
 	if err != nil {
-		log.Fatal().Err(err).Msgf("Error registering forecasters: %v", err)
+		log.Fatal().Err(err).Msgf("Error creating results writer: %v", err)
+	}
+	log.Info().Msgf("Writing research results to %s", resultsWriter.Dir())
+	for i, topicId := range topicIds {
+		if err := resultsWriter.WriteTopic(topicId, topics[i]); err != nil {
+			log.Fatal().Err(err).Msgf("Error writing topic %d: %v", topicId, err)
+		}
+		if err := resultsWriter.WriteActors(simulationData, topicId); err != nil {
+			log.Fatal().Err(err).Msgf("Error writing actors of topic %d: %v", topicId, err)
+		}
 	}
-	log.Info().Msgf("Successfully registered all forecasters")
 
 	// Start the simulation loops
 	log.Info().Msgf("Initiating actor simulation loops...")
 	err = research.StartActorLoops(
 		simulationData,
 		&config,
-		[]uint64{topicId},
+		resultsWriter,
+		topicIds,
 	)
+	client.LogHTTPStats()
+	if closeErr := resultsWriter.Close(); closeErr != nil {
+		log.Error().Msgf("Error closing results writer: %v", closeErr)
+	}
 	if err != nil {
 		log.Fatal().Err(err).Msgf("Error starting actor loops: %v", err)
 	}
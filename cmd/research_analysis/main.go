@@ -0,0 +1,43 @@
+package main
+
+import (
+	"flag"
+
+	"github.com/allora-network/allora-simulator/lib/logger"
+	"github.com/allora-network/allora-simulator/workloads/research/analysis"
+
+	"github.com/rs/zerolog/log"
+)
+
+func main() {
+	logger.InitLogger()
+
+	runDir := flag.String("run", "", "results directory of a finished research run")
+	outDir := flag.String("out", "", "directory to write the report to (default: the run directory)")
+	format := flag.String("format", analysis.FormatMarkdown, "report format: md or html")
+	flag.Parse()
+
+	if *runDir == "" {
+		log.Fatal().Msg("Missing -run: path to the results directory of a research run")
+	}
+	if *outDir == "" {
+		*outDir = *runDir
+	}
+
+	log.Info().Msgf("Analyzing research run %s...", *runDir)
+	run, err := analysis.LoadRun(*runDir)
+	if err != nil {
+		log.Fatal().Err(err).Msgf("Failed to load research run: %v", err)
+	}
+
+	result, err := analysis.Analyze(run)
+	if err != nil {
+		log.Fatal().Err(err).Msgf("Failed to analyze research run: %v", err)
+	}
+
+	path, err := analysis.WriteReport(result, *outDir, *format)
+	if err != nil {
+		log.Fatal().Err(err).Msgf("Failed to write report: %v", err)
+	}
+	log.Info().Msgf("Report written to %s", path)
+}
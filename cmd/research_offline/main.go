@@ -0,0 +1,33 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/allora-network/allora-simulator/lib/logger"
+	"github.com/allora-network/allora-simulator/types"
+	"github.com/allora-network/allora-simulator/workloads/research"
+
+	"github.com/rs/zerolog/log"
+)
+
+func main() {
+	logger.InitLogger()
+	log.Info().Msgf("Starting offline research simulation...")
+
+	config := types.Config{}
+	data, err := os.ReadFile("config.json")
+	if err != nil {
+		log.Fatal().Err(err).Msgf("Failed to read config file: %v", err)
+	}
+
+	if err := json.Unmarshal(data, &config); err != nil {
+		log.Fatal().Err(err).Msgf("Failed to parse config: %v", err)
+	}
+
+	if err := research.RunOfflineSimulation(&config); err != nil {
+		log.Fatal().Err(err).Msgf("Offline research simulation failed: %v", err)
+	}
+
+	log.Info().Msg("Offline research simulation completed")
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"encoding/json"
+	"math/rand"
+	"os"
+	"time"
+
+	"github.com/allora-network/allora-simulator/client"
+	"github.com/allora-network/allora-simulator/lib"
+	"github.com/allora-network/allora-simulator/lib/logger"
+	"github.com/allora-network/allora-simulator/types"
+	"github.com/allora-network/allora-simulator/workloads/common"
+	"github.com/allora-network/allora-simulator/workloads/research"
+	"github.com/allora-network/allora-simulator/workloads/research/sweep"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+
+	"github.com/rs/zerolog/log"
+)
+
+func main() {
+	logger.InitLogger()
+	log.Info().Msgf("Starting research parameter sweep...")
+
+	config := types.Config{}
+	data, err := os.ReadFile("config.json")
+	if err != nil {
+		log.Fatal().Err(err).Msgf("Failed to read config file: %v", err)
+	}
+
+	if err := json.Unmarshal(data, &config); err != nil {
+		log.Fatal().Err(err).Msgf("Failed to parse config: %v", err)
+	}
+
+	mnemonic, err := os.ReadFile("scripts/seedphrase")
+	if err != nil {
+		log.Fatal().Err(err).Msgf("Failed to read seed phrase: %v", err)
+	}
+
+	if err := lib.ValidateNodesConfig(config.Nodes); err != nil {
+		log.Fatal().Err(err).Msgf("Invalid nodes config: %v", err)
+	}
+	lib.ConfigureHTTPClient(config.Nodes.HTTP)
+
+	// Points are known before anything is sent to the chain
+	if err := sweep.ValidateSweepConfig(config.Sweep); err != nil {
+		log.Fatal().Err(err).Msgf("Invalid sweep config: %v", err)
+	}
+	points := sweep.GetPoints(config.Sweep)
+	log.Info().Msgf("Sweeping %d points of %d epochs", len(points), config.Sweep.Epochs)
+
+	// Set Bech32 prefixes and seal the configuration once
+	sdkConfig := sdk.GetConfig()
+	sdkConfig.SetBech32PrefixForAccount(config.Prefix, config.Prefix+"pub")
+	sdkConfig.SetBech32PrefixForValidator(config.Prefix+"valoper", config.Prefix+"valoperpub")
+	sdkConfig.SetBech32PrefixForConsensusNode(config.Prefix+"valcons", config.Prefix+"valconspub")
+	sdkConfig.Seal()
+
+	// The same actors join the topics of every point
+	if err := research.ValidateResearchArms(config.Research.Arms); err != nil {
+		log.Fatal().Err(err).Msgf("Invalid research arms: %v", err)
+	}
+	topics := research.GetResearchTopics(&config)
+	totalActors := research.GetNumActors(topics, config.Research.SharedActors)
+	// Spare actors are funded up front and join topics later through churn
+	numSpareActors := 0
+	if config.Churn.Enabled {
+		numSpareActors = config.Churn.SpareActors
+	}
+	// Delegators stake upon the reputers of every topic
+	if err := common.ValidateStakingConfig(config.Staking); err != nil {
+		log.Fatal().Err(err).Msgf("Invalid staking config: %v", err)
+	}
+	numDelegators := 0
+	if config.Staking.Enabled {
+		numDelegators = config.Staking.Delegators
+	}
+
+	if err := common.ValidateFeeConfig(config.Fees); err != nil {
+		log.Fatal().Err(err).Msgf("Invalid fees config: %v", err)
+	}
+
+	apiVersion, err := lib.NegotiateAPIVersion(&config)
+	if err != nil {
+		log.Fatal().Err(err).Msgf("Failed to negotiate the emissions API version: %v", err)
+	}
+	log.Info().Msgf("Using emissions API %s", apiVersion)
+
+	// Set initial gas price before sending any transactions
+	gasPrice := lib.NewGasPriceOracle(&config)
+	if _, err := gasPrice.Refresh(); err != nil {
+		log.Fatal().Err(err).Msgf("Error getting base fee: %v", err)
+	}
+
+	log.Info().Msgf("Creating and funding %d actors...", totalActors+numDelegators+numSpareActors)
+	faucet, simulationData := research.CreateAndFundActors(
+		&config,
+		gasPrice,
+		mnemonic,
+		totalActors+numDelegators+numSpareActors,
+		topics[0].Config.Topic.EpochLength,
+		rand.New(rand.NewSource(time.Now().UnixNano())),
+	)
+	simulationData.Delegators = simulationData.Actors[totalActors : totalActors+numDelegators]
+	simulationData.SpareActors = simulationData.Actors[totalActors+numDelegators:]
+	log.Info().Msgf("Successfully created and funded all actors")
+
+	// Configure chain global parameters, the points override the ones they sweep
+	err = research.ConfigureChainParams(faucet, &config)
+	if err != nil {
+		log.Fatal().Err(err).Msgf("Failed to configure chain parameters: %v", err)
+	}
+
+	topicActors := research.AssignTopicActors(simulationData.Actors[:totalActors], topics, config.Research.SharedActors)
+
+	dir, err := sweep.Run(faucet, &config, simulationData, topics, topicActors, points)
+	client.LogHTTPStats()
+	if err != nil {
+		log.Fatal().Err(err).Msgf("Sweep failed, results so far are in %s: %v", dir, err)
+	}
+	log.Info().Msgf("Sweep finished, comparison table written to %s", dir)
+}
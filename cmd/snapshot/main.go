@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/allora-network/allora-simulator/client"
+	"github.com/allora-network/allora-simulator/lib"
+	"github.com/allora-network/allora-simulator/lib/logger"
+	"github.com/allora-network/allora-simulator/lib/snapshot"
+	"github.com/allora-network/allora-simulator/types"
+	"github.com/rs/zerolog/log"
+)
+
+func main() {
+	logger.InitLogger()
+
+	outPath := flag.String("out", "", "file to write the snapshot to (default: results/snapshot_<time>.json)")
+	actorsPath := flag.String("actors", "", "addresses of actors to capture besides the active sets of the topics, one per line or a CSV with an actor column")
+	flag.Parse()
+
+	config := types.Config{}
+	data, err := os.ReadFile("config.json")
+	if err != nil {
+		log.Fatal().Err(err).Msgf("Failed to read config file: %v", err)
+	}
+
+	if err := json.Unmarshal(data, &config); err != nil {
+		log.Fatal().Err(err).Msgf("Failed to parse config: %v", err)
+	}
+
+	if err := lib.ValidateNodesConfig(config.Nodes); err != nil {
+		log.Fatal().Err(err).Msgf("Invalid nodes config: %v", err)
+	}
+	lib.ConfigureHTTPClient(config.Nodes.HTTP)
+
+	apiVersion, err := lib.NegotiateAPIVersion(&config)
+	if err != nil {
+		log.Fatal().Err(err).Msgf("Failed to negotiate the emissions API version: %v", err)
+	}
+	log.Info().Msgf("Using emissions API %s", apiVersion)
+
+	addresses := make([]string, 0)
+	if *actorsPath != "" {
+		addresses, err = snapshot.LoadAddresses(*actorsPath)
+		if err != nil {
+			log.Fatal().Err(err).Msgf("Failed to load actors: %v", err)
+		}
+	}
+
+	if *outPath == "" {
+		*outPath = filepath.Join("results", "snapshot_"+time.Now().UTC().Format("20060102_150405")+".json")
+	}
+	if err := os.MkdirAll(filepath.Dir(*outPath), 0o755); err != nil {
+		log.Fatal().Err(err).Msgf("Failed to create output directory: %v", err)
+	}
+
+	log.Info().Msg("Taking chain state snapshot...")
+	state, err := snapshot.Take(&config, addresses)
+	if err != nil {
+		log.Fatal().Err(err).Msgf("Failed to take snapshot: %v", err)
+	}
+	if err := snapshot.Write(state, *outPath); err != nil {
+		log.Fatal().Err(err).Msgf("Failed to write snapshot: %v", err)
+	}
+	log.Info().Msgf("Snapshot written to %s", *outPath)
+	client.LogHTTPStats()
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"os"
+
+	"github.com/allora-network/allora-simulator/lib/logger"
+	"github.com/allora-network/allora-simulator/lib/snapshot"
+	"github.com/rs/zerolog/log"
+)
+
+func main() {
+	logger.InitLogger()
+
+	beforePath := flag.String("before", "", "snapshot taken first")
+	afterPath := flag.String("after", "", "snapshot taken last")
+	format := flag.String("format", snapshot.FormatText, "diff format: text or json")
+	outPath := flag.String("out", "", "file to write the diff to (default: stdout)")
+	flag.Parse()
+
+	if *beforePath == "" || *afterPath == "" {
+		log.Fatal().Msg("Missing -before or -after: paths to the snapshots to compare")
+	}
+
+	before, err := snapshot.Load(*beforePath)
+	if err != nil {
+		log.Fatal().Err(err).Msgf("Failed to load snapshot: %v", err)
+	}
+	after, err := snapshot.Load(*afterPath)
+	if err != nil {
+		log.Fatal().Err(err).Msgf("Failed to load snapshot: %v", err)
+	}
+
+	diff, err := snapshot.Compare(before, after)
+	if err != nil {
+		log.Fatal().Err(err).Msgf("Failed to compare snapshots: %v", err)
+	}
+
+	var w io.Writer = os.Stdout
+	if *outPath != "" {
+		f, err := os.Create(*outPath)
+		if err != nil {
+			log.Fatal().Err(err).Msgf("Failed to create diff file: %v", err)
+		}
+		defer f.Close()
+		w = f
+	}
+	if err := snapshot.WriteDiff(w, diff, *format); err != nil {
+		log.Fatal().Err(err).Msgf("Failed to write diff: %v", err)
+	}
+	if *outPath != "" {
+		log.Info().Msgf("Diff of %d changes written to %s", len(diff.Changes), *outPath)
+	}
+}
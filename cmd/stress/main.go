@@ -6,9 +6,11 @@ import (
 	"os"
 	"time"
 
+	"github.com/allora-network/allora-simulator/client"
 	"github.com/allora-network/allora-simulator/lib"
 	"github.com/allora-network/allora-simulator/lib/logger"
 	"github.com/allora-network/allora-simulator/types"
+	"github.com/allora-network/allora-simulator/workloads/common"
 	"github.com/allora-network/allora-simulator/workloads/stress"
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	"github.com/rs/zerolog/log"
@@ -31,6 +33,11 @@ func main() {
 		log.Fatal().Err(err).Msgf("Failed to read seed phrase: %v", err)
 	}
 
+	if err := lib.ValidateNodesConfig(config.Nodes); err != nil {
+		log.Fatal().Err(err).Msgf("Invalid nodes config: %v", err)
+	}
+	lib.ConfigureHTTPClient(config.Nodes.HTTP)
+
 	// Set Bech32 prefixes and seal the configuration once
 	sdkConfig := sdk.GetConfig()
 	sdkConfig.SetBech32PrefixForAccount(config.Prefix, config.Prefix+"pub")
@@ -41,22 +48,62 @@ func main() {
 	// Calculate total number of actors
 	workersPerTopic := config.InferersPerTopic + config.ForecastersPerTopic
 	numActors := (workersPerTopic + config.ReputersPerTopic) * config.NumTopics
+	// Spare actors are funded up front and join topics later through churn
+	numSpareActors := 0
+	if config.Churn.Enabled {
+		numSpareActors = config.Churn.SpareActors
+	}
+	// Delegators stake upon the reputers of every topic
+	if err := common.ValidateStakingConfig(config.Staking); err != nil {
+		log.Fatal().Err(err).Msgf("Invalid staking config: %v", err)
+	}
+	numDelegators := 0
+	if config.Staking.Enabled {
+		numDelegators = config.Staking.Delegators
+	}
+	// Values of workers and reputers derive from the research models in model mode
+	if err := stress.ValidateStressValuesConfig(config.StressValues); err != nil {
+		log.Fatal().Err(err).Msgf("Invalid stress values config: %v", err)
+	}
+	if err := stress.ValidatePayloadConfig(config.Payload); err != nil {
+		log.Fatal().Err(err).Msgf("Invalid payload config: %v", err)
+	}
+	var valueModel *stress.ValueModel
+	if config.StressValues.Mode == stress.ValuesModel {
+		valueModel, err = stress.NewValueModel(&config.Research)
+		if err != nil {
+			log.Fatal().Err(err).Msgf("Failed to create the value model: %v", err)
+		}
+	}
 
-	// Set initial gas price before sending any transactions
-	gasPrice, err := lib.GetGasPrice(&config)
+	if err := common.ValidateFeeConfig(config.Fees); err != nil {
+		log.Fatal().Err(err).Msgf("Invalid fees config: %v", err)
+	}
+
+	apiVersion, err := lib.NegotiateAPIVersion(&config)
 	if err != nil {
+		log.Fatal().Err(err).Msgf("Failed to negotiate the emissions API version: %v", err)
+	}
+	log.Info().Msgf("Using emissions API %s", apiVersion)
+
+	// Set initial gas price before sending any transactions
+	gasPrice := lib.NewGasPriceOracle(&config)
+	if _, err := gasPrice.Refresh(); err != nil {
 		log.Fatal().Err(err).Msgf("Error getting base fee: %v", err)
 	}
-	lib.SetCurrentGasPrice(gasPrice)
 
-	log.Info().Msgf("Creating and funding %d actors...", numActors)
+	log.Info().Msgf("Creating and funding %d actors...", numActors+numDelegators+numSpareActors)
 	faucet, simulationData := stress.CreateAndFundActors(
 		&config,
+		gasPrice,
 		mnemonic,
-		numActors,
+		numActors+numDelegators+numSpareActors,
 		config.EpochLength,
 		rand.New(rand.NewSource(time.Now().UnixNano())),
 	)
+	simulationData.Delegators = simulationData.Actors[numActors : numActors+numDelegators]
+	simulationData.SpareActors = simulationData.Actors[numActors+numDelegators:]
+	simulationData.Values = valueModel
 	log.Info().Msgf("Successfully created and funded all actors")
 
 	// Create topics
@@ -112,6 +159,7 @@ func main() {
 	err = stress.FundTopics(
 		faucet,
 		topicIds,
+		simulationData.Batcher,
 	)
 	if err != nil {
 		log.Fatal().Err(err).Msgf("Error funding topics: %v", err)
@@ -122,6 +170,8 @@ func main() {
 		&config,
 		topicIds,
 	)
+	simulationData.FaultStats.LogSummary()
+	client.LogHTTPStats()
 	if err != nil {
 		log.Fatal().Err(err).Msgf("Error starting actor loops: %v", err)
 	}
@@ -3,6 +3,7 @@ module github.com/allora-network/allora-simulator
 go 1.23.2
 
 require (
+	cosmossdk.io/errors v1.0.1
 	cosmossdk.io/math v1.4.0
 	github.com/allora-network/allora-chain v0.10.0-beta3
 	github.com/cometbft/cometbft v0.38.17
@@ -11,6 +12,7 @@ require (
 	github.com/decred/dcrd/dcrec/secp256k1/v4 v4.3.0
 	github.com/rs/zerolog v1.33.0
 	github.com/skip-mev/feemarket v1.1.1
+	google.golang.org/grpc v1.70.0
 )
 
 require (
@@ -18,7 +20,6 @@ require (
 	cosmossdk.io/collections v0.4.0 // indirect
 	cosmossdk.io/core v0.11.2 // indirect
 	cosmossdk.io/depinject v1.1.0 // indirect
-	cosmossdk.io/errors v1.0.1 // indirect
 	cosmossdk.io/log v1.4.1 // indirect
 	cosmossdk.io/store v1.1.1 // indirect
 	cosmossdk.io/x/tx v0.13.7 // indirect
@@ -147,7 +148,6 @@ require (
 	google.golang.org/genproto v0.0.0-20240701130421-f6361c86f094 // indirect
 	google.golang.org/genproto/googleapis/api v0.0.0-20241202173237-19429a94021a // indirect
 	google.golang.org/genproto/googleapis/rpc v0.0.0-20241202173237-19429a94021a // indirect
-	google.golang.org/grpc v1.70.0 // indirect
 	google.golang.org/protobuf v1.36.4 // indirect
 	gopkg.in/ini.v1 v1.67.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
@@ -0,0 +1,141 @@
+package lib
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	emissionstypes "github.com/allora-network/allora-chain/x/emissions/types"
+	"github.com/allora-network/allora-simulator/client"
+	"github.com/allora-network/allora-simulator/types"
+	"github.com/cosmos/gogoproto/proto"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Emissions API versions the REST queries understand, newest first. Every route queried is
+// served by each of them with the same emissions.v3 types in the responses.
+var SupportedAPIVersions = []string{"v9", "v8", "v7", "v6", "v5"}
+
+// Versions newer than the supported ones probed to tell which one an unsupported chain serves
+const newerAPIVersionsProbed = 5
+
+// CompiledAPIVersion is the emissions API version of the types built in. Messages and gRPC
+// queries use it whatever the chain serves.
+var CompiledAPIVersion = compiledAPIVersion()
+
+// The version is the second part of the proto package, as in emissions.v9
+func compiledAPIVersion() string {
+	parts := strings.Split(proto.MessageName(&emissionstypes.GetParamsRequest{}), ".")
+	if len(parts) < 2 {
+		panic("unexpected emissions proto package: " + strings.Join(parts, "."))
+	}
+	return parts[1]
+}
+
+func isSupportedAPIVersion(version string) bool {
+	for _, supported := range SupportedAPIVersions {
+		if version == supported {
+			return true
+		}
+	}
+	return false
+}
+
+// Version of the emissions REST routes of the run, the compiled one until negotiated
+func apiVersion(config *types.Config) (string, error) {
+	if config.Nodes.APIVersion == "" {
+		return CompiledAPIVersion, nil
+	}
+	if !isSupportedAPIVersion(config.Nodes.APIVersion) {
+		return "", fmt.Errorf("unsupported emissions API version %s, supported are %s", config.Nodes.APIVersion, strings.Join(SupportedAPIVersions, ", "))
+	}
+	return config.Nodes.APIVersion, nil
+}
+
+// URL of an emissions REST route in the version of the run
+func emissionsURL(config *types.Config, route string) (string, error) {
+	version, err := apiVersion(config)
+	if err != nil {
+		return "", err
+	}
+	return config.Nodes.API + "/emissions/" + version + route, nil
+}
+
+// Whether the API serves the emissions routes of a version. Unknown routes answer with an error
+// body instead of the params.
+func servesAPIVersion(config *types.Config, version string) bool {
+	resp, err := client.HTTPGet(config.Nodes.API + "/emissions/" + version + "/params")
+	if err != nil {
+		return false
+	}
+	var paramsRes struct {
+		Params json.RawMessage `json:"params"`
+	}
+	if err := json.Unmarshal(resp, &paramsRes); err != nil {
+		return false
+	}
+	return len(paramsRes.Params) > 0
+}
+
+// NegotiateAPIVersion finds the emissions API version the chain serves and sets it in the config.
+// A version set in the config is checked rather than detected. Over gRPC only the compiled
+// version can be queried.
+func NegotiateAPIVersion(config *types.Config) (string, error) {
+	if UsesGRPC(config) {
+		if config.Nodes.APIVersion != "" && config.Nodes.APIVersion != CompiledAPIVersion {
+			return "", fmt.Errorf("transport %s only queries the compiled emissions API version %s, got %s", TransportGRPC, CompiledAPIVersion, config.Nodes.APIVersion)
+		}
+		_, err := queryEmissions(config, func(ctx context.Context, q emissionstypes.QueryServiceClient) (*emissionstypes.GetParamsResponse, error) {
+			return q.GetParams(ctx, &emissionstypes.GetParamsRequest{})
+		})
+		if status.Code(err) == codes.Unimplemented {
+			return "", fmt.Errorf("the node does not serve the emissions %s query service", CompiledAPIVersion)
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to query the emissions params: %w", err)
+		}
+		config.Nodes.APIVersion = CompiledAPIVersion
+	} else if config.Nodes.APIVersion != "" {
+		if _, err := apiVersion(config); err != nil {
+			return "", err
+		}
+		if !servesAPIVersion(config, config.Nodes.APIVersion) {
+			return "", fmt.Errorf("the node does not serve the emissions API version %s", config.Nodes.APIVersion)
+		}
+	} else {
+		version, err := detectAPIVersion(config)
+		if err != nil {
+			return "", err
+		}
+		config.Nodes.APIVersion = version
+	}
+
+	if config.Nodes.APIVersion != CompiledAPIVersion {
+		log.Warn().Msgf("The chain serves emissions %s but messages are built for %s, transactions may be rejected", config.Nodes.APIVersion, CompiledAPIVersion)
+	}
+	return config.Nodes.APIVersion, nil
+}
+
+// Probe the supported versions newest first, then newer ones to name the version of an unsupported chain
+func detectAPIVersion(config *types.Config) (string, error) {
+	for _, version := range SupportedAPIVersions {
+		if servesAPIVersion(config, version) {
+			return version, nil
+		}
+	}
+	newest, err := strconv.Atoi(strings.TrimPrefix(SupportedAPIVersions[0], "v"))
+	if err != nil {
+		return "", fmt.Errorf("invalid supported version %s: %w", SupportedAPIVersions[0], err)
+	}
+	for n := newest + newerAPIVersionsProbed; n > newest; n-- {
+		version := "v" + strconv.Itoa(n)
+		if servesAPIVersion(config, version) {
+			return "", fmt.Errorf("the chain serves emissions API version %s, supported are %s", version, strings.Join(SupportedAPIVersions, ", "))
+		}
+	}
+	return "", fmt.Errorf("the chain serves none of the supported emissions API versions %s", strings.Join(SupportedAPIVersions, ", "))
+}
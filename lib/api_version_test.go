@@ -0,0 +1,67 @@
+package lib
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/allora-network/allora-simulator/types"
+)
+
+// API serving the params route of the given versions only, like the gateway of a chain
+func newVersionedAPI(versions ...string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, version := range versions {
+			if r.URL.Path == "/emissions/"+version+"/params" {
+				w.Write([]byte(`{"params":{"version":"` + version + `"}}`))
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNotImplemented)
+		w.Write([]byte(`{"code":12,"message":"Not Implemented","details":[]}`))
+	}))
+}
+
+func TestCompiledAPIVersion(t *testing.T) {
+	if !isSupportedAPIVersion(CompiledAPIVersion) {
+		t.Errorf("expected the compiled version %s to be supported", CompiledAPIVersion)
+	}
+}
+
+func TestNegotiateAPIVersion(t *testing.T) {
+	api := newVersionedAPI("v7", "v8")
+	defer api.Close()
+	config := &types.Config{Nodes: types.NodesConfig{API: api.URL}}
+	version, err := NegotiateAPIVersion(config)
+	if err != nil || version != "v8" || config.Nodes.APIVersion != "v8" {
+		t.Fatalf("expected the newest served version v8, got %s and %v", version, err)
+	}
+	url, err := emissionsURL(config, "/next_topic_id")
+	if err != nil || url != api.URL+"/emissions/v8/next_topic_id" {
+		t.Errorf("expected a v8 route, got %s and %v", url, err)
+	}
+
+	// A pinned version is checked against the node
+	config.Nodes.APIVersion = "v7"
+	if version, err := NegotiateAPIVersion(config); err != nil || version != "v7" {
+		t.Errorf("expected the pinned version v7, got %s and %v", version, err)
+	}
+	config.Nodes.APIVersion = "v6"
+	if _, err := NegotiateAPIVersion(config); err == nil {
+		t.Errorf("expected an error for a version the node does not serve")
+	}
+	config.Nodes.APIVersion = "v2"
+	if _, err := emissionsURL(config, "/next_topic_id"); err == nil {
+		t.Errorf("expected an error for an unsupported version")
+	}
+}
+
+func TestNegotiateUnsupportedAPIVersion(t *testing.T) {
+	api := newVersionedAPI("v11")
+	defer api.Close()
+	_, err := NegotiateAPIVersion(&types.Config{Nodes: types.NodesConfig{API: api.URL}})
+	if err == nil || !strings.Contains(err.Error(), "v11") {
+		t.Errorf("expected an error naming v11, got %v", err)
+	}
+}
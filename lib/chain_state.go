@@ -0,0 +1,194 @@
+package lib
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	emissionstypes "github.com/allora-network/allora-chain/x/emissions/types"
+	"github.com/allora-network/allora-simulator/client"
+	"github.com/allora-network/allora-simulator/types"
+	"github.com/cosmos/gogoproto/jsonpb"
+	"github.com/cosmos/gogoproto/proto"
+	feemarkettypes "github.com/skip-mev/feemarket/x/feemarket/types"
+)
+
+// Decode the JSON of a REST response into its proto type. Fields of other chain versions that
+// the compiled types lack are ignored.
+func getProtoJSON[T any, PT interface {
+	*T
+	proto.Message
+}](url string) (*T, error) {
+	resp, err := client.HTTPGet(url)
+	if err != nil {
+		return nil, err
+	}
+	res := PT(new(T))
+	unmarshaler := jsonpb.Unmarshaler{AllowUnknownFields: true}
+	if err := unmarshaler.Unmarshal(bytes.NewReader(resp), res); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal %s result: %w", url, err)
+	}
+	return res, nil
+}
+
+func getEmissionsProto[T any, PT interface {
+	*T
+	proto.Message
+}](config *types.Config, route string) (*T, error) {
+	url, err := emissionsURL(config, route)
+	if err != nil {
+		return nil, err
+	}
+	return getProtoJSON[T, PT](url)
+}
+
+// Get the params of the emissions module
+func GetEmissionsParams(config *types.Config) (emissionstypes.Params, error) {
+	var res *emissionstypes.GetParamsResponse
+	var err error
+	if UsesGRPC(config) {
+		res, err = queryEmissions(config, func(ctx context.Context, q emissionstypes.QueryServiceClient) (*emissionstypes.GetParamsResponse, error) {
+			return q.GetParams(ctx, &emissionstypes.GetParamsRequest{})
+		})
+	} else {
+		res, err = getEmissionsProto[emissionstypes.GetParamsResponse](config, "/params")
+	}
+	if err != nil {
+		return emissionstypes.Params{}, err
+	}
+	return res.Params, nil
+}
+
+// Get the params of the feemarket module
+func GetFeeMarketParams(config *types.Config) (feemarkettypes.Params, error) {
+	var res *feemarkettypes.ParamsResponse
+	var err error
+	if UsesGRPC(config) {
+		var grpcClient *client.GRPCClient
+		grpcClient, err = client.GetGRPCClient(config.Nodes.GRPC)
+		if err != nil {
+			return feemarkettypes.Params{}, fmt.Errorf("failed to create grpc client: %w", err)
+		}
+		ctx, cancel := grpcContext()
+		defer cancel()
+		res, err = feemarkettypes.NewQueryClient(grpcClient.Conn).Params(ctx, &feemarkettypes.ParamsRequest{})
+	} else {
+		res, err = getProtoJSON[feemarkettypes.ParamsResponse](config.Nodes.API + "/feemarket/v1/params")
+	}
+	if err != nil {
+		return feemarkettypes.Params{}, err
+	}
+	return res.Params, nil
+}
+
+// Get a topic with its weight and effective revenue
+func GetTopic(config *types.Config, topicId uint64) (*emissionstypes.GetTopicResponse, error) {
+	if UsesGRPC(config) {
+		return queryEmissions(config, func(ctx context.Context, q emissionstypes.QueryServiceClient) (*emissionstypes.GetTopicResponse, error) {
+			return q.GetTopic(ctx, &emissionstypes.GetTopicRequest{TopicId: topicId})
+		})
+	}
+	return getEmissionsProto[emissionstypes.GetTopicResponse](config, topicPath("topics", topicId))
+}
+
+// Whether a topic is active, that is funded enough to run its epochs
+func IsTopicActive(config *types.Config, topicId uint64) (bool, error) {
+	var res *emissionstypes.IsTopicActiveResponse
+	var err error
+	if UsesGRPC(config) {
+		res, err = queryEmissions(config, func(ctx context.Context, q emissionstypes.QueryServiceClient) (*emissionstypes.IsTopicActiveResponse, error) {
+			return q.IsTopicActive(ctx, &emissionstypes.IsTopicActiveRequest{TopicId: topicId})
+		})
+	} else {
+		res, err = getEmissionsProto[emissionstypes.IsTopicActiveResponse](config, topicPath("is_topic_active", topicId))
+	}
+	if err != nil {
+		return false, err
+	}
+	return res.IsActive, nil
+}
+
+// Get the inferers in the active set of a topic
+func GetActiveInferers(config *types.Config, topicId uint64) ([]string, error) {
+	var res *emissionstypes.GetActiveInferersForTopicResponse
+	var err error
+	if UsesGRPC(config) {
+		res, err = queryEmissions(config, func(ctx context.Context, q emissionstypes.QueryServiceClient) (*emissionstypes.GetActiveInferersForTopicResponse, error) {
+			return q.GetActiveInferersForTopic(ctx, &emissionstypes.GetActiveInferersForTopicRequest{TopicId: topicId})
+		})
+	} else {
+		res, err = getEmissionsProto[emissionstypes.GetActiveInferersForTopicResponse](config, topicPath("active_inferers", topicId))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return res.Inferers, nil
+}
+
+// Get the forecasters in the active set of a topic
+func GetActiveForecasters(config *types.Config, topicId uint64) ([]string, error) {
+	var res *emissionstypes.GetActiveForecastersForTopicResponse
+	var err error
+	if UsesGRPC(config) {
+		res, err = queryEmissions(config, func(ctx context.Context, q emissionstypes.QueryServiceClient) (*emissionstypes.GetActiveForecastersForTopicResponse, error) {
+			return q.GetActiveForecastersForTopic(ctx, &emissionstypes.GetActiveForecastersForTopicRequest{TopicId: topicId})
+		})
+	} else {
+		res, err = getEmissionsProto[emissionstypes.GetActiveForecastersForTopicResponse](config, topicPath("active_forecasters", topicId))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return res.Forecasters, nil
+}
+
+// Get the reputers in the active set of a topic
+func GetActiveReputers(config *types.Config, topicId uint64) ([]string, error) {
+	var res *emissionstypes.GetActiveReputersForTopicResponse
+	var err error
+	if UsesGRPC(config) {
+		res, err = queryEmissions(config, func(ctx context.Context, q emissionstypes.QueryServiceClient) (*emissionstypes.GetActiveReputersForTopicResponse, error) {
+			return q.GetActiveReputersForTopic(ctx, &emissionstypes.GetActiveReputersForTopicRequest{TopicId: topicId})
+		})
+	} else {
+		res, err = getEmissionsProto[emissionstypes.GetActiveReputersForTopicResponse](config, topicPath("active_reputers", topicId))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return res.Reputers, nil
+}
+
+// Whether an address is registered as a worker of a topic
+func IsWorkerRegistered(config *types.Config, topicId uint64, address string) (bool, error) {
+	var res *emissionstypes.IsWorkerRegisteredInTopicIdResponse
+	var err error
+	if UsesGRPC(config) {
+		res, err = queryEmissions(config, func(ctx context.Context, q emissionstypes.QueryServiceClient) (*emissionstypes.IsWorkerRegisteredInTopicIdResponse, error) {
+			return q.IsWorkerRegisteredInTopicId(ctx, &emissionstypes.IsWorkerRegisteredInTopicIdRequest{TopicId: topicId, Address: address})
+		})
+	} else {
+		res, err = getEmissionsProto[emissionstypes.IsWorkerRegisteredInTopicIdResponse](config, topicPath("worker_registered", topicId, address))
+	}
+	if err != nil {
+		return false, err
+	}
+	return res.IsRegistered, nil
+}
+
+// Whether an address is registered as a reputer of a topic
+func IsReputerRegistered(config *types.Config, topicId uint64, address string) (bool, error) {
+	var res *emissionstypes.IsReputerRegisteredInTopicIdResponse
+	var err error
+	if UsesGRPC(config) {
+		res, err = queryEmissions(config, func(ctx context.Context, q emissionstypes.QueryServiceClient) (*emissionstypes.IsReputerRegisteredInTopicIdResponse, error) {
+			return q.IsReputerRegisteredInTopicId(ctx, &emissionstypes.IsReputerRegisteredInTopicIdRequest{TopicId: topicId, Address: address})
+		})
+	} else {
+		res, err = getEmissionsProto[emissionstypes.IsReputerRegisteredInTopicIdResponse](config, topicPath("reputer_registered", topicId, address))
+	}
+	if err != nil {
+		return false, err
+	}
+	return res.IsRegistered, nil
+}
@@ -0,0 +1,82 @@
+package lib
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	abci "github.com/cometbft/cometbft/abci/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/gogoproto/proto"
+
+	emissionstypes "github.com/allora-network/allora-chain/x/emissions/types"
+	"github.com/allora-network/allora-simulator/client"
+	"github.com/allora-network/allora-simulator/types"
+)
+
+// Emissions events emitted by the chain when it closes an epoch
+type EmissionsEvents struct {
+	Height       int64
+	Scores       []*emissionstypes.EventScoresSet
+	Rewards      []*emissionstypes.EventRewardsSettled
+	TopicRewards []*emissionstypes.EventTopicRewardsSet
+}
+
+// Get the latest block height of the chain
+func GetLatestBlockHeight(config *types.Config) (int64, error) {
+	c, err := client.GetClient(config.Nodes.RPC[0])
+	if err != nil {
+		return 0, err
+	}
+	return c.LatestBlockHeight(context.Background())
+}
+
+// Get the scores, rewards and topic emissions the emissions module emitted at the end of a block
+func GetEmissionsEventsAtBlock(config *types.Config, height int64) (*EmissionsEvents, error) {
+	c, err := client.GetClient(config.Nodes.RPC[0])
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := c.Client.BlockResults(context.Background(), &height)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get block results at height %d: %w", height, err)
+	}
+
+	events := &EmissionsEvents{Height: height}
+	for _, event := range res.FinalizeBlockEvents {
+		switch event.Type {
+		case proto.MessageName(&emissionstypes.EventScoresSet{}),
+			proto.MessageName(&emissionstypes.EventRewardsSettled{}),
+			proto.MessageName(&emissionstypes.EventTopicRewardsSet{}):
+		default:
+			continue
+		}
+
+		msg, err := parseTypedEvent(event)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s at height %d: %w", event.Type, height, err)
+		}
+		switch e := msg.(type) {
+		case *emissionstypes.EventScoresSet:
+			events.Scores = append(events.Scores, e)
+		case *emissionstypes.EventRewardsSettled:
+			events.Rewards = append(events.Rewards, e)
+		case *emissionstypes.EventTopicRewardsSet:
+			events.TopicRewards = append(events.TopicRewards, e)
+		}
+	}
+
+	return events, nil
+}
+
+// Typed events carry JSON attributes, the mode attribute added by the SDK is not JSON and is dropped
+func parseTypedEvent(event abci.Event) (proto.Message, error) {
+	attributes := make([]abci.EventAttribute, 0, len(event.Attributes))
+	for _, attr := range event.Attributes {
+		if json.Valid([]byte(attr.Value)) {
+			attributes = append(attributes, attr)
+		}
+	}
+	return sdk.ParseTypedEvent(abci.Event{Type: event.Type, Attributes: attributes})
+}
@@ -0,0 +1,44 @@
+package lib
+
+import (
+	"testing"
+
+	alloramath "github.com/allora-network/allora-chain/math"
+	emissionstypes "github.com/allora-network/allora-chain/x/emissions/types"
+	abci "github.com/cometbft/cometbft/abci/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+func TestParseTypedEventIgnoresMode(t *testing.T) {
+	event, err := sdk.TypedEventToEvent(&emissionstypes.EventRewardsSettled{
+		ActorType:     emissionstypes.ActorType_ACTOR_TYPE_REPUTER,
+		TopicId:       3,
+		BlockHeight:   120,
+		Addresses:     []string{"allo1a", "allo1b"},
+		Rewards:       []alloramath.Dec{alloramath.MustNewDecFromString("1.5"), alloramath.MustNewDecFromString("0.25")},
+		BlockHeightTx: 130,
+	})
+	if err != nil {
+		t.Fatalf("failed to build event: %v", err)
+	}
+	// Added by the SDK to events emitted while finalizing a block
+	event.Attributes = append(event.Attributes, abci.EventAttribute{Key: "mode", Value: "EndBlock"})
+
+	msg, err := parseTypedEvent(abci.Event(event))
+	if err != nil {
+		t.Fatalf("parseTypedEvent unexpected error: %v", err)
+	}
+	rewards, ok := msg.(*emissionstypes.EventRewardsSettled)
+	if !ok {
+		t.Fatalf("parseTypedEvent returned %T, want *EventRewardsSettled", msg)
+	}
+	if rewards.TopicId != 3 || rewards.BlockHeight != 120 || len(rewards.Addresses) != 2 {
+		t.Errorf("unexpected event: %+v", rewards)
+	}
+	if rewards.ActorType != emissionstypes.ActorType_ACTOR_TYPE_REPUTER {
+		t.Errorf("actor type = %v, want reputer", rewards.ActorType)
+	}
+	if rewards.Rewards[1].String() != "0.25" {
+		t.Errorf("second reward = %s, want 0.25", rewards.Rewards[1])
+	}
+}
@@ -4,28 +4,174 @@ import (
 	"encoding/json"
 	"fmt"
 	"strconv"
+	"sync"
+	"time"
 
 	"github.com/allora-network/allora-simulator/client"
 	"github.com/allora-network/allora-simulator/types"
+	"github.com/rs/zerolog/log"
 	feemarkettypes "github.com/skip-mev/feemarket/x/feemarket/types"
 )
 
-// Keeps track of the current gas price
-var gasPrice float64 = 0
+// Gas prices kept for the strategies pricing over the recent fee market
+const gasPriceHistorySize = 100
 
-// GetCurrentGasPrice returns the current gas price
-func GetCurrentGasPrice() float64 {
-	return gasPrice
+// Failed queries in a row after which the gas price is stale
+const staleAfterFailures = 5
+
+// How often Run queries the gas price
+const gasPriceRefreshInterval = 2 * time.Second
+
+// GasPriceSample is the gas price at the time it was queried
+type GasPriceSample struct {
+	Price float64
+	Time  time.Time
+}
+
+// GasPriceOracle keeps the gas price of the fee market the actors pay, with its recent history.
+// It is safe for concurrent use.
+type GasPriceOracle struct {
+	fetch func() (float64, error)
+
+	mu          sync.RWMutex
+	price       float64
+	history     []GasPriceSample
+	lastUpdate  time.Time
+	failures    int
+	subscribers map[<-chan float64]chan float64
+}
+
+// NewGasPriceOracle creates an oracle querying the gas price of the fee denom of config
+func NewGasPriceOracle(config *types.Config) *GasPriceOracle {
+	return newGasPriceOracle(func() (float64, error) {
+		return GetGasPrice(config)
+	})
+}
+
+func newGasPriceOracle(fetch func() (float64, error)) *GasPriceOracle {
+	return &GasPriceOracle{
+		fetch:       fetch,
+		subscribers: make(map[<-chan float64]chan float64),
+	}
+}
+
+// Price returns the current gas price
+func (o *GasPriceOracle) Price() float64 {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	return o.price
+}
+
+// History returns the recent gas prices, oldest first
+func (o *GasPriceOracle) History() []GasPriceSample {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	history := make([]GasPriceSample, len(o.history))
+	copy(history, o.history)
+	return history
+}
+
+// LastUpdate returns when the gas price was last set, zero before it ever was
+func (o *GasPriceOracle) LastUpdate() time.Time {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	return o.lastUpdate
+}
+
+// Stale reports whether the last queries of the gas price all failed, the price may be outdated
+func (o *GasPriceOracle) Stale() bool {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	return o.failures >= staleAfterFailures
+}
+
+// Set records a new gas price and notifies the subscribers when it changed
+func (o *GasPriceOracle) Set(price float64) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.failures >= staleAfterFailures {
+		log.Info().Msgf("Gas price is up to date again after %d failed queries", o.failures)
+	}
+	changed := price != o.price
+	o.price = price
+	o.lastUpdate = time.Now()
+	o.failures = 0
+	o.history = append(o.history, GasPriceSample{Price: price, Time: o.lastUpdate})
+	if len(o.history) > gasPriceHistorySize {
+		o.history = o.history[len(o.history)-gasPriceHistorySize:]
+	}
+	if !changed {
+		return
+	}
+	for _, ch := range o.subscribers {
+		// Subscribers only need the latest price, replace the one they have not read yet
+		select {
+		case <-ch:
+		default:
+		}
+		ch <- price
+	}
+}
+
+// Refresh queries the gas price and sets it. A failed query leaves the current price, and the price
+// becomes stale once enough queries failed in a row.
+func (o *GasPriceOracle) Refresh() (float64, error) {
+	price, err := o.fetch()
+	if err != nil {
+		o.mu.Lock()
+		defer o.mu.Unlock()
+		o.failures++
+		if o.failures == staleAfterFailures {
+			log.Warn().Msgf("Gas price is stale, the last %d queries failed, using %v since %s", o.failures, o.price, o.lastUpdate.Format(time.RFC3339))
+		}
+		return o.price, err
+	}
+	o.Set(price)
+	return price, nil
+}
+
+// Run refreshes the gas price periodically, forever
+func (o *GasPriceOracle) Run() {
+	o.RunUntil(nil)
+}
+
+// RunUntil refreshes the gas price periodically until stop is closed
+func (o *GasPriceOracle) RunUntil(stop <-chan struct{}) {
+	for {
+		if _, err := o.Refresh(); err != nil {
+			log.Error().Err(err).Msgf("Error getting base fee, will retry: %v", err)
+		}
+		select {
+		case <-stop:
+			return
+		case <-time.After(gasPriceRefreshInterval):
+		}
+	}
 }
 
-// SetCurrentGasPrice sets the current gas price
-func SetCurrentGasPrice(price float64) {
-	gasPrice = price
+// Subscribe returns a channel receiving the gas price whenever it changes.
+// A slow subscriber only gets the latest price.
+func (o *GasPriceOracle) Subscribe() <-chan float64 {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	ch := make(chan float64, 1)
+	o.subscribers[ch] = ch
+	return ch
 }
 
-// GetGasPrice queries the current gas price from the feemarket module
+// Unsubscribe stops the notifications of a channel returned by Subscribe
+func (o *GasPriceOracle) Unsubscribe(ch <-chan float64) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	delete(o.subscribers, ch)
+}
+
+// GetGasPrice queries the current gas price of the fee denom from the feemarket module
 func GetGasPrice(config *types.Config) (float64, error) {
-	resp, err := client.HTTPGet(config.Nodes.API + "/feemarket/v1/gas_price/uallo")
+	if UsesGRPC(config) {
+		return grpcGetGasPrice(config)
+	}
+	resp, err := client.HTTPGet(config.Nodes.API + "/feemarket/v1/gas_price/" + config.Denom)
 	if err != nil {
 		return 0, err
 	}
@@ -44,3 +190,24 @@ func GetGasPrice(config *types.Config) (float64, error) {
 
 	return gasPrice, nil
 }
+
+// GetFeeMarketMaxBlockUtilization queries the gas of a full block for the feemarket module
+func GetFeeMarketMaxBlockUtilization(config *types.Config) (uint64, error) {
+	if UsesGRPC(config) {
+		return grpcGetFeeMarketMaxBlockUtilization(config)
+	}
+	resp, err := client.HTTPGet(config.Nodes.API + "/feemarket/v1/params")
+	if err != nil {
+		return 0, err
+	}
+	// Integers are quoted in the JSON of the API
+	var paramsRes struct {
+		Params struct {
+			MaxBlockUtilization uint64 `json:"max_block_utilization,string"`
+		} `json:"params"`
+	}
+	if err := json.Unmarshal(resp, &paramsRes); err != nil {
+		return 0, fmt.Errorf("failed to unmarshal feemarket params: %w", err)
+	}
+	return paramsRes.Params.MaxBlockUtilization, nil
+}
@@ -0,0 +1,95 @@
+package lib
+
+import (
+	"errors"
+	"sync"
+	"testing"
+)
+
+func TestGasPriceOracle(t *testing.T) {
+	var fetchErr error
+	price := 1.0
+	oracle := newGasPriceOracle(func() (float64, error) {
+		return price, fetchErr
+	})
+	updates := oracle.Subscribe()
+
+	if _, err := oracle.Refresh(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if oracle.Price() != 1 || oracle.LastUpdate().IsZero() || oracle.Stale() {
+		t.Errorf("expected a fresh price of 1, got %v", oracle.Price())
+	}
+	if got := <-updates; got != 1 {
+		t.Errorf("expected the subscriber to get 1, got %v", got)
+	}
+
+	// An unread update is replaced by the latest one
+	oracle.Set(2)
+	oracle.Set(3)
+	if got := <-updates; got != 3 {
+		t.Errorf("expected the subscriber to get the latest price 3, got %v", got)
+	}
+	// The same price again is no change
+	oracle.Set(3)
+	select {
+	case got := <-updates:
+		t.Errorf("expected no update for an unchanged price, got %v", got)
+	default:
+	}
+
+	fetchErr = errors.New("unavailable")
+	for i := 0; i < staleAfterFailures; i++ {
+		if got, err := oracle.Refresh(); err == nil || got != 3 {
+			t.Fatalf("expected an error and the last price 3, got %v and %v", got, err)
+		}
+	}
+	if !oracle.Stale() {
+		t.Errorf("expected the price to be stale after %d failures", staleAfterFailures)
+	}
+	fetchErr = nil
+	price = 4
+	if _, err := oracle.Refresh(); err != nil || oracle.Stale() {
+		t.Errorf("expected a successful query to clear the staleness, got %v", err)
+	}
+	if got := <-updates; got != 4 {
+		t.Errorf("expected the subscriber to get 4, got %v", got)
+	}
+
+	history := oracle.History()
+	if len(history) != 5 || history[0].Price != 1 || history[4].Price != 4 {
+		t.Errorf("expected the 5 prices set in order, got %v", history)
+	}
+
+	oracle.Unsubscribe(updates)
+	oracle.Set(5)
+	select {
+	case got := <-updates:
+		t.Errorf("expected no update after unsubscribing, got %v", got)
+	default:
+	}
+}
+
+func TestGasPriceOracleConcurrentUse(t *testing.T) {
+	oracle := newGasPriceOracle(func() (float64, error) { return 1, nil })
+	for i := 0; i < gasPriceHistorySize; i++ {
+		oracle.Set(float64(i))
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			oracle.Refresh()
+		}()
+		go func() {
+			defer wg.Done()
+			_ = oracle.Price()
+			_ = oracle.History()
+		}()
+	}
+	wg.Wait()
+	if len(oracle.History()) != gasPriceHistorySize {
+		t.Errorf("expected the history to be capped at %d, got %d", gasPriceHistorySize, len(oracle.History()))
+	}
+}
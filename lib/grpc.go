@@ -0,0 +1,329 @@
+package lib
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	cosmosmath "cosmossdk.io/math"
+	emissionstypes "github.com/allora-network/allora-chain/x/emissions/types"
+	"github.com/allora-network/allora-simulator/client"
+	"github.com/allora-network/allora-simulator/types"
+	sdktypes "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/query"
+	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	feemarkettypes "github.com/skip-mev/feemarket/x/feemarket/types"
+)
+
+// Same as the timeout of the HTTP client
+const grpcQueryTimeout = 10 * time.Second
+
+func grpcContext() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), grpcQueryTimeout)
+}
+
+func emissionsQueryClient(config *types.Config) (emissionstypes.QueryServiceClient, error) {
+	grpcClient, err := client.GetGRPCClient(config.Nodes.GRPC)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create grpc client: %w", err)
+	}
+	return emissionstypes.NewQueryServiceClient(grpcClient.Conn), nil
+}
+
+// Run an emissions query through gRPC
+func queryEmissions[T any](config *types.Config, query func(context.Context, emissionstypes.QueryServiceClient) (T, error)) (T, error) {
+	queryClient, err := emissionsQueryClient(config)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	ctx, cancel := grpcContext()
+	defer cancel()
+	return query(ctx, queryClient)
+}
+
+func grpcGetAccountInfo(address string, config *types.Config) (seqint, accnum uint64, err error) {
+	grpcClient, err := client.GetGRPCClient(config.Nodes.GRPC)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to create grpc client: %w", err)
+	}
+	ctx, cancel := grpcContext()
+	defer cancel()
+	res, err := authtypes.NewQueryClient(grpcClient.Conn).AccountInfo(ctx, &authtypes.QueryAccountInfoRequest{Address: address})
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get initial sequence: %v", err)
+	}
+	return res.Info.Sequence, res.Info.AccountNumber, nil
+}
+
+func grpcGetAccountBalance(address string, config *types.Config) (cosmosmath.Int, error) {
+	grpcClient, err := client.GetGRPCClient(config.Nodes.GRPC)
+	if err != nil {
+		return cosmosmath.ZeroInt(), fmt.Errorf("failed to create grpc client: %w", err)
+	}
+	ctx, cancel := grpcContext()
+	defer cancel()
+	// All balances rather than the balance of the denom, which is zero instead of missing
+	balances, err := CollectPages(0, func(req PageRequest) (Page[sdktypes.Coin], error) {
+		res, err := banktypes.NewQueryClient(grpcClient.Conn).AllBalances(ctx, &banktypes.QueryAllBalancesRequest{
+			Address:    address,
+			Pagination: grpcPageRequest(req),
+		})
+		if err != nil {
+			return Page[sdktypes.Coin]{}, err
+		}
+		return grpcPage(res.Balances, res.Pagination), nil
+	})
+	if err != nil {
+		return cosmosmath.ZeroInt(), err
+	}
+	for _, coin := range balances {
+		if coin.Denom == config.Denom {
+			return coin.Amount, nil
+		}
+	}
+	return cosmosmath.ZeroInt(), fmt.Errorf("denomination %s %w", config.Denom, ErrNoBalance)
+}
+
+func grpcPageRequest(req PageRequest) *query.PageRequest {
+	return &query.PageRequest{
+		Key:        req.Key,
+		Offset:     req.Offset,
+		Limit:      req.Limit,
+		CountTotal: len(req.Key) == 0 && req.Offset == 0,
+	}
+}
+
+func grpcPage[T any](items []T, pagination *query.PageResponse) Page[T] {
+	page := Page[T]{Items: items}
+	if pagination != nil {
+		page.NextKey = pagination.NextKey
+		page.Total = pagination.Total
+	}
+	return page
+}
+
+func grpcGetGasPrice(config *types.Config) (float64, error) {
+	grpcClient, err := client.GetGRPCClient(config.Nodes.GRPC)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create grpc client: %w", err)
+	}
+	ctx, cancel := grpcContext()
+	defer cancel()
+	res, err := feemarkettypes.NewQueryClient(grpcClient.Conn).GasPrice(ctx, &feemarkettypes.GasPriceRequest{Denom: config.Denom})
+	if err != nil {
+		return 0, err
+	}
+	gasPrice, err := strconv.ParseFloat(res.Price.Amount.String(), 64)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse gas price: %w", err)
+	}
+	return gasPrice, nil
+}
+
+func grpcGetFeeMarketMaxBlockUtilization(config *types.Config) (uint64, error) {
+	grpcClient, err := client.GetGRPCClient(config.Nodes.GRPC)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create grpc client: %w", err)
+	}
+	ctx, cancel := grpcContext()
+	defer cancel()
+	res, err := feemarkettypes.NewQueryClient(grpcClient.Conn).Params(ctx, &feemarkettypes.ParamsRequest{})
+	if err != nil {
+		return 0, err
+	}
+	return res.Params.MaxBlockUtilization, nil
+}
+
+func grpcGetNextTopicId(config *types.Config) (uint64, error) {
+	return queryEmissions(config, func(ctx context.Context, q emissionstypes.QueryServiceClient) (uint64, error) {
+		res, err := q.GetNextTopicId(ctx, &emissionstypes.GetNextTopicIdRequest{})
+		if err != nil {
+			return 0, err
+		}
+		return res.NextTopicId, nil
+	})
+}
+
+func grpcGetUnfulfilledWorkerNonces(config *types.Config, topicId uint64) ([]int64, error) {
+	return queryEmissions(config, func(ctx context.Context, q emissionstypes.QueryServiceClient) ([]int64, error) {
+		res, err := q.GetUnfulfilledWorkerNonces(ctx, &emissionstypes.GetUnfulfilledWorkerNoncesRequest{TopicId: topicId})
+		if err != nil {
+			return nil, err
+		}
+		heights := make([]int64, 0)
+		if res.Nonces == nil {
+			return heights, nil
+		}
+		for _, nonce := range res.Nonces.Nonces {
+			heights = append(heights, nonce.BlockHeight)
+		}
+		return heights, nil
+	})
+}
+
+func grpcGetUnfulfilledReputerNonces(config *types.Config, topicId uint64) ([]int64, error) {
+	return queryEmissions(config, func(ctx context.Context, q emissionstypes.QueryServiceClient) ([]int64, error) {
+		res, err := q.GetUnfulfilledReputerNonces(ctx, &emissionstypes.GetUnfulfilledReputerNoncesRequest{TopicId: topicId})
+		if err != nil {
+			return nil, err
+		}
+		heights := make([]int64, 0)
+		if res.Nonces == nil {
+			return heights, nil
+		}
+		for _, nonce := range res.Nonces.Nonces {
+			if nonce.ReputerNonce == nil {
+				return nil, fmt.Errorf("reputer request nonce without a reputer nonce")
+			}
+			heights = append(heights, nonce.ReputerNonce.BlockHeight)
+		}
+		return heights, nil
+	})
+}
+
+func grpcGetActiveWorkersForTopic(config *types.Config, topicId uint64, blockHeight int64) ([]string, error) {
+	return queryEmissions(config, func(ctx context.Context, q emissionstypes.QueryServiceClient) ([]string, error) {
+		res, err := q.GetInferencesAtBlock(ctx, &emissionstypes.GetInferencesAtBlockRequest{TopicId: topicId, BlockHeight: blockHeight})
+		if err != nil {
+			return []string{}, err
+		}
+		workers := make([]string, 0)
+		if res.Inferences == nil {
+			return workers, nil
+		}
+		for _, inference := range res.Inferences.Inferences {
+			workers = append(workers, inference.Inferer)
+		}
+		return workers, nil
+	})
+}
+
+func grpcGetNetworkInferencesAtBlock(config *types.Config, topicId uint64, blockHeight int64) (*emissionstypes.ValueBundle, error) {
+	return queryEmissions(config, func(ctx context.Context, q emissionstypes.QueryServiceClient) (*emissionstypes.ValueBundle, error) {
+		res, err := q.GetNetworkInferencesAtBlock(ctx, &emissionstypes.GetNetworkInferencesAtBlockRequest{
+			TopicId:                  topicId,
+			BlockHeightLastInference: blockHeight,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get reputer values: %v", err)
+		}
+		if res.NetworkInferences == nil {
+			return nil, fmt.Errorf("no network inferences found")
+		}
+		return res.NetworkInferences, nil
+	})
+}
+
+func scoresOf(scores *emissionstypes.Scores) []emissionstypes.Score {
+	res := make([]emissionstypes.Score, 0)
+	if scores == nil {
+		return res
+	}
+	for _, score := range scores.Scores {
+		if score != nil {
+			res = append(res, *score)
+		}
+	}
+	return res
+}
+
+func scoreOf(score *emissionstypes.Score) (emissionstypes.Score, error) {
+	if score == nil {
+		return emissionstypes.Score{}, fmt.Errorf("no score found")
+	}
+	return *score, nil
+}
+
+func regretOf(regret *emissionstypes.TimestampedValue) (emissionstypes.TimestampedValue, error) {
+	if regret == nil {
+		return emissionstypes.TimestampedValue{}, fmt.Errorf("no regret found")
+	}
+	return *regret, nil
+}
+
+func grpcGetInfererScoresAtBlock(config *types.Config, topicId uint64, blockHeight int64) ([]emissionstypes.Score, error) {
+	return queryEmissions(config, func(ctx context.Context, q emissionstypes.QueryServiceClient) ([]emissionstypes.Score, error) {
+		res, err := q.GetWorkerInferenceScoresAtBlock(ctx, &emissionstypes.GetWorkerInferenceScoresAtBlockRequest{TopicId: topicId, BlockHeight: blockHeight})
+		if err != nil {
+			return nil, err
+		}
+		return scoresOf(res.Scores), nil
+	})
+}
+
+func grpcGetForecasterScoresAtBlock(config *types.Config, topicId uint64, blockHeight int64) ([]emissionstypes.Score, error) {
+	return queryEmissions(config, func(ctx context.Context, q emissionstypes.QueryServiceClient) ([]emissionstypes.Score, error) {
+		res, err := q.GetWorkerForecastScoresAtBlock(ctx, &emissionstypes.GetWorkerForecastScoresAtBlockRequest{TopicId: topicId, BlockHeight: blockHeight})
+		if err != nil {
+			return nil, err
+		}
+		return scoresOf(res.Scores), nil
+	})
+}
+
+func grpcGetReputerScoresAtBlock(config *types.Config, topicId uint64, blockHeight int64) ([]emissionstypes.Score, error) {
+	return queryEmissions(config, func(ctx context.Context, q emissionstypes.QueryServiceClient) ([]emissionstypes.Score, error) {
+		res, err := q.GetReputersScoresAtBlock(ctx, &emissionstypes.GetReputersScoresAtBlockRequest{TopicId: topicId, BlockHeight: blockHeight})
+		if err != nil {
+			return nil, err
+		}
+		return scoresOf(res.Scores), nil
+	})
+}
+
+func grpcGetInfererScoreEma(config *types.Config, topicId uint64, inferer string) (emissionstypes.Score, error) {
+	return queryEmissions(config, func(ctx context.Context, q emissionstypes.QueryServiceClient) (emissionstypes.Score, error) {
+		res, err := q.GetInfererScoreEma(ctx, &emissionstypes.GetInfererScoreEmaRequest{TopicId: topicId, Inferer: inferer})
+		if err != nil {
+			return emissionstypes.Score{}, err
+		}
+		return scoreOf(res.Score)
+	})
+}
+
+func grpcGetForecasterScoreEma(config *types.Config, topicId uint64, forecaster string) (emissionstypes.Score, error) {
+	return queryEmissions(config, func(ctx context.Context, q emissionstypes.QueryServiceClient) (emissionstypes.Score, error) {
+		res, err := q.GetForecasterScoreEma(ctx, &emissionstypes.GetForecasterScoreEmaRequest{TopicId: topicId, Forecaster: forecaster})
+		if err != nil {
+			return emissionstypes.Score{}, err
+		}
+		return scoreOf(res.Score)
+	})
+}
+
+func grpcGetReputerScoreEma(config *types.Config, topicId uint64, reputer string) (emissionstypes.Score, error) {
+	return queryEmissions(config, func(ctx context.Context, q emissionstypes.QueryServiceClient) (emissionstypes.Score, error) {
+		res, err := q.GetReputerScoreEma(ctx, &emissionstypes.GetReputerScoreEmaRequest{TopicId: topicId, Reputer: reputer})
+		if err != nil {
+			return emissionstypes.Score{}, err
+		}
+		return scoreOf(res.Score)
+	})
+}
+
+// Regret queries differ in their request only
+type regretResponse interface {
+	GetRegret() *emissionstypes.TimestampedValue
+}
+
+func grpcGetRegret[R regretResponse](config *types.Config, query func(context.Context, emissionstypes.QueryServiceClient) (R, error)) (emissionstypes.TimestampedValue, error) {
+	return queryEmissions(config, func(ctx context.Context, q emissionstypes.QueryServiceClient) (emissionstypes.TimestampedValue, error) {
+		res, err := query(ctx, q)
+		if err != nil {
+			return emissionstypes.TimestampedValue{}, err
+		}
+		return regretOf(res.GetRegret())
+	})
+}
+
+// Run a stake query through gRPC, a zero stake on errors as with the API
+func grpcGetStake(config *types.Config, query func(context.Context, emissionstypes.QueryServiceClient) (cosmosmath.Int, error)) (cosmosmath.Int, error) {
+	stake, err := queryEmissions(config, query)
+	if err != nil {
+		return cosmosmath.ZeroInt(), err
+	}
+	return stake, nil
+}
@@ -0,0 +1,95 @@
+package lib
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	alloramath "github.com/allora-network/allora-chain/math"
+	emissionstypes "github.com/allora-network/allora-chain/x/emissions/types"
+	"github.com/allora-network/allora-simulator/types"
+	"github.com/cosmos/cosmos-sdk/codec"
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
+	"google.golang.org/grpc"
+)
+
+type fakeEmissionsServer struct {
+	emissionstypes.UnimplementedQueryServiceServer
+}
+
+func (fakeEmissionsServer) GetNextTopicId(context.Context, *emissionstypes.GetNextTopicIdRequest) (*emissionstypes.GetNextTopicIdResponse, error) {
+	return &emissionstypes.GetNextTopicIdResponse{NextTopicId: 7}, nil
+}
+
+func (fakeEmissionsServer) GetNetworkInferencesAtBlock(_ context.Context, req *emissionstypes.GetNetworkInferencesAtBlockRequest) (*emissionstypes.GetNetworkInferencesAtBlockResponse, error) {
+	return &emissionstypes.GetNetworkInferencesAtBlockResponse{NetworkInferences: &emissionstypes.ValueBundle{
+		TopicId:       req.TopicId,
+		CombinedValue: alloramath.MustNewDecFromString("1.5"),
+		NaiveValue:    alloramath.MustNewDecFromString("2"),
+		InfererValues: []*emissionstypes.WorkerAttributedValue{{Worker: "inferer", Value: alloramath.MustNewDecFromString("3")}},
+	}}, nil
+}
+
+func (fakeEmissionsServer) GetWorkerInferenceScoresAtBlock(context.Context, *emissionstypes.GetWorkerInferenceScoresAtBlockRequest) (*emissionstypes.GetWorkerInferenceScoresAtBlockResponse, error) {
+	return &emissionstypes.GetWorkerInferenceScoresAtBlockResponse{}, nil
+}
+
+func TestValidateNodesConfig(t *testing.T) {
+	valid := []types.NodesConfig{
+		{RPC: []string{"rpc"}, API: "api"},
+		{RPC: []string{"rpc"}, API: "api", Transport: TransportREST},
+		{RPC: []string{"rpc"}, GRPC: "grpc", Transport: TransportGRPC},
+	}
+	for _, config := range valid {
+		if err := ValidateNodesConfig(config); err != nil {
+			t.Errorf("expected %+v to be valid, got %v", config, err)
+		}
+	}
+	invalid := []types.NodesConfig{
+		{API: "api"},
+		{RPC: []string{"rpc"}, GRPC: "grpc"},
+		{RPC: []string{"rpc"}, API: "api", Transport: TransportGRPC},
+		{RPC: []string{"rpc"}, API: "api", GRPC: "grpc", Transport: "websocket"},
+	}
+	for _, config := range invalid {
+		if err := ValidateNodesConfig(config); err == nil {
+			t.Errorf("expected %+v to be invalid", config)
+		}
+	}
+}
+
+func TestGRPCQueries(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	protoCodec := codec.NewProtoCodec(codectypes.NewInterfaceRegistry())
+	server := grpc.NewServer(grpc.ForceServerCodec(protoCodec.GRPCCodec()))
+	emissionstypes.RegisterQueryServiceServer(server, &fakeEmissionsServer{})
+	go server.Serve(listener)
+	defer server.Stop()
+
+	config := &types.Config{Nodes: types.NodesConfig{GRPC: listener.Addr().String(), Transport: TransportGRPC}}
+
+	topicId, err := GetNextTopicId(config)
+	if err != nil || topicId != 7 {
+		t.Errorf("expected the next topic id 7, got %d and %v", topicId, err)
+	}
+
+	bundle, err := GetNetworkInferencesAtBlock(config, 3, 100)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if bundle.TopicId != 3 || bundle.CombinedValue.String() != "1.5" || len(bundle.InfererValues) != 1 || bundle.InfererValues[0].Worker != "inferer" {
+		t.Errorf("unexpected network inferences %+v", bundle)
+	}
+
+	scores, err := GetInfererScoresAtBlock(config, 3, 100)
+	if err != nil || len(scores) != 0 {
+		t.Errorf("expected no scores, got %v and %v", scores, err)
+	}
+
+	if _, err := GetNaiveInfererNetworkRegret(config, 3, "inferer"); err == nil {
+		t.Errorf("expected an error for a query the node does not serve")
+	}
+}
@@ -13,10 +13,10 @@ import (
 	"github.com/allora-network/allora-simulator/types"
 )
 
-// Update this when the API version changes
-const ALLORA_API_VERSION = "v9"
-
 func GetAccountInfo(address string, config *types.Config) (seqint, accnum uint64, err error) {
+	if UsesGRPC(config) {
+		return grpcGetAccountInfo(address, config)
+	}
 	resp, err := client.HTTPGet(config.Nodes.API + "/cosmos/auth/v1beta1/accounts/" + address)
 	if err != nil {
 		return 0, 0, fmt.Errorf("failed to get initial sequence: %v", err)
@@ -41,19 +41,39 @@ func GetAccountInfo(address string, config *types.Config) (seqint, accnum uint64
 	return seqint, accnum, nil
 }
 
+// ErrNoBalance is returned by GetAccountBalance for an account without the denom
+var ErrNoBalance = errors.New("not found in account balances")
+
 func GetAccountBalance(address string, config *types.Config) (cosmosmath.Int, error) {
-	resp, err := client.HTTPGet(config.Nodes.API + "/cosmos/bank/v1beta1/balances/" + address)
-	if err != nil {
-		return cosmosmath.ZeroInt(), err
+	if UsesGRPC(config) {
+		return grpcGetAccountBalance(address, config)
 	}
+	balances, err := CollectPages(0, func(req PageRequest) (Page[types.Coin], error) {
+		url, err := pageURL(config.Nodes.API+"/cosmos/bank/v1beta1/balances/"+address, req)
+		if err != nil {
+			return Page[types.Coin]{}, err
+		}
+		resp, err := client.HTTPGet(url)
+		if err != nil {
+			return Page[types.Coin]{}, err
+		}
 
-	var balanceRes types.BalanceResult
-	err = json.Unmarshal(resp, &balanceRes)
+		var balanceRes types.BalanceResult
+		err = json.Unmarshal(resp, &balanceRes)
+		if err != nil {
+			return Page[types.Coin]{}, err
+		}
+		nextKey, total, err := parsePagination(balanceRes.Pagination)
+		if err != nil {
+			return Page[types.Coin]{}, err
+		}
+		return Page[types.Coin]{Items: balanceRes.Balances, NextKey: nextKey, Total: total}, nil
+	})
 	if err != nil {
 		return cosmosmath.ZeroInt(), err
 	}
 
-	for _, coin := range balanceRes.Balances {
+	for _, coin := range balances {
 		if coin.Denom == config.Denom {
 			amount, ok := cosmosmath.NewIntFromString(coin.Amount)
 			if !ok {
@@ -64,11 +84,18 @@ func GetAccountBalance(address string, config *types.Config) (cosmosmath.Int, er
 	}
 
 	// If no balance found for the denom, return zero balance
-	return cosmosmath.ZeroInt(), fmt.Errorf("denomination %s not found in account balances", config.Denom)
+	return cosmosmath.ZeroInt(), fmt.Errorf("denomination %s %w", config.Denom, ErrNoBalance)
 }
 
 func GetNextTopicId(config *types.Config) (uint64, error) {
-	resp, err := client.HTTPGet(config.Nodes.API + "/emissions/" + ALLORA_API_VERSION + "/next_topic_id")
+	if UsesGRPC(config) {
+		return grpcGetNextTopicId(config)
+	}
+	url, err := emissionsURL(config, "/next_topic_id")
+	if err != nil {
+		return 0, err
+	}
+	resp, err := client.HTTPGet(url)
 	if err != nil {
 		return 0, err
 	}
@@ -87,61 +114,117 @@ func GetNextTopicId(config *types.Config) (uint64, error) {
 	return topicId, nil
 }
 
-// Get the latest open worker nonce for a topic
-func GetLatestOpenWorkerNonceByTopicId(config *types.Config, topicId uint64) (int64, error) {
-	resp, err := client.HTTPGet(config.Nodes.API + "/emissions/" + ALLORA_API_VERSION + "/unfulfilled_worker_nonces/" + strconv.FormatUint(topicId, 10))
+// Get the block heights of the unfulfilled worker nonces of a topic
+func GetUnfulfilledWorkerNonces(config *types.Config, topicId uint64) ([]int64, error) {
+	if UsesGRPC(config) {
+		return grpcGetUnfulfilledWorkerNonces(config, topicId)
+	}
+	url, err := emissionsURL(config, "/unfulfilled_worker_nonces/"+strconv.FormatUint(topicId, 10))
 	if err != nil {
-		return 0, err
+		return nil, err
+	}
+	resp, err := client.HTTPGet(url)
+	if err != nil {
+		return nil, err
 	}
 
 	var res types.UnfulfilledWorkerNoncesResult
 	err = json.Unmarshal(resp, &res)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 
-	if len(res.Nonces.Nonces) == 0 {
-		return 0, err
+	heights := make([]int64, len(res.Nonces.Nonces))
+	for i, nonce := range res.Nonces.Nonces {
+		heights[i], err = strconv.ParseInt(nonce.BlockHeight, 10, 64)
+		if err != nil {
+			return nil, err
+		}
 	}
+	return heights, nil
+}
 
-	// Convert to int64
-	blockHeight, err := strconv.ParseInt(res.Nonces.Nonces[0].BlockHeight, 10, 64)
+// Get the block heights of the unfulfilled reputer nonces of a topic
+func GetUnfulfilledReputerNonces(config *types.Config, topicId uint64) ([]int64, error) {
+	if UsesGRPC(config) {
+		return grpcGetUnfulfilledReputerNonces(config, topicId)
+	}
+	url, err := emissionsURL(config, "/unfulfilled_reputer_nonces/"+strconv.FormatUint(topicId, 10))
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
-
-	return blockHeight, nil
-}
-
-// Get the oldest reputer nonce for a topic
-func GetOldestReputerNonceByTopicId(config *types.Config, topicId uint64) (int64, error) {
-	resp, err := client.HTTPGet(config.Nodes.API + "/emissions/" + ALLORA_API_VERSION + "/unfulfilled_reputer_nonces/" + strconv.FormatUint(topicId, 10))
+	resp, err := client.HTTPGet(url)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 
 	var res types.UnfulfilledReputerNoncesResult
 	err = json.Unmarshal(resp, &res)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 
-	if len(res.Nonces.Nonces) == 0 {
-		return 0, nil
+	heights := make([]int64, len(res.Nonces.Nonces))
+	for i, nonce := range res.Nonces.Nonces {
+		heights[i], err = strconv.ParseInt(nonce.ReputerNonce.BlockHeight, 10, 64)
+		if err != nil {
+			return nil, err
+		}
 	}
+	return heights, nil
+}
 
-	// Convert to int64
-	blockHeight, err := strconv.ParseInt(res.Nonces.Nonces[len(res.Nonces.Nonces)-1].ReputerNonce.BlockHeight, 10, 64)
+// Get the latest open worker nonce for a topic, 0 when none is open
+func GetLatestOpenWorkerNonceByTopicId(config *types.Config, topicId uint64) (int64, error) {
+	heights, err := GetUnfulfilledWorkerNonces(config, topicId)
 	if err != nil {
 		return 0, err
 	}
+	return latestNonce(heights), nil
+}
 
-	return blockHeight, nil
+// Get the oldest reputer nonce for a topic, 0 when none is open
+func GetOldestReputerNonceByTopicId(config *types.Config, topicId uint64) (int64, error) {
+	heights, err := GetUnfulfilledReputerNonces(config, topicId)
+	if err != nil {
+		return 0, err
+	}
+	return oldestNonce(heights), nil
+}
+
+// The unfulfilled nonces come whole, without pagination, newest first. The heights decide
+// rather than that order.
+func latestNonce(heights []int64) int64 {
+	latest := int64(0)
+	for _, height := range heights {
+		if height > latest {
+			latest = height
+		}
+	}
+	return latest
+}
+
+func oldestNonce(heights []int64) int64 {
+	oldest := int64(0)
+	for _, height := range heights {
+		if oldest == 0 || height < oldest {
+			oldest = height
+		}
+	}
+	return oldest
 }
 
 // Get the active workers for a topic at a given block height to use for reputer payloads
+// The inferences of a block come whole, the query has no pagination
 func GetActiveWorkersForTopic(config *types.Config, topicId uint64, blockHeight int64) ([]string, error) {
-	resp, err := client.HTTPGet(config.Nodes.API + "/emissions/" + ALLORA_API_VERSION + "/inferences/" + strconv.FormatUint(topicId, 10) + "/" + strconv.FormatInt(blockHeight, 10))
+	if UsesGRPC(config) {
+		return grpcGetActiveWorkersForTopic(config, topicId, blockHeight)
+	}
+	url, err := emissionsURL(config, "/inferences/"+strconv.FormatUint(topicId, 10)+"/"+strconv.FormatInt(blockHeight, 10))
+	if err != nil {
+		return []string{}, err
+	}
+	resp, err := client.HTTPGet(url)
 	if err != nil {
 		return []string{}, err
 	}
@@ -161,10 +244,14 @@ func GetActiveWorkersForTopic(config *types.Config, topicId uint64, blockHeight
 }
 
 func GetNetworkInferencesAtBlock(config *types.Config, topicId uint64, blockHeight int64) (*emissionstypes.ValueBundle, error) {
-	resp, err := client.HTTPGet(fmt.Sprintf("%s/emissions/"+ALLORA_API_VERSION+"/network_inferences/%d/last_inference/%d",
-		config.Nodes.API,
-		topicId,
-		blockHeight))
+	if UsesGRPC(config) {
+		return grpcGetNetworkInferencesAtBlock(config, topicId, blockHeight)
+	}
+	url, err := emissionsURL(config, fmt.Sprintf("/network_inferences/%d/last_inference/%d", topicId, blockHeight))
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.HTTPGet(url)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get reputer values: %v", err)
 	}
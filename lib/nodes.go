@@ -0,0 +1,71 @@
+package lib
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/allora-network/allora-simulator/client"
+	"github.com/allora-network/allora-simulator/types"
+)
+
+// Transports of queries and broadcasts
+const (
+	TransportREST = "rest"
+	TransportGRPC = "grpc"
+)
+
+// ValidateNodesConfig checks the transport, that the endpoints it needs are set and the HTTP options.
+// Blocks and events are followed through the RPC whatever the transport.
+func ValidateNodesConfig(config types.NodesConfig) error {
+	if len(config.RPC) == 0 {
+		return fmt.Errorf("at least one rpc endpoint is required")
+	}
+	switch config.Transport {
+	case "", TransportREST:
+		if config.API == "" {
+			return fmt.Errorf("transport %s needs the api endpoint", TransportREST)
+		}
+	case TransportGRPC:
+		if config.GRPC == "" {
+			return fmt.Errorf("transport %s needs the grpc endpoint", TransportGRPC)
+		}
+	default:
+		return fmt.Errorf("unknown transport %q, expected %s or %s", config.Transport, TransportREST, TransportGRPC)
+	}
+
+	http := config.HTTP
+	if http.TimeoutSeconds < 0 {
+		return fmt.Errorf("http timeout_seconds must not be negative, got %v", http.TimeoutSeconds)
+	}
+	if http.Retries < 0 {
+		return fmt.Errorf("http retries must not be negative, got %d", http.Retries)
+	}
+	if http.RetryBackoffMs < 0 {
+		return fmt.Errorf("http retry_backoff_ms must not be negative, got %d", http.RetryBackoffMs)
+	}
+	if http.BreakerFailures < 0 {
+		return fmt.Errorf("http breaker_failures must not be negative, got %d", http.BreakerFailures)
+	}
+	if http.BreakerFailures > 0 && http.BreakerCooldownSeconds <= 0 {
+		return fmt.Errorf("http breaker_cooldown_seconds must be positive with breaker_failures, got %v", http.BreakerCooldownSeconds)
+	}
+	return nil
+}
+
+// UsesGRPC tells whether queries and broadcasts of the run go through gRPC
+func UsesGRPC(config *types.Config) bool {
+	return config.Nodes.Transport == TransportGRPC
+}
+
+// ConfigureHTTPClient applies the HTTP options of the config to every REST request that follows
+func ConfigureHTTPClient(config types.HTTPConfig) {
+	opts := client.DefaultHTTPOptions()
+	if config.TimeoutSeconds > 0 {
+		opts.Timeout = time.Duration(config.TimeoutSeconds * float64(time.Second))
+	}
+	opts.Retries = config.Retries
+	opts.RetryBackoff = time.Duration(config.RetryBackoffMs) * time.Millisecond
+	opts.BreakerFailures = config.BreakerFailures
+	opts.BreakerCooldown = time.Duration(config.BreakerCooldownSeconds * float64(time.Second))
+	client.SetHTTPOptions(opts)
+}
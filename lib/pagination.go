@@ -0,0 +1,104 @@
+package lib
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"github.com/allora-network/allora-simulator/types"
+)
+
+// Items asked for per page of a list query
+const defaultPageLimit = 100
+
+// Pages of a list query followed before giving up, a guard against endpoints that loop
+const maxPages = 10000
+
+// PageRequest selects a page of a list query, by the key of the previous page or by offset
+type PageRequest struct {
+	Key    []byte
+	Offset uint64
+	Limit  uint64
+}
+
+// Page is the items of a list query and where the next page starts. A nil NextKey with a Total
+// above the items fetched so far asks for the next page by offset.
+type Page[T any] struct {
+	Items   []T
+	NextKey []byte
+	Total   uint64
+}
+
+// CollectPages fetches every page of a list query. It follows next keys, and falls back to
+// offsets for endpoints that only report a total.
+func CollectPages[T any](limit uint64, fetch func(req PageRequest) (Page[T], error)) ([]T, error) {
+	if limit == 0 {
+		limit = defaultPageLimit
+	}
+	all := make([]T, 0)
+	req := PageRequest{Limit: limit}
+	// Counted with the first page only
+	var total uint64
+	for pages := 0; pages < maxPages; pages++ {
+		page, err := fetch(req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get page %d: %w", pages+1, err)
+		}
+		all = append(all, page.Items...)
+		if page.Total > 0 {
+			total = page.Total
+		}
+
+		switch {
+		case len(page.NextKey) > 0:
+			if string(page.NextKey) == string(req.Key) {
+				return nil, fmt.Errorf("page %d repeats the key of the previous page", pages+1)
+			}
+			req = PageRequest{Key: page.NextKey, Limit: limit}
+		case len(page.Items) > 0 && total > uint64(len(all)):
+			req = PageRequest{Offset: uint64(len(all)), Limit: limit}
+		default:
+			return all, nil
+		}
+	}
+	return nil, fmt.Errorf("list query has more than %d pages", maxPages)
+}
+
+// Add the query parameters of a page request to a REST url, keys base64 encoded as the API expects
+func pageURL(rawURL string, req PageRequest) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	query := u.Query()
+	if len(req.Key) > 0 {
+		query.Set("pagination.key", base64.StdEncoding.EncodeToString(req.Key))
+	} else if req.Offset > 0 {
+		query.Set("pagination.offset", strconv.FormatUint(req.Offset, 10))
+	}
+	query.Set("pagination.limit", strconv.FormatUint(req.Limit, 10))
+	// The total is counted only when asked for, and only the first page needs it
+	if len(req.Key) == 0 && req.Offset == 0 {
+		query.Set("pagination.count_total", "true")
+	}
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+// Decode the pagination of a REST response
+func parsePagination(pagination types.Pagination) (nextKey []byte, total uint64, err error) {
+	if pagination.NextKey != "" {
+		nextKey, err = base64.StdEncoding.DecodeString(pagination.NextKey)
+		if err != nil {
+			return nil, 0, fmt.Errorf("invalid next key %q: %w", pagination.NextKey, err)
+		}
+	}
+	if pagination.Total != "" {
+		total, err = strconv.ParseUint(pagination.Total, 10, 64)
+		if err != nil {
+			return nil, 0, fmt.Errorf("invalid total %q: %w", pagination.Total, err)
+		}
+	}
+	return nextKey, total, nil
+}
@@ -0,0 +1,82 @@
+package lib
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/allora-network/allora-simulator/types"
+)
+
+func TestCollectPages(t *testing.T) {
+	items := []int{1, 2, 3, 4, 5}
+
+	// By next key
+	got, err := CollectPages(2, func(req PageRequest) (Page[int], error) {
+		start := 0
+		if len(req.Key) > 0 {
+			start = int(req.Key[0])
+		}
+		end := min(start+int(req.Limit), len(items))
+		page := Page[int]{Items: items[start:end]}
+		if end < len(items) {
+			page.NextKey = []byte{byte(end)}
+		}
+		return page, nil
+	})
+	if err != nil || len(got) != 5 || got[4] != 5 {
+		t.Errorf("expected all items by key, got %v and %v", got, err)
+	}
+
+	// By offset, with the total of the first page only
+	got, err = CollectPages(2, func(req PageRequest) (Page[int], error) {
+		end := min(int(req.Offset+req.Limit), len(items))
+		page := Page[int]{Items: items[req.Offset:end]}
+		if req.Offset == 0 {
+			page.Total = uint64(len(items))
+		}
+		return page, nil
+	})
+	if err != nil || len(got) != 5 || got[4] != 5 {
+		t.Errorf("expected all items by offset, got %v and %v", got, err)
+	}
+
+	_, err = CollectPages(2, func(req PageRequest) (Page[int], error) {
+		return Page[int]{Items: items[:1], NextKey: []byte("same")}, nil
+	})
+	if err == nil {
+		t.Errorf("expected an error for a repeated key")
+	}
+}
+
+func TestGetAccountBalanceFollowsPages(t *testing.T) {
+	nextKey := base64.StdEncoding.EncodeToString([]byte("page2"))
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("pagination.key") == nextKey {
+			w.Write([]byte(`{"balances":[{"denom":"uallo","amount":"42"}],"pagination":{"next_key":null,"total":"0"}}`))
+			return
+		}
+		w.Write([]byte(`{"balances":[{"denom":"ibc/a","amount":"1"}],"pagination":{"next_key":"` + nextKey + `","total":"2"}}`))
+	}))
+	defer api.Close()
+
+	config := &types.Config{Denom: "uallo", Nodes: types.NodesConfig{API: api.URL}}
+	balance, err := GetAccountBalance("allo1", config)
+	if err != nil || balance.Int64() != 42 {
+		t.Errorf("expected the balance of the second page, got %v and %v", balance, err)
+	}
+}
+
+func TestNonceSelection(t *testing.T) {
+	heights := []int64{30, 10, 20}
+	if got := latestNonce(heights); got != 30 {
+		t.Errorf("expected the latest nonce 30, got %d", got)
+	}
+	if got := oldestNonce(heights); got != 10 {
+		t.Errorf("expected the oldest nonce 10, got %d", got)
+	}
+	if latestNonce(nil) != 0 || oldestNonce(nil) != 0 {
+		t.Errorf("expected 0 without nonces")
+	}
+}
@@ -0,0 +1,257 @@
+package lib
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+
+	alloramath "github.com/allora-network/allora-chain/math"
+	emissionstypes "github.com/allora-network/allora-chain/x/emissions/types"
+	"github.com/allora-network/allora-simulator/client"
+	"github.com/allora-network/allora-simulator/types"
+)
+
+// Query an emissions endpoint and decode its JSON response
+func getEmissionsQuery[T any](config *types.Config, path string) (*T, error) {
+	url, err := emissionsURL(config, path)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.HTTPGet(url)
+	if err != nil {
+		return nil, err
+	}
+
+	var res T
+	if err := json.Unmarshal(resp, &res); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal %s result: %w", path, err)
+	}
+	return &res, nil
+}
+
+func topicPath(endpoint string, topicId uint64, segments ...string) string {
+	path := "/" + endpoint + "/" + strconv.FormatUint(topicId, 10)
+	for _, segment := range segments {
+		path += "/" + segment
+	}
+	return path
+}
+
+// Convert from API version to Proto version
+func parseScore(score types.Score) (emissionstypes.Score, error) {
+	topicId, err := strconv.ParseUint(score.TopicId, 10, 64)
+	if err != nil {
+		return emissionstypes.Score{}, fmt.Errorf("invalid score topic id: %w", err)
+	}
+	blockHeight, err := strconv.ParseInt(score.BlockHeight, 10, 64)
+	if err != nil {
+		return emissionstypes.Score{}, fmt.Errorf("invalid score block height: %w", err)
+	}
+	value, err := alloramath.NewDecFromString(score.Score)
+	if err != nil {
+		return emissionstypes.Score{}, fmt.Errorf("invalid score of %s: %w", score.Address, err)
+	}
+	return emissionstypes.Score{
+		TopicId:     topicId,
+		BlockHeight: blockHeight,
+		Address:     score.Address,
+		Score:       value,
+	}, nil
+}
+
+func parseScores(scores []types.Score) ([]emissionstypes.Score, error) {
+	res := make([]emissionstypes.Score, len(scores))
+	for i, score := range scores {
+		parsed, err := parseScore(score)
+		if err != nil {
+			return nil, err
+		}
+		res[i] = parsed
+	}
+	return res, nil
+}
+
+func parseTimestampedValue(value types.TimestampedValue) (emissionstypes.TimestampedValue, error) {
+	blockHeight, err := strconv.ParseInt(value.BlockHeight, 10, 64)
+	if err != nil {
+		return emissionstypes.TimestampedValue{}, fmt.Errorf("invalid block height: %w", err)
+	}
+	dec, err := alloramath.NewDecFromString(value.Value)
+	if err != nil {
+		return emissionstypes.TimestampedValue{}, fmt.Errorf("invalid value: %w", err)
+	}
+	return emissionstypes.TimestampedValue{BlockHeight: blockHeight, Value: dec}, nil
+}
+
+func getScoresAtBlock(config *types.Config, endpoint string, topicId uint64, blockHeight int64) ([]emissionstypes.Score, error) {
+	res, err := getEmissionsQuery[types.ScoresResult](config, topicPath(endpoint, topicId, strconv.FormatInt(blockHeight, 10)))
+	if err != nil {
+		return nil, err
+	}
+	return parseScores(res.Scores.Scores)
+}
+
+func getScoreEma(config *types.Config, endpoint string, topicId uint64, actor string) (emissionstypes.Score, error) {
+	res, err := getEmissionsQuery[types.ScoreResult](config, topicPath(endpoint, topicId, actor))
+	if err != nil {
+		return emissionstypes.Score{}, err
+	}
+	return parseScore(res.Score)
+}
+
+func getRegret(config *types.Config, path string) (emissionstypes.TimestampedValue, error) {
+	res, err := getEmissionsQuery[types.RegretResult](config, path)
+	if err != nil {
+		return emissionstypes.TimestampedValue{}, err
+	}
+	return parseTimestampedValue(res.Regret)
+}
+
+// Get the inference scores of all inferers of an epoch
+func GetInfererScoresAtBlock(config *types.Config, topicId uint64, blockHeight int64) ([]emissionstypes.Score, error) {
+	if UsesGRPC(config) {
+		return grpcGetInfererScoresAtBlock(config, topicId, blockHeight)
+	}
+	return getScoresAtBlock(config, "worker_inference_scores_at_block", topicId, blockHeight)
+}
+
+// Get the forecast scores of all forecasters of an epoch
+func GetForecasterScoresAtBlock(config *types.Config, topicId uint64, blockHeight int64) ([]emissionstypes.Score, error) {
+	if UsesGRPC(config) {
+		return grpcGetForecasterScoresAtBlock(config, topicId, blockHeight)
+	}
+	return getScoresAtBlock(config, "worker_forecast_scores_at_block", topicId, blockHeight)
+}
+
+// Get the scores of all reputers of an epoch
+func GetReputerScoresAtBlock(config *types.Config, topicId uint64, blockHeight int64) ([]emissionstypes.Score, error) {
+	if UsesGRPC(config) {
+		return grpcGetReputerScoresAtBlock(config, topicId, blockHeight)
+	}
+	return getScoresAtBlock(config, "reputers_scores_at_block", topicId, blockHeight)
+}
+
+// Get the EMA score of an inferer
+func GetInfererScoreEma(config *types.Config, topicId uint64, inferer string) (emissionstypes.Score, error) {
+	if UsesGRPC(config) {
+		return grpcGetInfererScoreEma(config, topicId, inferer)
+	}
+	return getScoreEma(config, "inferer_score_ema", topicId, inferer)
+}
+
+// Get the EMA score of a forecaster
+func GetForecasterScoreEma(config *types.Config, topicId uint64, forecaster string) (emissionstypes.Score, error) {
+	if UsesGRPC(config) {
+		return grpcGetForecasterScoreEma(config, topicId, forecaster)
+	}
+	return getScoreEma(config, "forecaster_score_ema", topicId, forecaster)
+}
+
+// Get the EMA score of a reputer
+func GetReputerScoreEma(config *types.Config, topicId uint64, reputer string) (emissionstypes.Score, error) {
+	if UsesGRPC(config) {
+		return grpcGetReputerScoreEma(config, topicId, reputer)
+	}
+	return getScoreEma(config, "reputer_score_ema", topicId, reputer)
+}
+
+// Get the regret of an inferer relative to the network inference
+func GetInfererNetworkRegret(config *types.Config, topicId uint64, inferer string) (emissionstypes.TimestampedValue, error) {
+	if UsesGRPC(config) {
+		return grpcGetRegret(config, func(ctx context.Context, q emissionstypes.QueryServiceClient) (*emissionstypes.GetInfererNetworkRegretResponse, error) {
+			return q.GetInfererNetworkRegret(ctx, &emissionstypes.GetInfererNetworkRegretRequest{TopicId: topicId, ActorId: inferer})
+		})
+	}
+	return getRegret(config, topicPath("inferer_network_regret", topicId, inferer))
+}
+
+// Get the regret of a forecaster relative to the network inference
+func GetForecasterNetworkRegret(config *types.Config, topicId uint64, forecaster string) (emissionstypes.TimestampedValue, error) {
+	if UsesGRPC(config) {
+		return grpcGetRegret(config, func(ctx context.Context, q emissionstypes.QueryServiceClient) (*emissionstypes.GetForecasterNetworkRegretResponse, error) {
+			return q.GetForecasterNetworkRegret(ctx, &emissionstypes.GetForecasterNetworkRegretRequest{TopicId: topicId, Worker: forecaster})
+		})
+	}
+	return getRegret(config, topicPath("forecaster_network_regret", topicId, forecaster))
+}
+
+// Get the regret of an inferer relative to the naive network inference
+func GetNaiveInfererNetworkRegret(config *types.Config, topicId uint64, inferer string) (emissionstypes.TimestampedValue, error) {
+	if UsesGRPC(config) {
+		return grpcGetRegret(config, func(ctx context.Context, q emissionstypes.QueryServiceClient) (*emissionstypes.GetNaiveInfererNetworkRegretResponse, error) {
+			return q.GetNaiveInfererNetworkRegret(ctx, &emissionstypes.GetNaiveInfererNetworkRegretRequest{TopicId: topicId, Inferer: inferer})
+		})
+	}
+	return getRegret(config, "/native_inferer_network_regret?"+url.Values{
+		"topic_id": {strconv.FormatUint(topicId, 10)},
+		"inferer":  {inferer},
+	}.Encode())
+}
+
+// Get the regret of an inferer relative to the network inference that includes only a forecaster's implied inference
+func GetOneInForecasterNetworkRegret(config *types.Config, topicId uint64, forecaster, inferer string) (emissionstypes.TimestampedValue, error) {
+	if UsesGRPC(config) {
+		return grpcGetRegret(config, func(ctx context.Context, q emissionstypes.QueryServiceClient) (*emissionstypes.GetOneInForecasterNetworkRegretResponse, error) {
+			return q.GetOneInForecasterNetworkRegret(ctx, &emissionstypes.GetOneInForecasterNetworkRegretRequest{TopicId: topicId, Forecaster: forecaster, Inferer: inferer})
+		})
+	}
+	return getRegret(config, topicPath("one_in_forecaster_network_regret", topicId, forecaster, inferer))
+}
+
+// Get the regret of an inferer relative to the network inference without another inferer
+func GetOneOutInfererInfererNetworkRegret(config *types.Config, topicId uint64, oneOutInferer, inferer string) (emissionstypes.TimestampedValue, error) {
+	if UsesGRPC(config) {
+		return grpcGetRegret(config, func(ctx context.Context, q emissionstypes.QueryServiceClient) (*emissionstypes.GetOneOutInfererInfererNetworkRegretResponse, error) {
+			return q.GetOneOutInfererInfererNetworkRegret(ctx, &emissionstypes.GetOneOutInfererInfererNetworkRegretRequest{TopicId: topicId, OneOutInferer: oneOutInferer, Inferer: inferer})
+		})
+	}
+	return getRegret(config, "/one_out_inferer_inferer_network_regret?"+url.Values{
+		"topic_id":        {strconv.FormatUint(topicId, 10)},
+		"one_out_inferer": {oneOutInferer},
+		"inferer":         {inferer},
+	}.Encode())
+}
+
+// Get the regret of a forecaster relative to the network inference without an inferer
+func GetOneOutInfererForecasterNetworkRegret(config *types.Config, topicId uint64, oneOutInferer, forecaster string) (emissionstypes.TimestampedValue, error) {
+	if UsesGRPC(config) {
+		return grpcGetRegret(config, func(ctx context.Context, q emissionstypes.QueryServiceClient) (*emissionstypes.GetOneOutInfererForecasterNetworkRegretResponse, error) {
+			return q.GetOneOutInfererForecasterNetworkRegret(ctx, &emissionstypes.GetOneOutInfererForecasterNetworkRegretRequest{TopicId: topicId, OneOutInferer: oneOutInferer, Forecaster: forecaster})
+		})
+	}
+	return getRegret(config, "/one_out_inferer_forecaster_network_regret?"+url.Values{
+		"topic_id":        {strconv.FormatUint(topicId, 10)},
+		"one_out_inferer": {oneOutInferer},
+		"forecaster":      {forecaster},
+	}.Encode())
+}
+
+// Get the regret of an inferer relative to the network inference without a forecaster
+func GetOneOutForecasterInfererNetworkRegret(config *types.Config, topicId uint64, oneOutForecaster, inferer string) (emissionstypes.TimestampedValue, error) {
+	if UsesGRPC(config) {
+		return grpcGetRegret(config, func(ctx context.Context, q emissionstypes.QueryServiceClient) (*emissionstypes.GetOneOutForecasterInfererNetworkRegretResponse, error) {
+			return q.GetOneOutForecasterInfererNetworkRegret(ctx, &emissionstypes.GetOneOutForecasterInfererNetworkRegretRequest{TopicId: topicId, OneOutForecaster: oneOutForecaster, Inferer: inferer})
+		})
+	}
+	return getRegret(config, "/one_out_forecaster_inferer_network_regret?"+url.Values{
+		"topic_id":           {strconv.FormatUint(topicId, 10)},
+		"one_out_forecaster": {oneOutForecaster},
+		"inferer":            {inferer},
+	}.Encode())
+}
+
+// Get the regret of a forecaster relative to the network inference without another forecaster
+func GetOneOutForecasterForecasterNetworkRegret(config *types.Config, topicId uint64, oneOutForecaster, forecaster string) (emissionstypes.TimestampedValue, error) {
+	if UsesGRPC(config) {
+		return grpcGetRegret(config, func(ctx context.Context, q emissionstypes.QueryServiceClient) (*emissionstypes.GetOneOutForecasterForecasterNetworkRegretResponse, error) {
+			return q.GetOneOutForecasterForecasterNetworkRegret(ctx, &emissionstypes.GetOneOutForecasterForecasterNetworkRegretRequest{TopicId: topicId, OneOutForecaster: oneOutForecaster, Forecaster: forecaster})
+		})
+	}
+	return getRegret(config, "/one_out_forecaster_forecaster_network_regret?"+url.Values{
+		"topic_id":           {strconv.FormatUint(topicId, 10)},
+		"one_out_forecaster": {oneOutForecaster},
+		"forecaster":         {forecaster},
+	}.Encode())
+}
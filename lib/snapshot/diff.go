@@ -0,0 +1,237 @@
+package snapshot
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Output formats of a diff
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// Kinds of change between two snapshots
+const (
+	ChangeAdded   = "added"
+	ChangeRemoved = "removed"
+	ChangeChanged = "changed"
+)
+
+// Change is a value of a snapshot that differs between two of them. Paths are dot separated
+// fields of the snapshot JSON, e.g. topics.1.topic.epoch_length. Members of lists of plain values
+// are compared as sets, written path[member].
+type Change struct {
+	Path   string `json:"path"`
+	Kind   string `json:"kind"`
+	Before string `json:"before,omitempty"`
+	After  string `json:"after,omitempty"`
+	// After minus before, for integer and decimal values
+	Delta string `json:"delta,omitempty"`
+}
+
+type SnapshotInfo struct {
+	TakenAt    time.Time `json:"taken_at"`
+	Height     int64     `json:"height"`
+	APIVersion string    `json:"api_version"`
+}
+
+type Diff struct {
+	Before  SnapshotInfo `json:"before"`
+	After   SnapshotInfo `json:"after"`
+	Changes []Change     `json:"changes"`
+}
+
+func infoOf(snapshot *Snapshot) SnapshotInfo {
+	return SnapshotInfo{TakenAt: snapshot.TakenAt, Height: snapshot.Height, APIVersion: snapshot.APIVersion}
+}
+
+// Compare lists every value that was added, removed or changed from one snapshot to the other,
+// sorted by path. When and where the snapshots were taken are not compared.
+func Compare(before, after *Snapshot) (*Diff, error) {
+	beforeValues, err := flattenSnapshot(before)
+	if err != nil {
+		return nil, fmt.Errorf("failed to flatten the before snapshot: %w", err)
+	}
+	afterValues, err := flattenSnapshot(after)
+	if err != nil {
+		return nil, fmt.Errorf("failed to flatten the after snapshot: %w", err)
+	}
+
+	diff := &Diff{Before: infoOf(before), After: infoOf(after), Changes: make([]Change, 0)}
+	for path, value := range beforeValues {
+		afterValue, ok := afterValues[path]
+		switch {
+		case !ok:
+			diff.Changes = append(diff.Changes, Change{Path: path, Kind: ChangeRemoved, Before: value})
+		case afterValue != value:
+			d := delta(value, afterValue)
+			// Decimals are written with varying precision, 0 and 0.000000000000000000 are the same
+			if d == "0" {
+				continue
+			}
+			diff.Changes = append(diff.Changes, Change{Path: path, Kind: ChangeChanged, Before: value, After: afterValue, Delta: d})
+		}
+	}
+	for path, value := range afterValues {
+		if _, ok := beforeValues[path]; !ok {
+			diff.Changes = append(diff.Changes, Change{Path: path, Kind: ChangeAdded, After: value})
+		}
+	}
+	sort.Slice(diff.Changes, func(i, j int) bool { return diff.Changes[i].Path < diff.Changes[j].Path })
+	return diff, nil
+}
+
+// Flatten the JSON of a snapshot into its leaf values by path
+func flattenSnapshot(snapshot *Snapshot) (map[string]string, error) {
+	data, err := json.Marshal(struct {
+		Params ModuleParams           `json:"params"`
+		Topics map[uint64]*TopicState `json:"topics"`
+		Actors map[string]*ActorState `json:"actors"`
+	}{snapshot.Params, snapshot.Topics, snapshot.Actors})
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	// Numbers are kept as written so that large integers compare exactly
+	decoder.UseNumber()
+	var root any
+	if err := decoder.Decode(&root); err != nil {
+		return nil, err
+	}
+	values := make(map[string]string)
+	flatten("", root, values)
+	return values, nil
+}
+
+func flatten(path string, value any, values map[string]string) {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, child := range v {
+			flatten(joinPath(path, key), child, values)
+		}
+	case []any:
+		for i, child := range v {
+			switch child.(type) {
+			case map[string]any, []any:
+				flatten(fmt.Sprintf("%s[%d]", path, i), child, values)
+			default:
+				// Members of a set have no value of their own
+				values[fmt.Sprintf("%s[%s]", path, scalarString(child))] = ""
+			}
+		}
+	case nil:
+		// Absent and null are the same to a snapshot
+	default:
+		values[path] = scalarString(v)
+	}
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func scalarString(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// Difference of two numeric values, empty when either is not a number
+func delta(before, after string) string {
+	b, okBefore := new(big.Rat).SetString(before)
+	a, okAfter := new(big.Rat).SetString(after)
+	if !okBefore || !okAfter {
+		return ""
+	}
+	d := new(big.Rat).Sub(a, b)
+	sign := ""
+	if d.Sign() > 0 {
+		sign = "+"
+	}
+	if d.IsInt() {
+		return sign + d.Num().String()
+	}
+	return sign + strings.TrimRight(strings.TrimRight(d.FloatString(18), "0"), ".")
+}
+
+// WriteDiff writes a diff as text for people or as JSON for tools
+func WriteDiff(w io.Writer, diff *Diff, format string) error {
+	switch format {
+	case FormatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(diff)
+	case FormatText:
+		return writeDiffText(w, diff)
+	default:
+		return fmt.Errorf("unknown diff format %q, expected %s or %s", format, FormatText, FormatJSON)
+	}
+}
+
+func writeDiffText(w io.Writer, diff *Diff) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "before: height %d at %s\n", diff.Before.Height, diff.Before.TakenAt.Format(time.RFC3339))
+	fmt.Fprintf(&b, "after:  height %d at %s\n", diff.After.Height, diff.After.TakenAt.Format(time.RFC3339))
+	if diff.Before.APIVersion != diff.After.APIVersion {
+		fmt.Fprintf(&b, "api version changed from %s to %s\n", diff.Before.APIVersion, diff.After.APIVersion)
+	}
+
+	counts := make(map[string]int)
+	for _, change := range diff.Changes {
+		counts[change.Kind]++
+	}
+	fmt.Fprintf(&b, "%d added, %d removed, %d changed\n", counts[ChangeAdded], counts[ChangeRemoved], counts[ChangeChanged])
+
+	section := ""
+	for _, change := range diff.Changes {
+		// Group the changes by their top level section, e.g. topics.1 or actors.allo1...
+		if s := sectionOf(change.Path); s != section {
+			section = s
+			fmt.Fprintf(&b, "\n%s\n", section)
+		}
+		switch change.Kind {
+		case ChangeAdded:
+			fmt.Fprintf(&b, "  + %s%s\n", change.Path, valueSuffix(change.After))
+		case ChangeRemoved:
+			fmt.Fprintf(&b, "  - %s%s\n", change.Path, valueSuffix(change.Before))
+		case ChangeChanged:
+			fmt.Fprintf(&b, "  ~ %s: %s -> %s", change.Path, change.Before, change.After)
+			if change.Delta != "" {
+				fmt.Fprintf(&b, " (%s)", change.Delta)
+			}
+			b.WriteString("\n")
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func valueSuffix(value string) string {
+	if value == "" {
+		return ""
+	}
+	return ": " + value
+}
+
+func sectionOf(path string) string {
+	parts := strings.SplitN(path, ".", 3)
+	if len(parts) < 2 {
+		return parts[0]
+	}
+	return parts[0] + "." + strings.SplitN(parts[1], "[", 2)[0]
+}
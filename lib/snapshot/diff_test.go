@@ -0,0 +1,88 @@
+package snapshot
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	emissionstypes "github.com/allora-network/allora-chain/x/emissions/types"
+)
+
+func testSnapshot() *Snapshot {
+	return &Snapshot{
+		TakenAt: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		Height:  100,
+		Params:  ModuleParams{Emissions: emissionstypes.Params{MaxTopInferersToReward: 64}},
+		Topics: map[uint64]*TopicState{
+			1: {
+				Topic:                   &emissionstypes.Topic{Id: 1, EpochLength: 10},
+				Active:                  true,
+				ActiveInferers:          []string{"allo1a", "allo1b"},
+				UnfulfilledWorkerNonces: []int64{90},
+			},
+		},
+		Actors: map[string]*ActorState{
+			"allo1a": {Balance: "1000", Topics: map[uint64]*ActorTopicState{1: {Worker: true}}},
+		},
+	}
+}
+
+func TestCompare(t *testing.T) {
+	before := testSnapshot()
+	path := filepath.Join(t.TempDir(), "after.json")
+	if err := Write(testSnapshot(), path); err != nil {
+		t.Fatal(err)
+	}
+	after, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	diff, err := Compare(before, after)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diff.Changes) != 0 {
+		t.Fatalf("expected a snapshot to match itself after a round trip, got %+v", diff.Changes)
+	}
+
+	after.Height = 200
+	after.Params.Emissions.MaxTopInferersToReward = 32
+	after.Topics[1].ActiveInferers = []string{"allo1b", "allo1c"}
+	after.Topics[1].UnfulfilledWorkerNonces = []int64{100}
+	after.Actors["allo1a"].Balance = "750"
+	after.Actors["allo1c"] = &ActorState{Balance: "5"}
+	diff, err = Compare(before, after)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]Change{
+		"params.emissions.max_top_inferers_to_reward": {Kind: ChangeChanged, Before: "64", After: "32", Delta: "-32"},
+		"topics.1.active_inferers[allo1a]":            {Kind: ChangeRemoved},
+		"topics.1.active_inferers[allo1c]":            {Kind: ChangeAdded},
+		"topics.1.unfulfilled_worker_nonces[90]":      {Kind: ChangeRemoved},
+		"topics.1.unfulfilled_worker_nonces[100]":     {Kind: ChangeAdded},
+		"actors.allo1a.balance":                       {Kind: ChangeChanged, Before: "1000", After: "750", Delta: "-250"},
+		"actors.allo1c.balance":                       {Kind: ChangeAdded, After: "5"},
+	}
+	if len(diff.Changes) != len(expected) {
+		t.Errorf("expected %d changes, got %+v", len(expected), diff.Changes)
+	}
+	for _, change := range diff.Changes {
+		want, ok := expected[change.Path]
+		want.Path = change.Path
+		if !ok || change != want {
+			t.Errorf("unexpected change %+v", change)
+		}
+	}
+
+	var text bytes.Buffer
+	if err := WriteDiff(&text, diff, FormatText); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(text.String(), "~ actors.allo1a.balance: 1000 -> 750 (-250)") || !strings.Contains(text.String(), "3 added, 2 removed, 2 changed") {
+		t.Errorf("unexpected text diff:\n%s", text.String())
+	}
+}
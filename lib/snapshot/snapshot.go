@@ -0,0 +1,306 @@
+package snapshot
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	emissionstypes "github.com/allora-network/allora-chain/x/emissions/types"
+	"github.com/allora-network/allora-simulator/client"
+	"github.com/allora-network/allora-simulator/lib"
+	"github.com/allora-network/allora-simulator/types"
+	"github.com/rs/zerolog/log"
+	feemarkettypes "github.com/skip-mev/feemarket/x/feemarket/types"
+)
+
+// Actors queried at the same time
+const actorQueryConcurrency = 16
+
+// Snapshot is the state of the chain as the simulator sees it through its query layer. Queries are
+// not pinned to a block, so the state is read over the blocks following Height.
+type Snapshot struct {
+	TakenAt    time.Time              `json:"taken_at"`
+	Height     int64                  `json:"height"`
+	APIVersion string                 `json:"api_version"`
+	Params     ModuleParams           `json:"params"`
+	Topics     map[uint64]*TopicState `json:"topics"`
+	Actors     map[string]*ActorState `json:"actors"`
+}
+
+type ModuleParams struct {
+	Emissions emissionstypes.Params `json:"emissions"`
+	FeeMarket feemarkettypes.Params `json:"feemarket"`
+}
+
+type TopicState struct {
+	Topic            *emissionstypes.Topic `json:"topic"`
+	Weight           string                `json:"weight"`
+	EffectiveRevenue string                `json:"effective_revenue"`
+	Active           bool                  `json:"active"`
+	// Active sets of the last epoch, sorted. Registered actors outside them are not listed, the chain
+	// has no query for the registered actors of a topic.
+	ActiveInferers    []string `json:"active_inferers"`
+	ActiveForecasters []string `json:"active_forecasters"`
+	ActiveReputers    []string `json:"active_reputers"`
+	// Block heights, sorted
+	UnfulfilledWorkerNonces  []int64 `json:"unfulfilled_worker_nonces"`
+	UnfulfilledReputerNonces []int64 `json:"unfulfilled_reputer_nonces"`
+}
+
+type ActorState struct {
+	Balance string `json:"balance"`
+	// Topics the actor is registered in or stakes in
+	Topics map[uint64]*ActorTopicState `json:"topics,omitempty"`
+}
+
+type ActorTopicState struct {
+	Worker  bool `json:"worker"`
+	Reputer bool `json:"reputer"`
+	// Total stake of a reputer, delegated stake included
+	Stake string `json:"stake,omitempty"`
+	// Stake the actor delegated to reputers of the topic
+	DelegatedStake string `json:"delegated_stake,omitempty"`
+}
+
+// Take captures the module params, every topic and the actors of the addresses given along with
+// the members of the active sets of the topics. Registered actors outside the active sets are only
+// captured when their address is given.
+func Take(config *types.Config, addresses []string) (*Snapshot, error) {
+	rpc, err := client.GetClient(config.Nodes.RPC[0])
+	if err != nil {
+		return nil, fmt.Errorf("failed to create rpc client: %w", err)
+	}
+	height, err := rpc.LatestBlockHeight(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the latest block height: %w", err)
+	}
+
+	snapshot := &Snapshot{
+		TakenAt:    time.Now().UTC(),
+		Height:     height,
+		APIVersion: config.Nodes.APIVersion,
+		Topics:     make(map[uint64]*TopicState),
+		Actors:     make(map[string]*ActorState),
+	}
+	snapshot.Params.Emissions, err = lib.GetEmissionsParams(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the emissions params: %w", err)
+	}
+	snapshot.Params.FeeMarket, err = lib.GetFeeMarketParams(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the feemarket params: %w", err)
+	}
+
+	nextTopicId, err := lib.GetNextTopicId(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the next topic id: %w", err)
+	}
+	// Topic ids start at 1
+	topicIds := make([]uint64, 0)
+	for topicId := uint64(1); topicId < nextTopicId; topicId++ {
+		topic, err := takeTopic(config, topicId)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get topic %d: %w", topicId, err)
+		}
+		snapshot.Topics[topicId] = topic
+		topicIds = append(topicIds, topicId)
+		addresses = append(addresses, topic.ActiveInferers...)
+		addresses = append(addresses, topic.ActiveForecasters...)
+		addresses = append(addresses, topic.ActiveReputers...)
+	}
+	log.Info().Msgf("Captured %d topics at height %d", len(topicIds), height)
+
+	addresses = uniqueSorted(addresses)
+	var mu sync.Mutex
+	var firstErr error
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, actorQueryConcurrency)
+	for _, address := range addresses {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(address string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			actor, err := takeActor(config, address, topicIds)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if firstErr == nil {
+					firstErr = fmt.Errorf("failed to get actor %s: %w", address, err)
+				}
+				return
+			}
+			snapshot.Actors[address] = actor
+		}(address)
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	log.Info().Msgf("Captured %d actors", len(snapshot.Actors))
+	return snapshot, nil
+}
+
+func takeTopic(config *types.Config, topicId uint64) (*TopicState, error) {
+	res, err := lib.GetTopic(config, topicId)
+	if err != nil {
+		return nil, err
+	}
+	topic := &TopicState{Topic: res.Topic, Weight: res.Weight, EffectiveRevenue: res.EffectiveRevenue}
+	if topic.Active, err = lib.IsTopicActive(config, topicId); err != nil {
+		return nil, fmt.Errorf("failed to get whether it is active: %w", err)
+	}
+	if topic.ActiveInferers, err = lib.GetActiveInferers(config, topicId); err != nil {
+		return nil, fmt.Errorf("failed to get the active inferers: %w", err)
+	}
+	if topic.ActiveForecasters, err = lib.GetActiveForecasters(config, topicId); err != nil {
+		return nil, fmt.Errorf("failed to get the active forecasters: %w", err)
+	}
+	if topic.ActiveReputers, err = lib.GetActiveReputers(config, topicId); err != nil {
+		return nil, fmt.Errorf("failed to get the active reputers: %w", err)
+	}
+	if topic.UnfulfilledWorkerNonces, err = lib.GetUnfulfilledWorkerNonces(config, topicId); err != nil {
+		return nil, fmt.Errorf("failed to get the unfulfilled worker nonces: %w", err)
+	}
+	if topic.UnfulfilledReputerNonces, err = lib.GetUnfulfilledReputerNonces(config, topicId); err != nil {
+		return nil, fmt.Errorf("failed to get the unfulfilled reputer nonces: %w", err)
+	}
+	topic.ActiveInferers = uniqueSorted(topic.ActiveInferers)
+	topic.ActiveForecasters = uniqueSorted(topic.ActiveForecasters)
+	topic.ActiveReputers = uniqueSorted(topic.ActiveReputers)
+	sort.Slice(topic.UnfulfilledWorkerNonces, func(i, j int) bool { return topic.UnfulfilledWorkerNonces[i] < topic.UnfulfilledWorkerNonces[j] })
+	sort.Slice(topic.UnfulfilledReputerNonces, func(i, j int) bool { return topic.UnfulfilledReputerNonces[i] < topic.UnfulfilledReputerNonces[j] })
+	return topic, nil
+}
+
+func takeActor(config *types.Config, address string, topicIds []uint64) (*ActorState, error) {
+	actor := &ActorState{Topics: make(map[uint64]*ActorTopicState)}
+	balance, err := lib.GetAccountBalance(address, config)
+	if err != nil {
+		// An account that never held the denom has no balance of it
+		if !errors.Is(err, lib.ErrNoBalance) {
+			return nil, fmt.Errorf("failed to get the balance: %w", err)
+		}
+	}
+	actor.Balance = balance.String()
+
+	for _, topicId := range topicIds {
+		state := &ActorTopicState{}
+		if state.Worker, err = lib.IsWorkerRegistered(config, topicId, address); err != nil {
+			return nil, fmt.Errorf("failed to get whether it is a worker of topic %d: %w", topicId, err)
+		}
+		if state.Reputer, err = lib.IsReputerRegistered(config, topicId, address); err != nil {
+			return nil, fmt.Errorf("failed to get whether it is a reputer of topic %d: %w", topicId, err)
+		}
+		stake, err := lib.GetReputerStakeInTopic(config, topicId, address)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the stake in topic %d: %w", topicId, err)
+		}
+		if !stake.IsZero() {
+			state.Stake = stake.String()
+		}
+		delegated, err := lib.GetDelegatorStakeInTopic(config, topicId, address)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the delegated stake in topic %d: %w", topicId, err)
+		}
+		if !delegated.IsZero() {
+			state.DelegatedStake = delegated.String()
+		}
+		if state.Worker || state.Reputer || state.Stake != "" || state.DelegatedStake != "" {
+			actor.Topics[topicId] = state
+		}
+	}
+	return actor, nil
+}
+
+func uniqueSorted(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	unique := make([]string, 0, len(values))
+	for _, value := range values {
+		if value != "" && !seen[value] {
+			seen[value] = true
+			unique = append(unique, value)
+		}
+	}
+	sort.Strings(unique)
+	return unique
+}
+
+// LoadAddresses reads the addresses of actors to capture: one per line, or the actor column of a
+// CSV file with a header such as the actors file of a research run
+func LoadAddresses(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	reader := bufio.NewReader(f)
+	header, err := reader.Peek(256)
+	if err != nil && len(header) == 0 {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	firstLine, _, _ := strings.Cut(string(header), "\n")
+	if !strings.Contains(firstLine, ",") {
+		addresses := make([]string, 0)
+		scanner := bufio.NewScanner(reader)
+		for scanner.Scan() {
+			if line := strings.TrimSpace(scanner.Text()); line != "" && !strings.HasPrefix(line, "#") {
+				addresses = append(addresses, line)
+			}
+		}
+		return addresses, scanner.Err()
+	}
+
+	records, err := csv.NewReader(reader).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	column := -1
+	for i, name := range records[0] {
+		if name == "actor" || name == "address" {
+			column = i
+		}
+	}
+	if column < 0 {
+		return nil, fmt.Errorf("%s has no actor or address column", path)
+	}
+	addresses := make([]string, 0, len(records)-1)
+	for _, record := range records[1:] {
+		addresses = append(addresses, record[column])
+	}
+	return addresses, nil
+}
+
+// Write saves a snapshot as indented JSON
+func Write(snapshot *Snapshot, path string) error {
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal snapshot: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	return nil
+}
+
+// Load reads a snapshot written by Write
+func Load(path string) (*Snapshot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot: %w", err)
+	}
+	var snapshot Snapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal snapshot %s: %w", path, err)
+	}
+	return &snapshot, nil
+}
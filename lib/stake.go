@@ -0,0 +1,115 @@
+package lib
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	cosmosmath "cosmossdk.io/math"
+	emissionstypes "github.com/allora-network/allora-chain/x/emissions/types"
+	"github.com/allora-network/allora-simulator/types"
+)
+
+func parseStake(amount string) (cosmosmath.Int, error) {
+	stake, ok := cosmosmath.NewIntFromString(amount)
+	if !ok {
+		return cosmosmath.ZeroInt(), fmt.Errorf("invalid stake amount: %q", amount)
+	}
+	return stake, nil
+}
+
+func getStakeAmount(config *types.Config, path string) (cosmosmath.Int, error) {
+	res, err := getEmissionsQuery[types.StakeAmountResult](config, path)
+	if err != nil {
+		return cosmosmath.ZeroInt(), err
+	}
+	return parseStake(res.Amount)
+}
+
+// Get the total stake of a reputer in a topic, including stake delegated to it
+func GetReputerStakeInTopic(config *types.Config, topicId uint64, reputer string) (cosmosmath.Int, error) {
+	if UsesGRPC(config) {
+		return grpcGetStake(config, func(ctx context.Context, q emissionstypes.QueryServiceClient) (cosmosmath.Int, error) {
+			res, err := q.GetReputerStakeInTopic(ctx, &emissionstypes.GetReputerStakeInTopicRequest{Address: reputer, TopicId: topicId})
+			if err != nil {
+				return cosmosmath.ZeroInt(), err
+			}
+			return res.Amount, nil
+		})
+	}
+	return getStakeAmount(config, "/reputer_stake/"+reputer+"/"+strconv.FormatUint(topicId, 10))
+}
+
+// Get the stake a reputer placed on itself in a topic
+func GetReputerSelfStakeInTopic(config *types.Config, topicId uint64, reputer string) (cosmosmath.Int, error) {
+	if UsesGRPC(config) {
+		return grpcGetStake(config, func(ctx context.Context, q emissionstypes.QueryServiceClient) (cosmosmath.Int, error) {
+			res, err := q.GetStakeFromReputerInTopicInSelf(ctx, &emissionstypes.GetStakeFromReputerInTopicInSelfRequest{ReputerAddress: reputer, TopicId: topicId})
+			if err != nil {
+				return cosmosmath.ZeroInt(), err
+			}
+			return res.Amount, nil
+		})
+	}
+	return getStakeAmount(config, "/reputer_stake_self/"+reputer+"/"+strconv.FormatUint(topicId, 10))
+}
+
+// Get the stake delegated to a reputer in a topic
+func GetReputerDelegatedStakeInTopic(config *types.Config, topicId uint64, reputer string) (cosmosmath.Int, error) {
+	if UsesGRPC(config) {
+		return grpcGetStake(config, func(ctx context.Context, q emissionstypes.QueryServiceClient) (cosmosmath.Int, error) {
+			res, err := q.GetDelegateStakeInTopicInReputer(ctx, &emissionstypes.GetDelegateStakeInTopicInReputerRequest{ReputerAddress: reputer, TopicId: topicId})
+			if err != nil {
+				return cosmosmath.ZeroInt(), err
+			}
+			return res.Amount, nil
+		})
+	}
+	return getStakeAmount(config, "/reputer_delegate_stake/"+reputer+"/"+strconv.FormatUint(topicId, 10))
+}
+
+// Get the stake a delegator placed on a reputer in a topic
+func GetDelegatorStakeInReputer(config *types.Config, topicId uint64, delegator, reputer string) (cosmosmath.Int, error) {
+	if UsesGRPC(config) {
+		return grpcGetStake(config, func(ctx context.Context, q emissionstypes.QueryServiceClient) (cosmosmath.Int, error) {
+			res, err := q.GetStakeFromDelegatorInTopicInReputer(ctx, &emissionstypes.GetStakeFromDelegatorInTopicInReputerRequest{DelegatorAddress: delegator, ReputerAddress: reputer, TopicId: topicId})
+			if err != nil {
+				return cosmosmath.ZeroInt(), err
+			}
+			return res.Amount, nil
+		})
+	}
+	return getStakeAmount(config, "/delegate_stake/"+delegator+"/"+reputer+"/"+strconv.FormatUint(topicId, 10))
+}
+
+// Get the stake a delegator placed on all reputers of a topic
+func GetDelegatorStakeInTopic(config *types.Config, topicId uint64, delegator string) (cosmosmath.Int, error) {
+	if UsesGRPC(config) {
+		return grpcGetStake(config, func(ctx context.Context, q emissionstypes.QueryServiceClient) (cosmosmath.Int, error) {
+			res, err := q.GetStakeFromDelegatorInTopic(ctx, &emissionstypes.GetStakeFromDelegatorInTopicRequest{DelegatorAddress: delegator, TopicId: topicId})
+			if err != nil {
+				return cosmosmath.ZeroInt(), err
+			}
+			return res.Amount, nil
+		})
+	}
+	return getStakeAmount(config, "/delegate_stake/"+delegator+"/"+strconv.FormatUint(topicId, 10))
+}
+
+// Get the stake delegated upon a reputer in a topic, as used to share reputer rewards with delegators
+func GetDelegateStakeUponReputer(config *types.Config, topicId uint64, reputer string) (cosmosmath.Int, error) {
+	if UsesGRPC(config) {
+		return grpcGetStake(config, func(ctx context.Context, q emissionstypes.QueryServiceClient) (cosmosmath.Int, error) {
+			res, err := q.GetDelegateStakeUponReputer(ctx, &emissionstypes.GetDelegateStakeUponReputerRequest{TopicId: topicId, Target: reputer})
+			if err != nil {
+				return cosmosmath.ZeroInt(), err
+			}
+			return res.Stake, nil
+		})
+	}
+	res, err := getEmissionsQuery[types.DelegateStakeUponReputerResult](config, topicPath("delegate_stake_upon_reputer", topicId, reputer))
+	if err != nil {
+		return cosmosmath.ZeroInt(), err
+	}
+	return parseStake(res.Stake)
+}
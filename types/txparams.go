@@ -1,7 +1,9 @@
 package types
 
 import (
+	"cosmossdk.io/math"
 	cryptotypes "github.com/cosmos/cosmos-sdk/crypto/types"
+	sdktypes "github.com/cosmos/cosmos-sdk/types"
 )
 
 type TransactionParams struct {
@@ -10,4 +12,21 @@ type TransactionParams struct {
 	AccNum   uint64
 	PrivKey  cryptotypes.PrivKey
 	PubKey   cryptotypes.PubKey
+	// Prices the transactions of the actor
+	Fees FeeStrategy
+}
+
+// FeeRequest describes a transaction to price
+type FeeRequest struct {
+	Gas  uint64
+	Msgs []sdktypes.Msg
+	// Failed broadcasts of the transaction so far
+	Attempt int64
+	// Fee the chain asked for on the last attempt, 0 when unknown
+	RequiredFee uint64
+}
+
+// FeeStrategy prices the transactions of an actor
+type FeeStrategy interface {
+	Fee(req FeeRequest) (math.Int, error)
 }
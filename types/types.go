@@ -2,6 +2,7 @@ package types
 
 import (
 	cryptorand "crypto/rand"
+	"encoding/json"
 	"math/big"
 	"math/rand/v2"
 	"strconv"
@@ -28,12 +29,168 @@ type Config struct {
 	Nodes                 NodesConfig         `json:"nodes"`
 	Research              ResearchConfig      `json:"research"`
 	BasicActivity         BasicActivityConfig `json:"basic_activity"`
+	Churn                 ChurnConfig         `json:"churn"`
+	Staking               StakingConfig       `json:"staking"`
+	Faults                FaultConfig         `json:"faults"`
+	StressValues          StressValuesConfig  `json:"stress_values"`
+	Payload               PayloadConfig       `json:"payload"`
+	Batch                 BatchConfig         `json:"batch"`
+	Fees                  FeeConfig           `json:"fees"`
+	FeeMarket             FeeMarketConfig     `json:"fee_market"`
+	Sweep                 SweepConfig         `json:"sweep"`
+}
+
+// FeeConfig selects the fee strategy every actor prices its transactions with
+type FeeConfig struct {
+	// "base_fee" (default), "fixed", "percentile", "priority" or "escalate"
+	Strategy string `json:"strategy"`
+	// Gas price of the fixed strategy, or its fee per tx when Fee is set
+	GasPrice float64 `json:"gas_price"`
+	Fee      uint64  `json:"fee"`
+	// Factor over the base fee of the other strategies, 1 when unset
+	Multiplier float64 `json:"multiplier"`
+	// Percentile of the recent base fees the percentile strategy bids, in [0, 100]
+	Percentile float64 `json:"percentile"`
+	// Multipliers of the priority strategy per message type, keyed by type url or message name.
+	// A tx takes the highest tier of its messages, others use Multiplier.
+	Tiers map[string]float64 `json:"tiers"`
+	// The escalate strategy raises the fee of an actor by this factor on each retry, up to MaxEscalations times,
+	// and lowers it back one step with each new tx
+	EscalationStep float64 `json:"escalation_step"`
+	MaxEscalations int     `json:"max_escalations"`
+}
+
+// BatchConfig limits the messages the batcher packs in one transaction
+type BatchConfig struct {
+	// Messages per transaction, 0 for no limit
+	MaxMsgs int `json:"max_msgs"`
+	// Encoded bytes of the messages of a transaction, 0 for no limit
+	MaxBytes int `json:"max_bytes"`
+	// Stress workers batch this many bank sends to the faucet with their payload every epoch
+	StressFillerMsgs int `json:"stress_filler_msgs"`
+}
+
+// PayloadConfig shapes the worker payloads of the stress module, to drive the tx size apart from the actor count
+type PayloadConfig struct {
+	// Inferers forecasts cover: "previous_active" the inferers active in the previous epoch,
+	// "all_registered" every inferer registered in the topic
+	ForecastInferers string `json:"forecast_inferers"`
+	// Number of elements of every forecast when set, cycling over the inferers above
+	ForecastElements int `json:"forecast_elements"`
+	// Random bytes in the ExtraData of inferences and forecasts
+	ExtraDataBytes int `json:"extra_data_bytes"`
+	// Random characters in the Proof of inferences
+	ProofBytes int `json:"proof_bytes"`
+	// Digits after the decimal point of every value when set, the digits a float cannot hold are random
+	ValueDecimals int `json:"value_decimals"`
+}
+
+// StressValuesConfig selects how stress workers and reputers draw the values they submit
+type StressValuesConfig struct {
+	// "random" draws independent values, "model" derives them from the research models,
+	// with the ground truth, worker noise and experience of the research config
+	Mode string `json:"mode"`
+}
+
+// FaultConfig makes stress workers also send faulty payloads, to check the chain rejects them.
+// Rates are the probabilities, per worker and epoch, to send each kind of faulty payload.
+type FaultConfig struct {
+	Late                float64 `json:"late"`
+	Duplicate           float64 `json:"duplicate"`
+	BadSignature        float64 `json:"bad_signature"`
+	MismatchedPubkey    float64 `json:"mismatched_pubkey"`
+	WrongTopic          float64 `json:"wrong_topic"`
+	UnregisteredInferer float64 `json:"unregistered_inferer"`
+	OutOfBounds         float64 `json:"out_of_bounds"`
+	// Stop the run when a faulty payload is accepted or rejected with an unexpected code
+	FailOnUnexpected bool `json:"fail_on_unexpected"`
+}
+
+// ChurnConfig makes actors join, leave and return to topics while the stress and research loops run
+type ChurnConfig struct {
+	Enabled bool `json:"enabled"`
+	// Epochs between two churn rounds
+	IntervalEpochs int64 `json:"interval_epochs"`
+	// Funded actors kept out of the initial population, new actors join from them
+	SpareActors int `json:"spare_actors"`
+	// Leaving actors remove their registration, otherwise they only stop submitting
+	Unregister bool `json:"unregister"`
+	// Research roles
+	Inferers    RoleChurn `json:"inferers"`
+	Forecasters RoleChurn `json:"forecasters"`
+	Reputers    RoleChurn `json:"reputers"`
+	// Stress workers, which are both inferers and forecasters
+	Workers RoleChurn `json:"workers"`
+}
+
+// RoleChurn are the churn rates of a role in every topic, per churn round
+type RoleChurn struct {
+	JoinRate   float64 `json:"join_rate"`   // mean number of spare actors joining
+	LeaveRate  float64 `json:"leave_rate"`  // probability of each registered actor to leave
+	ReturnRate float64 `json:"return_rate"` // probability of each dormant actor to return
+}
+
+// StakingConfig sets the initial stake of reputers and makes reputers and delegators
+// change their stake while the stress and research loops run
+type StakingConfig struct {
+	// Stake of every registering reputer, a constant 9e4 when unset
+	Stake   StakeDistribution `json:"stake"`
+	Enabled bool              `json:"enabled"`
+	// Epochs between two staking rounds
+	IntervalEpochs int64 `json:"interval_epochs"`
+	// Probabilities of each reputer to add or remove stake in a round
+	AddStakeProbability    float64 `json:"add_stake_probability"`
+	RemoveStakeProbability float64 `json:"remove_stake_probability"`
+	// Fraction of its current self stake a reputer adds or removes
+	StakeChangeFraction float64 `json:"stake_change_fraction"`
+	// Funded actors delegating stake to the reputers of every topic
+	Delegators      int               `json:"delegators"`
+	DelegationStake StakeDistribution `json:"delegation_stake"`
+	// Probabilities of each delegator to delegate to a random reputer, to remove one of its delegations,
+	// and to claim the rewards of its delegations in a round
+	DelegateProbability     float64 `json:"delegate_probability"`
+	UndelegateProbability   float64 `json:"undelegate_probability"`
+	ClaimRewardsProbability float64 `json:"claim_rewards_probability"`
+}
+
+// StakeDistribution is the distribution stake amounts are drawn from
+type StakeDistribution struct {
+	// constant, uniform, lognormal or pareto
+	Distribution string `json:"distribution"`
+	// Constant amount, median of the lognormal or minimum of the pareto distribution
+	Amount uint64 `json:"amount"`
+	// Bounds of the uniform distribution. Max also caps the lognormal and pareto distributions when set
+	Min uint64 `json:"min"`
+	Max uint64 `json:"max"`
+	// Standard deviation of the log of the lognormal distribution
+	Sigma float64 `json:"sigma"`
+	// Tail index of the pareto distribution, lower is more concentrated
+	Alpha float64 `json:"alpha"`
 }
 
 type NodesConfig struct {
 	RPC  []string `json:"rpc"`
 	API  string   `json:"api"`
 	GRPC string   `json:"grpc"`
+	// Transport of queries and broadcasts: "rest" (API and RPC, the default) or "grpc"
+	Transport string `json:"transport"`
+	// Emissions API version of the chain, detected at startup when empty
+	APIVersion string     `json:"api_version"`
+	HTTP       HTTPConfig `json:"http"`
+}
+
+// HTTPConfig tunes the REST requests. Zero values keep a 10s timeout without retries or circuit breaking.
+type HTTPConfig struct {
+	// Timeout of an attempt
+	TimeoutSeconds float64 `json:"timeout_seconds"`
+	// Attempts after the first one for timeouts, transport errors and overloaded endpoints
+	Retries int `json:"retries"`
+	// Base of the exponential backoff between retries, fully jittered
+	RetryBackoffMs int64 `json:"retry_backoff_ms"`
+	// Failures in a row opening the circuit of an endpoint, 0 never opens it
+	BreakerFailures int `json:"breaker_failures"`
+	// Time an open circuit rejects requests before probing the endpoint again
+	BreakerCooldownSeconds float64 `json:"breaker_cooldown_seconds"`
 }
 
 type AccountInfo struct {
@@ -65,10 +222,9 @@ type Pagination struct {
 }
 
 type Actor struct {
-	Name           string
-	Addr           string
-	TxParams       *TransactionParams
-	ResearchParams *ResearchParams
+	Name     string
+	Addr     string
+	TxParams *TransactionParams
 }
 
 func (a Actor) String() string {
@@ -160,18 +316,152 @@ type GetNetworkInferencesAtBlockResponse struct {
 	NetworkInferences *ValueBundle `json:"network_inferences"`
 }
 
+type Score struct {
+	TopicId     string `json:"topic_id"`
+	BlockHeight string `json:"block_height"`
+	Address     string `json:"address"`
+	Score       string `json:"score"`
+}
+
+type Scores struct {
+	Scores []Score `json:"scores"`
+}
+
+type ScoreResult struct {
+	Score Score `json:"score"`
+}
+
+type ScoresResult struct {
+	Scores Scores `json:"scores"`
+}
+
+type TimestampedValue struct {
+	BlockHeight string `json:"block_height"`
+	Value       string `json:"value"`
+}
+
+type RegretResult struct {
+	Regret TimestampedValue `json:"regret"`
+}
+
+type StakeAmountResult struct {
+	Amount string `json:"amount"`
+}
+
+type DelegateStakeUponReputerResult struct {
+	Stake string `json:"stake"`
+}
+
 // RESEARCH MODULE
 
 type ResearchConfig struct {
-	InitialPrice           float64      `json:"initial_price"`
-	Drift                  float64      `json:"drift"`
-	Volatility             float64      `json:"volatility"`
-	BaseExperienceFactor   float64      `json:"base_experience_factor"`
-	ExperienceGrowth       float64      `json:"experience_growth"`
-	OutperformValue        float64      `json:"outperform_value"`
-	ConsistentOutperformer bool         `json:"consistent_outperformer"`
-	Topic                  TopicConfig  `json:"topic"`
-	GlobalParams           GlobalParams `json:"global_params"`
+	InitialPrice           float64           `json:"initial_price"`
+	Drift                  float64           `json:"drift"`
+	Volatility             float64           `json:"volatility"`
+	BaseExperienceFactor   float64           `json:"base_experience_factor"`
+	ExperienceGrowth       float64           `json:"experience_growth"`
+	OutperformValue        float64           `json:"outperform_value"`
+	ConsistentOutperformer bool              `json:"consistent_outperformer"`
+	GroundTruth            GroundTruthConfig `json:"ground_truth"`
+	Topic                  TopicConfig       `json:"topic"`
+	GlobalParams           GlobalParams      `json:"global_params"`
+	// When set, replaces the single topic above with several topics, each with its own model and population
+	Topics       []ResearchTopicConfig `json:"topics"`
+	SharedActors bool                  `json:"shared_actors"`
+	Offline      OfflineConfig         `json:"offline"`
+	// Per-epoch data of online runs is written to a new subdirectory of this directory
+	OutputDir string `json:"output_dir"`
+	// Also query one-in and one-out regrets, one query per pair of actors
+	TrackPairwiseRegrets bool `json:"track_pairwise_regrets"`
+	// Adversarial behaviours mixed into the actor population of every topic
+	Adversaries AdversaryConfig `json:"adversaries"`
+	// Epochs every topic runs before an online run ends, 0 runs until the timeout
+	Epochs int64 `json:"epochs"`
+	// Runs every topic as one topic per arm, the arms sharing the actor params and the ground truth
+	Arms []ArmConfig `json:"arms"`
+}
+
+// ArmConfig is an experiment arm of the research topics.
+// Topic holds the topic params the arm changes, in the json layout of TopicConfig.
+type ArmConfig struct {
+	Name  string          `json:"name"`
+	Topic json.RawMessage `json:"topic"`
+}
+
+// AdversaryConfig turns some actors of each role population into adversarial archetypes.
+// Archetypes are assigned to the first actors of a role, in the order of the fields below, the rest stay honest.
+type AdversaryConfig struct {
+	// Inferers resubmitting the latest combined network inference
+	Copycats int `json:"copycats"`
+	// Groups of inferers submitting one shared value
+	SybilClusters    int `json:"sybil_clusters"`
+	SybilClusterSize int `json:"sybil_cluster_size"`
+	// Reputers adding or subtracting ReportingBias to the log10 of every loss they report
+	OverReporters  int     `json:"over_reporters"`
+	UnderReporters int     `json:"under_reporters"`
+	ReportingBias  float64 `json:"reporting_bias"`
+	// Forecasters always forecasting the lowest loss for the inferer at index TargetInferer of the population
+	Shills        int `json:"shills"`
+	TargetInferer int `json:"target_inferer"`
+	// Actors of each role submitting only with probability SubmitProbability each epoch
+	IntermittentInferers    int     `json:"intermittent_inferers"`
+	IntermittentForecasters int     `json:"intermittent_forecasters"`
+	IntermittentReputers    int     `json:"intermittent_reputers"`
+	SubmitProbability       float64 `json:"submit_probability"`
+}
+
+// OfflineConfig drives the research models without a chain
+type OfflineConfig struct {
+	Epochs       int     `json:"epochs"`
+	Weighting    string  `json:"weighting"`      // how the local network combines predictions: "mean" or "inverse_loss"
+	LossEmaAlpha float64 `json:"loss_ema_alpha"` // smoothing of the past losses used by "inverse_loss"
+	OutputDir    string  `json:"output_dir"`
+}
+
+// ResearchTopicConfig is the model and actor population of one research topic
+type ResearchTopicConfig struct {
+	Name         string            `json:"name"`
+	InitialPrice float64           `json:"initial_price"`
+	Drift        float64           `json:"drift"`
+	Volatility   float64           `json:"volatility"`
+	GroundTruth  GroundTruthConfig `json:"ground_truth"`
+	Topic        TopicConfig       `json:"topic"`
+	Inferers     int               `json:"inferers"`
+	Forecasters  int               `json:"forecasters"`
+	Reputers     int               `json:"reputers"`
+	// Replaces the top level adversaries for this topic when set
+	Adversaries *AdversaryConfig `json:"adversaries"`
+}
+
+// GroundTruthConfig selects the price process used to generate the research ground truth.
+// Drift and Volatility from ResearchConfig are the base parameters of every process.
+type GroundTruthConfig struct {
+	Process string `json:"process"`
+	// Ornstein-Uhlenbeck
+	MeanReversion float64 `json:"mean_reversion"`
+	LongRunPrice  float64 `json:"long_run_price"`
+	// Jump diffusion
+	JumpIntensity  float64 `json:"jump_intensity"`
+	JumpMean       float64 `json:"jump_mean"`
+	JumpVolatility float64 `json:"jump_volatility"`
+	// GARCH(1,1) stochastic volatility
+	GarchOmega float64 `json:"garch_omega"`
+	GarchAlpha float64 `json:"garch_alpha"`
+	GarchBeta  float64 `json:"garch_beta"`
+	// Regime switching
+	TurbulentDrift      float64 `json:"turbulent_drift"`
+	TurbulentVolatility float64 `json:"turbulent_volatility"`
+	CalmToTurbulentProb float64 `json:"calm_to_turbulent_prob"`
+	TurbulentToCalmProb float64 `json:"turbulent_to_calm_prob"`
+	// Deterministic sine and step series
+	Amplitude float64 `json:"amplitude"`
+	Period    int64   `json:"period"`
+	// Historical replay of a CSV or JSON (timestamp, price) series
+	HistoricalFile     string  `json:"historical_file"`
+	HistoricalInterval string  `json:"historical_interval"` // series time covered by one epoch, e.g. "1h"
+	StartOffset        int64   `json:"start_offset"`        // epochs skipped at the start of the series
+	Scale              float64 `json:"scale"`               // multiplier applied to every price, 1 if unset
+	Loop               bool    `json:"loop"`                // restart from the offset when the series is exhausted
 }
 
 type GlobalParams struct {
@@ -201,12 +491,18 @@ type ResearchParams struct {
 	ContextSensitivity float64
 	Outperform         bool
 	LossFunction       string
+	Archetype          string // adversarial behaviour of the actor, "honest" by default
+	SybilCluster       int    // cluster of sybil inferers, only set for the sybil archetype
+	Index              int    // index of the actor in its role population, -1 for actors joining through churn
 }
 
 type GroundTruthState struct {
 	CumulativeReturn float64
 	CurrentPrice     float64
 	LastReturn       float64
+	Step             int64
+	Variance         float64 // used by stochastic volatility processes
+	Turbulent        bool    // used by regime switching processes
 }
 
 // BASIC ACTIVITY MODULE
@@ -219,6 +515,50 @@ type BasicActivityConfig struct {
 	RefundAmount   math.Int        `json:"refund_amount"`
 }
 
+// SweepConfig runs the research topics at every point of a design over emissions and topic params
+type SweepConfig struct {
+	// "grid" runs every combination of the values of the params, "random" draws Points points
+	Design string `json:"design"`
+	Points int    `json:"points"`
+	// Seed of the random design, 0 for a random seed
+	Seed int64 `json:"seed"`
+	// Epochs every topic runs at each point
+	Epochs int64        `json:"epochs"`
+	Params []SweepParam `json:"params"`
+}
+
+// SweepParam is a param of a sweep and the values it takes
+type SweepParam struct {
+	// "global.<name>" for an emissions param of OptionalParams, "topic.<name>" for a param of TopicConfig
+	Name string `json:"name"`
+	// Values of the grid, or drawn from by the random design
+	Values []string `json:"values"`
+	// Range the random design draws from without values, uniformly or log uniformly
+	Min float64 `json:"min"`
+	Max float64 `json:"max"`
+	Log bool    `json:"log"`
+}
+
+// FeeMarketConfig drives blocks through stages of fullness and observes how the base fee reacts
+type FeeMarketConfig struct {
+	// Actors sending load, split evenly between the strategies
+	NumActors int `json:"num_actors"`
+	// Fee strategies competing for block space, by name. The fees config is the only strategy when empty.
+	Strategies map[string]FeeConfig `json:"strategies"`
+	// Stages of the run, in order
+	Stages []FeeMarketStage `json:"stages"`
+	// Blocks a tx may wait in the mempool before it counts as priced out
+	InclusionBlocks int64 `json:"inclusion_blocks"`
+	// The blocks, txs and report of the run are written to a new subdirectory of this directory
+	OutputDir string `json:"output_dir"`
+}
+
+// FeeMarketStage targets a block fullness, the gas of the load over the max block utilization of the fee market
+type FeeMarketStage struct {
+	Fullness float64 `json:"fullness"`
+	Blocks   int     `json:"blocks"`
+}
+
 type Range[T intType] struct {
 	Min T `json:"min"`
 	Max T `json:"max"`
@@ -13,6 +13,7 @@ import (
 
 func Start(config *types.Config, state *State) error {
 	log.Info().Int("nbActors", len(state.actors)).Msg("Starting basic activity simulation")
+	go state.gasPrice.Run()
 
 	for {
 		actors := state.getShuffledActors()
@@ -3,11 +3,12 @@ package basic_activity
 import (
 	"io"
 
+	"github.com/allora-network/allora-simulator/lib"
 	"github.com/allora-network/allora-simulator/types"
 	"github.com/allora-network/allora-simulator/workloads/common"
 )
 
-func CreateAndFundActors(config *types.Config, faucetMnemonic []byte, rand io.Reader) *State {
-	faucet, actorsList, fundedAmount := common.CreateAndFundActors(config, faucetMnemonic, config.BasicActivity.NumActors, rand)
-	return NewState(faucet, actorsList, fundedAmount)
+func CreateAndFundActors(config *types.Config, gasPrice *lib.GasPriceOracle, faucetMnemonic []byte, rand io.Reader) *State {
+	faucet, actorsList, fundedAmount := common.CreateAndFundActors(config, gasPrice, faucetMnemonic, config.BasicActivity.NumActors, rand)
+	return NewState(faucet, gasPrice, actorsList, fundedAmount)
 }
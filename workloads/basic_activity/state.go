@@ -5,11 +5,13 @@ import (
 	"sync"
 
 	"cosmossdk.io/math"
+	"github.com/allora-network/allora-simulator/lib"
 	"github.com/allora-network/allora-simulator/types"
 )
 
 type State struct {
-	faucet *types.Actor
+	faucet   *types.Actor
+	gasPrice *lib.GasPriceOracle
 
 	actors        []*types.Actor
 	actorsPerAddr map[string]*types.Actor
@@ -18,7 +20,7 @@ type State struct {
 	mutex sync.Mutex
 }
 
-func NewState(faucet *types.Actor, actors []*types.Actor, balance math.Int) *State {
+func NewState(faucet *types.Actor, gasPrice *lib.GasPriceOracle, actors []*types.Actor, balance math.Int) *State {
 	balances := make(map[string]math.Int, len(actors))
 	perAddr := make(map[string]*types.Actor, len(actors))
 	for _, actor := range actors {
@@ -28,6 +30,7 @@ func NewState(faucet *types.Actor, actors []*types.Actor, balance math.Int) *Sta
 
 	return &State{
 		faucet:        faucet,
+		gasPrice:      gasPrice,
 		actors:        actors,
 		actorsPerAddr: perAddr,
 		balances:      balances,
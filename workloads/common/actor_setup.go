@@ -13,8 +13,11 @@ import (
 	"github.com/rs/zerolog/log"
 )
 
+// CreateAndFundActors creates numActors actors and the faucet funding them, all pricing their
+// transactions over the gas prices of the oracle
 func CreateAndFundActors(
 	config *types.Config,
+	gasPrice *lib.GasPriceOracle,
 	faucetMnemonic []byte,
 	numActors int,
 	rand io.Reader,
@@ -26,7 +29,7 @@ func CreateAndFundActors(
 	var err error
 	// fund all actors from the faucet with some amount
 	// give everybody the same amount of money to start with
-	actorsList = createActors(numActors, config, rand)
+	actorsList = createActors(numActors, config, gasPrice, rand)
 
 	privKey, pubKey, faucetAddr := GetPrivKey(config.Prefix, faucetMnemonic)
 
@@ -39,6 +42,7 @@ func CreateAndFundActors(
 			AccNum:   0,
 			PrivKey:  privKey,
 			PubKey:   pubKey,
+			Fees:     newFeeStrategy(config, gasPrice),
 		},
 	}
 	preFundAmount, err = getPreFundAmount(faucet, numActors)
@@ -73,7 +77,7 @@ func CreateAndFundActors(
 }
 
 // Create a new actor and register them in the node's account registry
-func createNewActor(numActors int, config *types.Config, rand io.Reader) *types.Actor {
+func createNewActor(numActors int, config *types.Config, gasPrice *lib.GasPriceOracle, rand io.Reader) *types.Actor {
 	actorName := types.GetActorName(numActors)
 	privKey, pubKey, address := GeneratePrivKey(rand)
 
@@ -86,15 +90,25 @@ func createNewActor(numActors int, config *types.Config, rand io.Reader) *types.
 			AccNum:   0,
 			PrivKey:  privKey,
 			PubKey:   pubKey,
+			Fees:     newFeeStrategy(config, gasPrice),
 		},
 	}
 }
 
+// Fee strategy of a new actor, the config is validated before any actor is created
+func newFeeStrategy(config *types.Config, gasPrice *lib.GasPriceOracle) types.FeeStrategy {
+	fees, err := NewFeeStrategy(config, gasPrice)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid fees config")
+	}
+	return fees
+}
+
 // Create a list of actors both as a map and a slice, returns both
-func createActors(numToCreate int, config *types.Config, rand io.Reader) []*types.Actor {
+func createActors(numToCreate int, config *types.Config, gasPrice *lib.GasPriceOracle, rand io.Reader) []*types.Actor {
 	actorsList := make([]*types.Actor, numToCreate)
 	for i := 0; i < numToCreate; i++ {
-		actorsList[i] = createNewActor(i, config, rand)
+		actorsList[i] = createNewActor(i, config, gasPrice, rand)
 	}
 	return actorsList
 }
@@ -0,0 +1,219 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/allora-network/allora-simulator/client"
+	"github.com/allora-network/allora-simulator/types"
+	sdktypes "github.com/cosmos/cosmos-sdk/types"
+	gogoproto "github.com/cosmos/gogoproto/proto"
+	"github.com/rs/zerolog/log"
+)
+
+// Signers sending their batches at the same time
+const maxConcurrentSigners = 1000
+
+// SignedMsg is a message and the actor signing it
+type SignedMsg struct {
+	Signer *types.Actor
+	Msg    sdktypes.Msg
+}
+
+// Batcher packs the messages of each signer in as few transactions as the limits allow.
+// A batch the chain rejects is split in halves which are retried, until the failing messages are isolated.
+type Batcher struct {
+	config      *types.Config
+	maxMsgs     int
+	maxBytes    int
+	maxBlockGas uint64 // 0 when unlimited
+}
+
+// NewBatcher creates a batcher with the limits of the config and the max block gas of the chain
+func NewBatcher(config *types.Config) *Batcher {
+	b := &Batcher{
+		config:   config,
+		maxMsgs:  config.Batch.MaxMsgs,
+		maxBytes: config.Batch.MaxBytes,
+	}
+	rpc, err := client.GetClient(config.Nodes.RPC[0])
+	if err != nil {
+		log.Warn().Err(err).Msg("Batches are not limited by block gas, failed to create rpc client")
+		return b
+	}
+	maxGas, err := rpc.MaxBlockGas(context.Background())
+	if err != nil {
+		log.Warn().Err(err).Msg("Batches are not limited by block gas, failed to get the consensus params")
+		return b
+	}
+	if maxGas > 0 {
+		b.maxBlockGas = uint64(maxGas)
+	}
+	return b
+}
+
+// Batch groups msgs by signer, in order, into batches within the message count, byte and block gas limits.
+// A message over the limits on its own gets a batch of its own.
+func (b *Batcher) Batch(msgs []SignedMsg) [][]SignedMsg {
+	bySigner := make(map[string][]SignedMsg)
+	signers := make([]string, 0)
+	for _, msg := range msgs {
+		if _, ok := bySigner[msg.Signer.Addr]; !ok {
+			signers = append(signers, msg.Signer.Addr)
+		}
+		bySigner[msg.Signer.Addr] = append(bySigner[msg.Signer.Addr], msg)
+	}
+
+	batches := make([][]SignedMsg, 0)
+	for _, signer := range signers {
+		batch := make([]SignedMsg, 0)
+		var size batchSize
+		for _, msg := range bySigner[signer] {
+			msgSize := batchSize{bytes: gogoproto.Size(msg.Msg), gasSize: len(msg.Msg.String())}
+			if len(batch) > 0 && !b.fits(len(batch)+1, size.add(msgSize)) {
+				batches = append(batches, batch)
+				batch, size = make([]SignedMsg, 0), batchSize{}
+			}
+			batch = append(batch, msg)
+			size = size.add(msgSize)
+		}
+		if len(batch) > 0 {
+			batches = append(batches, batch)
+		}
+	}
+	return batches
+}
+
+// batchSize is the encoded size of the messages of a batch, and the size their gas is estimated from
+type batchSize struct {
+	bytes   int
+	gasSize int
+}
+
+func (s batchSize) add(other batchSize) batchSize {
+	return batchSize{bytes: s.bytes + other.bytes, gasSize: s.gasSize + other.gasSize}
+}
+
+// Whether a batch of msgs messages and size is within the limits
+func (b *Batcher) fits(msgs int, size batchSize) bool {
+	if b.maxMsgs > 0 && msgs > b.maxMsgs {
+		return false
+	}
+	if b.maxBytes > 0 && size.bytes > b.maxBytes {
+		return false
+	}
+	if b.maxBlockGas > 0 {
+		gas, err := estimateAdjustedGas(size.gasSize, b.config)
+		if err != nil || gas > b.maxBlockGas {
+			return false
+		}
+	}
+	return true
+}
+
+// Send batches msgs and sends the batches, the batches of different signers at the same time.
+// It returns the messages which failed on their own.
+func (b *Batcher) Send(waitForTx bool, msgs ...SignedMsg) []SignedMsg {
+	bySigner := make(map[string][][]SignedMsg)
+	signers := make([]string, 0)
+	for _, batch := range b.Batch(msgs) {
+		signer := batch[0].Signer.Addr
+		if _, ok := bySigner[signer]; !ok {
+			signers = append(signers, signer)
+		}
+		bySigner[signer] = append(bySigner[signer], batch)
+	}
+
+	var mu sync.Mutex
+	failed := make([]SignedMsg, 0)
+	sem := make(chan struct{}, maxConcurrentSigners)
+	completed := atomic.Int32{}
+	var wg sync.WaitGroup
+	for _, signer := range signers {
+		wg.Add(1)
+		go func(batches [][]SignedMsg) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() {
+				<-sem
+				count := completed.Add(1)
+				if int(count)%1000 == 0 || count == int32(len(signers)) {
+					log.Debug().Msgf("Processed the batches of %d/%d signers", count, len(signers))
+				}
+			}()
+			// The batches of a signer go one after the other, they share its sequence
+			for _, batch := range batches {
+				if batchFailed := b.sendBatch(waitForTx, batch); len(batchFailed) > 0 {
+					mu.Lock()
+					failed = append(failed, batchFailed...)
+					mu.Unlock()
+				}
+			}
+		}(bySigner[signer])
+	}
+	wg.Wait()
+
+	if len(failed) > 0 {
+		log.Error().Msgf("%d of %d batched messages failed", len(failed), len(msgs))
+	}
+	return failed
+}
+
+// Send a batch, splitting it in halves when it fails, returns the messages which failed on their own.
+// The batch goes through the retries of SendDataWithRetry, its halves are sent once.
+func (b *Batcher) sendBatch(waitForTx bool, batch []SignedMsg) []SignedMsg {
+	resp, err := SendFromActor(batch[0].Signer, waitForTx, sdkMsgs(batch)...)
+	if err == nil && resp != nil && resp.Code == 0 {
+		return nil
+	}
+	return b.splitBatch(waitForTx, batch, err)
+}
+
+func (b *Batcher) splitBatch(waitForTx bool, batch []SignedMsg, err error) []SignedMsg {
+	signer := batch[0].Signer
+	if len(batch) == 1 {
+		log.Error().Err(err).Msgf("Batched message %s from %s failed", sdktypes.MsgTypeURL(batch[0].Msg), signer.Addr)
+		return batch
+	}
+	log.Warn().Msgf("Batch of %d messages from %s failed, sending its halves", len(batch), signer.Addr)
+	half := len(batch) / 2
+	return append(b.sendHalf(waitForTx, batch[:half]), b.sendHalf(waitForTx, batch[half:])...)
+}
+
+// Send half of a failed batch once. A half the chain rejected is split again, a half that did not
+// reach the chain failed for a transient reason and is sent again with retries.
+func (b *Batcher) sendHalf(waitForTx bool, batch []SignedMsg) []SignedMsg {
+	signer := batch[0].Signer
+	unlock := LockActor(signer)
+	resp, updatedSeq, err := SendDataOnce(signer.TxParams, sdkMsgs(batch)...)
+	signer.TxParams.Sequence = updatedSeq
+	unlock()
+	switch {
+	case resp == nil:
+		log.Warn().Err(err).Msgf("Half batch of %d messages from %s did not reach the chain, sending it again", len(batch), signer.Addr)
+		return b.sendBatch(waitForTx, batch)
+	case resp.Code != 0:
+		return b.splitBatch(waitForTx, batch, fmt.Errorf("rejected with code %d: %s", resp.Code, resp.Log))
+	}
+	return nil
+}
+
+// ContainsMsg reports whether msg is one of msgs
+func ContainsMsg(msgs []SignedMsg, msg sdktypes.Msg) bool {
+	for _, m := range msgs {
+		if m.Msg == msg {
+			return true
+		}
+	}
+	return false
+}
+
+func sdkMsgs(batch []SignedMsg) []sdktypes.Msg {
+	msgs := make([]sdktypes.Msg, len(batch))
+	for i, msg := range batch {
+		msgs[i] = msg.Msg
+	}
+	return msgs
+}
@@ -0,0 +1,62 @@
+package common
+
+import (
+	"testing"
+
+	emissionstypes "github.com/allora-network/allora-chain/x/emissions/types"
+	"github.com/allora-network/allora-simulator/types"
+	gogoproto "github.com/cosmos/gogoproto/proto"
+)
+
+func newTestMsgs(signer *types.Actor, n int) []SignedMsg {
+	msgs := make([]SignedMsg, n)
+	for i := range msgs {
+		msgs[i] = SignedMsg{Signer: signer, Msg: &emissionstypes.FundTopicRequest{Sender: signer.Addr, TopicId: uint64(i + 1)}}
+	}
+	return msgs
+}
+
+func TestBatch(t *testing.T) {
+	actors := newTestActors(2)
+	msgs := append(newTestMsgs(actors[0], 5), newTestMsgs(actors[1], 2)...)
+	// Interleave the signers
+	msgs[1], msgs[5] = msgs[5], msgs[1]
+
+	b := &Batcher{config: &types.Config{}, maxMsgs: 2}
+	batches := b.Batch(msgs)
+	sizes := []int{2, 2, 1, 2}
+	if len(batches) != len(sizes) {
+		t.Fatalf("expected %d batches, got %d", len(sizes), len(batches))
+	}
+	for i, batch := range batches {
+		if len(batch) != sizes[i] {
+			t.Errorf("expected batch %d to have %d messages, got %d", i, sizes[i], len(batch))
+		}
+		for _, msg := range batch {
+			if msg.Signer != batch[0].Signer {
+				t.Errorf("expected batch %d to have a single signer", i)
+			}
+		}
+	}
+
+	// Byte limit of about two messages
+	msgBytes := gogoproto.Size(msgs[0].Msg)
+	b = &Batcher{config: &types.Config{}, maxBytes: 2*msgBytes + 1}
+	if batches := b.Batch(newTestMsgs(actors[0], 5)); len(batches) != 3 {
+		t.Errorf("expected 3 batches within the byte limit, got %d", len(batches))
+	}
+
+	// Block gas limit of two messages
+	config := &types.Config{BaseGas: 100, GasPerByte: 1}
+	msgGasSize := len(msgs[0].Msg.String())
+	b = &Batcher{config: config, maxBlockGas: uint64(100 + 2*msgGasSize)}
+	if batches := b.Batch(newTestMsgs(actors[0], 4)); len(batches) != 2 {
+		t.Errorf("expected 2 batches within the block gas, got %d", len(batches))
+	}
+
+	// No limits
+	b = &Batcher{config: &types.Config{}}
+	if batches := b.Batch(msgs); len(batches) != 2 {
+		t.Errorf("expected one batch per signer without limits, got %d", len(batches))
+	}
+}
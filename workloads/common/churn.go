@@ -0,0 +1,247 @@
+package common
+
+import (
+	"math"
+	"math/rand"
+	"sync"
+
+	cosmosmath "cosmossdk.io/math"
+	emissionstypes "github.com/allora-network/allora-chain/x/emissions/types"
+	"github.com/allora-network/allora-simulator/types"
+	sdktypes "github.com/cosmos/cosmos-sdk/types"
+	"github.com/rs/zerolog/log"
+)
+
+// ChurnRound is the membership changes drawn for one role of a topic
+type ChurnRound struct {
+	Joining   []*types.Actor
+	Leaving   []*types.Actor
+	Returning []*types.Actor
+}
+
+// ChurnRole describes a role of a topic to churn and how its membership is updated.
+// Join, Leave and Return run once the chain accepted the change, possibly concurrently.
+// Remove takes a leaving actor out of the role before its unregistration is sent, so that the loops
+// stop sending from it, and Restore puts it back when the unregistration failed.
+type ChurnRole struct {
+	TopicId   uint64
+	Name      string
+	IsReputer bool
+	// Draws the stake added by each joining reputer
+	Stake      func() cosmosmath.Int
+	Unregister bool
+	Join       func(actor *types.Actor)
+	Remove     func(actor *types.Actor)
+	Restore    func(actor *types.Actor)
+	Leave      func(actor *types.Actor)
+	Return     func(actor *types.Actor)
+}
+
+// ChurnState holds the spare actors yet to join and the dormant actors of every role of every topic.
+// It is owned by a single churn loop and is not safe for concurrent use.
+type ChurnState struct {
+	spares  []*types.Actor
+	dormant map[uint64]map[string][]*types.Actor
+}
+
+func NewChurnState(spares []*types.Actor) *ChurnState {
+	return &ChurnState{
+		spares:  append([]*types.Actor{}, spares...),
+		dormant: make(map[uint64]map[string][]*types.Actor),
+	}
+}
+
+// Dormant returns the actors that left a role of a topic and have not returned
+func (s *ChurnState) Dormant(topicId uint64, role string) []*types.Actor {
+	return s.dormant[topicId][role]
+}
+
+func (s *ChurnState) setDormant(topicId uint64, role string, actors []*types.Actor) {
+	if s.dormant[topicId] == nil {
+		s.dormant[topicId] = make(map[string][]*types.Actor)
+	}
+	s.dormant[topicId][role] = actors
+}
+
+// Poisson draws a number of events of a Poisson distribution with the given mean, with Knuth's
+// algorithm, which is fine for the small means of the simulator
+func Poisson(mean float64) int {
+	if mean <= 0 {
+		return 0
+	}
+	limit, k, p := math.Exp(-mean), 0, 1.0
+	for {
+		p *= rand.Float64()
+		if p <= limit {
+			return k
+		}
+		k++
+	}
+}
+
+// DrawChurnRound draws which registered actors leave, which dormant actors return,
+// and how many spare actors join a role
+func DrawChurnRound(rates types.RoleChurn, registered, dormant, spares []*types.Actor) ChurnRound {
+	round := ChurnRound{}
+	for _, actor := range registered {
+		if rand.Float64() < rates.LeaveRate {
+			round.Leaving = append(round.Leaving, actor)
+		}
+	}
+	for _, actor := range dormant {
+		if rand.Float64() < rates.ReturnRate {
+			round.Returning = append(round.Returning, actor)
+		}
+	}
+	joining := min(Poisson(rates.JoinRate), len(spares))
+	round.Joining = spares[:joining]
+	return round
+}
+
+// Round draws and applies a churn round for a role of a topic.
+// Actors whose transaction fails keep their previous membership.
+func (s *ChurnState) Round(rates types.RoleChurn, role ChurnRole, registered []*types.Actor) {
+	dormant := s.Dormant(role.TopicId, role.Name)
+	round := DrawChurnRound(rates, registered, dormant, s.spares)
+	s.spares = s.spares[len(round.Joining):]
+	if len(round.Joining)+len(round.Leaving)+len(round.Returning) == 0 {
+		return
+	}
+	log.Info().Msgf("Churn in topic %d: %d %ss joining, %d leaving, %d returning",
+		role.TopicId, len(round.Joining), role.Name, len(round.Leaving), len(round.Returning))
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	failedJoins := make([]*types.Actor, 0)
+	left := make([]*types.Actor, 0)
+	returned := make(map[string]bool)
+
+	apply := func(actor *types.Actor, send func() error, onSuccess func()) {
+		if err := send(); err != nil {
+			log.Error().Err(err).Msgf("Churn of %s %s in topic %d failed", role.Name, actor.Addr, role.TopicId)
+			return
+		}
+		onSuccess()
+	}
+
+	for _, actor := range round.Leaving {
+		role.Remove(actor)
+		wg.Add(1)
+		go func(actor *types.Actor) {
+			defer wg.Done()
+			gone := false
+			apply(actor, func() error {
+				if !role.Unregister {
+					return nil
+				}
+				return UnregisterActor(actor, role.TopicId, role.IsReputer)
+			}, func() {
+				if role.Leave != nil {
+					role.Leave(actor)
+				}
+				gone = true
+				mu.Lock()
+				left = append(left, actor)
+				mu.Unlock()
+			})
+			if !gone {
+				role.Restore(actor)
+			}
+		}(actor)
+	}
+	for _, actor := range round.Returning {
+		wg.Add(1)
+		go func(actor *types.Actor) {
+			defer wg.Done()
+			apply(actor, func() error {
+				if !role.Unregister {
+					return nil
+				}
+				// Reputers kept their stake while unregistered
+				return RegisterActor(actor, role.TopicId, role.IsReputer, cosmosmath.ZeroInt())
+			}, func() {
+				role.Return(actor)
+				mu.Lock()
+				returned[actor.Addr] = true
+				mu.Unlock()
+			})
+		}(actor)
+	}
+	for _, actor := range round.Joining {
+		wg.Add(1)
+		go func(actor *types.Actor) {
+			defer wg.Done()
+			joined := false
+			apply(actor, func() error {
+				stake := cosmosmath.ZeroInt()
+				if role.Stake != nil {
+					stake = role.Stake()
+				}
+				return RegisterActor(actor, role.TopicId, role.IsReputer, stake)
+			}, func() {
+				role.Join(actor)
+				joined = true
+			})
+			if !joined {
+				mu.Lock()
+				failedJoins = append(failedJoins, actor)
+				mu.Unlock()
+			}
+		}(actor)
+	}
+	wg.Wait()
+
+	// Spares that failed to join can be drawn again
+	s.spares = append(s.spares, failedJoins...)
+	stillDormant := make([]*types.Actor, 0, len(dormant)+len(left))
+	for _, actor := range dormant {
+		if !returned[actor.Addr] {
+			stillDormant = append(stillDormant, actor)
+		}
+	}
+	s.setDormant(role.TopicId, role.Name, append(stillDormant, left...))
+}
+
+// RegisterActor registers an actor in a topic. Reputers also add stake when it is positive.
+func RegisterActor(actor *types.Actor, topicId uint64, isReputer bool, stake cosmosmath.Int) error {
+	msgs := []sdktypes.Msg{
+		&emissionstypes.RegisterRequest{
+			Sender:    actor.Addr,
+			Owner:     actor.Addr,
+			IsReputer: isReputer,
+			TopicId:   topicId,
+		},
+	}
+	if isReputer && stake.IsPositive() {
+		msgs = append(msgs, &emissionstypes.AddStakeRequest{
+			Sender:  actor.Addr,
+			TopicId: topicId,
+			Amount:  stake,
+		})
+	}
+
+	_, err := SendFromActor(actor, true, msgs...)
+	return err
+}
+
+// UnregisterActor removes the registration of an actor from a topic
+func UnregisterActor(actor *types.Actor, topicId uint64, isReputer bool) error {
+	_, err := SendFromActor(actor, true, &emissionstypes.RemoveRegistrationRequest{
+		Sender:    actor.Addr,
+		TopicId:   topicId,
+		IsReputer: isReputer,
+	})
+	return err
+}
+
+// RemoveActor returns a copy of actors without the actor with the given address.
+// Slices handed out to running loops are never modified in place.
+func RemoveActor(actors []*types.Actor, addr string) []*types.Actor {
+	res := make([]*types.Actor, 0, len(actors))
+	for _, actor := range actors {
+		if actor.Addr != addr {
+			res = append(res, actor)
+		}
+	}
+	return res
+}
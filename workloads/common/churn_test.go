@@ -0,0 +1,111 @@
+package common
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/allora-network/allora-simulator/lib"
+	"github.com/allora-network/allora-simulator/types"
+)
+
+func newTestActors(n int) []*types.Actor {
+	actors := make([]*types.Actor, n)
+	for i := range actors {
+		actors[i] = &types.Actor{Addr: fmt.Sprintf("actor%d", i)}
+	}
+	return actors
+}
+
+// Actors sending to a node that refuses every connection, so that every broadcast fails
+func newUnreachableActors(t *testing.T, n int) []*types.Actor {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	config := &types.Config{ChainID: "test", Denom: "uallo", BaseGas: 100000, Nodes: types.NodesConfig{RPC: []string{"http://" + addr}}}
+	gasPrice := lib.NewGasPriceOracle(config)
+	gasPrice.Set(10)
+	fees, err := NewFeeStrategy(config, gasPrice)
+	if err != nil {
+		t.Fatal(err)
+	}
+	actors := make([]*types.Actor, n)
+	for i := range actors {
+		privKey, pubKey, address := GeneratePrivKey(rand.Reader)
+		actors[i] = &types.Actor{
+			Addr:     address,
+			TxParams: &types.TransactionParams{Config: config, PrivKey: privKey, PubKey: pubKey, Fees: fees},
+		}
+	}
+	return actors
+}
+
+func TestDrawChurnRound(t *testing.T) {
+	registered, dormant, spares := newTestActors(4), newTestActors(3), newTestActors(2)
+
+	round := DrawChurnRound(types.RoleChurn{}, registered, dormant, spares)
+	if len(round.Joining)+len(round.Leaving)+len(round.Returning) != 0 {
+		t.Errorf("expected no churn with zero rates, got %+v", round)
+	}
+
+	round = DrawChurnRound(types.RoleChurn{JoinRate: 1000, LeaveRate: 1, ReturnRate: 1}, registered, dormant, spares)
+	if len(round.Leaving) != len(registered) {
+		t.Errorf("expected all %d registered actors to leave, got %d", len(registered), len(round.Leaving))
+	}
+	if len(round.Returning) != len(dormant) {
+		t.Errorf("expected all %d dormant actors to return, got %d", len(dormant), len(round.Returning))
+	}
+	if len(round.Joining) != len(spares) {
+		t.Errorf("expected joins to be capped at %d spares, got %d", len(spares), len(round.Joining))
+	}
+}
+
+func TestRemoveActor(t *testing.T) {
+	actors := newTestActors(3)
+	removed := RemoveActor(actors, "actor1")
+	if len(removed) != 2 || removed[0].Addr != "actor0" || removed[1].Addr != "actor2" {
+		t.Errorf("unexpected actors after removal: %v", removed)
+	}
+	if actors[1].Addr != "actor1" {
+		t.Errorf("original slice was modified")
+	}
+}
+
+func TestChurnRoundFailedTxs(t *testing.T) {
+	actors := newUnreachableActors(t, 2)
+	if err := UnregisterActor(actors[0], 1, false); !errors.Is(err, ErrTxNotAccepted) {
+		t.Fatalf("expected ErrTxNotAccepted when every broadcast fails, got %v", err)
+	}
+
+	registered := []*types.Actor{actors[0]}
+	joined := 0
+	role := ChurnRole{
+		TopicId:    1,
+		Name:       "inferer",
+		Unregister: true,
+		Join:       func(actor *types.Actor) { joined++ },
+		Remove:     func(actor *types.Actor) { registered = RemoveActor(registered, actor.Addr) },
+		Restore:    func(actor *types.Actor) { registered = append(registered, actor) },
+		Leave:      func(actor *types.Actor) { t.Errorf("actor %s left although its unregistration failed", actor.Addr) },
+		Return:     func(actor *types.Actor) {},
+	}
+	state := NewChurnState(actors[1:])
+	state.Round(types.RoleChurn{LeaveRate: 1, JoinRate: 1000}, role, registered)
+
+	if len(registered) != 1 || registered[0] != actors[0] {
+		t.Errorf("expected the leaving actor to stay registered, got %v", registered)
+	}
+	if len(state.Dormant(1, "inferer")) != 0 {
+		t.Errorf("expected no dormant actors, got %v", state.Dormant(1, "inferer"))
+	}
+	if joined != 0 || len(state.spares) != 1 {
+		t.Errorf("expected the spare to stay a spare, %d joined and %d spares left", joined, len(state.spares))
+	}
+}
@@ -0,0 +1,203 @@
+package common
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sync"
+
+	cosmosmath "cosmossdk.io/math"
+	"github.com/allora-network/allora-simulator/lib"
+	"github.com/allora-network/allora-simulator/types"
+	sdktypes "github.com/cosmos/cosmos-sdk/types"
+	gogoproto "github.com/cosmos/gogoproto/proto"
+)
+
+// Fee strategies
+const (
+	FeeStrategyBaseFee    = "base_fee"
+	FeeStrategyFixed      = "fixed"
+	FeeStrategyPercentile = "percentile"
+	FeeStrategyPriority   = "priority"
+	FeeStrategyEscalate   = "escalate"
+)
+
+// ValidateFeeConfig checks the fee strategy and its parameters
+func ValidateFeeConfig(config types.FeeConfig) error {
+	switch config.Strategy {
+	case "", FeeStrategyBaseFee, FeeStrategyPercentile:
+	case FeeStrategyFixed:
+		if config.Fee == 0 && config.GasPrice <= 0 {
+			return fmt.Errorf("fixed fees need a fee or a gas_price")
+		}
+	case FeeStrategyPriority:
+		for tier, multiplier := range config.Tiers {
+			if multiplier <= 0 {
+				return fmt.Errorf("tier %s must have a positive multiplier, got %v", tier, multiplier)
+			}
+		}
+	case FeeStrategyEscalate:
+		if config.EscalationStep <= 0 {
+			return fmt.Errorf("escalation_step must be positive, got %v", config.EscalationStep)
+		}
+		if config.MaxEscalations < 0 {
+			return fmt.Errorf("max_escalations must not be negative, got %d", config.MaxEscalations)
+		}
+	default:
+		return fmt.Errorf("unknown fee strategy: %s", config.Strategy)
+	}
+	if config.Multiplier < 0 {
+		return fmt.Errorf("multiplier must not be negative, got %v", config.Multiplier)
+	}
+	if config.Percentile < 0 || config.Percentile > 100 {
+		return fmt.Errorf("percentile must be between 0 and 100, got %v", config.Percentile)
+	}
+	return nil
+}
+
+// NewFeeStrategy creates the fee strategy of an actor, pricing over the gas prices of the oracle.
+// Every actor needs its own, the escalate strategy keeps the state of its actor.
+func NewFeeStrategy(config *types.Config, gasPrice *lib.GasPriceOracle) (types.FeeStrategy, error) {
+	fees := config.Fees
+	if err := ValidateFeeConfig(fees); err != nil {
+		return nil, err
+	}
+	multiplier := fees.Multiplier
+	if multiplier == 0 {
+		multiplier = 1
+	}
+	strategy := fees.Strategy
+	// The override fee of older configs pays the same fee for every tx
+	if strategy == "" && config.OverrideFee > 0 {
+		strategy = FeeStrategyFixed
+		fees.Fee = config.OverrideFee
+	}
+	switch strategy {
+	case FeeStrategyFixed:
+		return &fixedFees{fee: fees.Fee, gasPrice: fees.GasPrice}, nil
+	case FeeStrategyPercentile:
+		return &percentileFees{gasPrice: gasPrice, percentile: fees.Percentile, multiplier: multiplier}, nil
+	case FeeStrategyPriority:
+		return &priorityFees{gasPrice: gasPrice, tiers: fees.Tiers, multiplier: multiplier}, nil
+	case FeeStrategyEscalate:
+		return &escalatingFees{
+			gasPrice:       gasPrice,
+			multiplier:     multiplier,
+			step:           fees.EscalationStep,
+			maxEscalations: fees.MaxEscalations,
+		}, nil
+	default:
+		return &baseFees{gasPrice: gasPrice, multiplier: multiplier}, nil
+	}
+}
+
+// baseFees pays the current base fee of the fee market times a multiplier
+type baseFees struct {
+	gasPrice   *lib.GasPriceOracle
+	multiplier float64
+}
+
+func (f *baseFees) Fee(req types.FeeRequest) (cosmosmath.Int, error) {
+	return CalculateFees(req.Gas, f.gasPrice.Price()*f.multiplier)
+}
+
+// fixedFees pays the same fee, or the same gas price, whatever the fee market
+type fixedFees struct {
+	fee      uint64
+	gasPrice float64
+}
+
+func (f *fixedFees) Fee(req types.FeeRequest) (cosmosmath.Int, error) {
+	if f.fee > 0 {
+		return cosmosmath.NewIntFromUint64(f.fee), nil
+	}
+	return CalculateFees(req.Gas, f.gasPrice)
+}
+
+// percentileFees pays a percentile of the recent base fees times a multiplier
+type percentileFees struct {
+	gasPrice   *lib.GasPriceOracle
+	percentile float64
+	multiplier float64
+}
+
+func (f *percentileFees) Fee(req types.FeeRequest) (cosmosmath.Int, error) {
+	history := f.gasPrice.History()
+	prices := make([]float64, len(history))
+	for i, sample := range history {
+		prices[i] = sample.Price
+	}
+	price := percentile(prices, f.percentile)
+	if price == 0 {
+		price = f.gasPrice.Price()
+	}
+	return CalculateFees(req.Gas, price*f.multiplier)
+}
+
+// Nearest rank percentile p of values, 0 when there are none
+func percentile(values []float64, p float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+	rank := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+	return sorted[max(rank, 0)]
+}
+
+// priorityFees pays the base fee times the multiplier of the message types of the tx
+type priorityFees struct {
+	gasPrice   *lib.GasPriceOracle
+	tiers      map[string]float64
+	multiplier float64
+}
+
+func (f *priorityFees) Fee(req types.FeeRequest) (cosmosmath.Int, error) {
+	return CalculateFees(req.Gas, f.gasPrice.Price()*f.tierMultiplier(req.Msgs))
+}
+
+// Highest tier among msgs, the default multiplier when none has one
+func (f *priorityFees) tierMultiplier(msgs []sdktypes.Msg) float64 {
+	multiplier := 0.0
+	for _, msg := range msgs {
+		tier, ok := f.tiers[sdktypes.MsgTypeURL(msg)]
+		if !ok {
+			tier, ok = f.tiers[gogoproto.MessageName(msg)]
+		}
+		if ok && tier > multiplier {
+			multiplier = tier
+		}
+	}
+	if multiplier == 0 {
+		return f.multiplier
+	}
+	return multiplier
+}
+
+// escalatingFees raises the fee of its actor on every retry and lowers it back one step with every new tx,
+// so an actor priced out of busy blocks keeps bidding higher until it gets in
+type escalatingFees struct {
+	gasPrice       *lib.GasPriceOracle
+	mu             sync.Mutex
+	multiplier     float64
+	step           float64
+	maxEscalations int
+	level          int
+}
+
+func (f *escalatingFees) Fee(req types.FeeRequest) (cosmosmath.Int, error) {
+	f.mu.Lock()
+	if req.Attempt > 0 {
+		f.level++
+		if f.maxEscalations > 0 {
+			f.level = min(f.level, f.maxEscalations)
+		}
+	} else {
+		f.level = max(f.level-1, 0)
+	}
+	level := f.level
+	f.mu.Unlock()
+
+	price := f.gasPrice.Price() * f.multiplier * math.Pow(1+f.step, float64(level))
+	return CalculateFees(req.Gas, price)
+}
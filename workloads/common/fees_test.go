@@ -0,0 +1,85 @@
+package common
+
+import (
+	"testing"
+
+	emissionstypes "github.com/allora-network/allora-chain/x/emissions/types"
+	"github.com/allora-network/allora-simulator/lib"
+	"github.com/allora-network/allora-simulator/types"
+	sdktypes "github.com/cosmos/cosmos-sdk/types"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+)
+
+func TestFeeStrategies(t *testing.T) {
+	gasPrice := lib.NewGasPriceOracle(&types.Config{})
+	gasPrice.Set(10)
+	fee := func(config types.Config, req types.FeeRequest) int64 {
+		t.Helper()
+		strategy, err := NewFeeStrategy(&config, gasPrice)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		fees, err := strategy.Fee(req)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return fees.Int64()
+	}
+	req := types.FeeRequest{Gas: 100}
+
+	if got := fee(types.Config{}, req); got != 1000 {
+		t.Errorf("expected the base fee 1000, got %d", got)
+	}
+	if got := fee(types.Config{Fees: types.FeeConfig{Multiplier: 1.5}}, req); got != 1500 {
+		t.Errorf("expected 1.5 times the base fee, got %d", got)
+	}
+	if got := fee(types.Config{OverrideFee: 42}, req); got != 42 {
+		t.Errorf("expected the override fee, got %d", got)
+	}
+	if got := fee(types.Config{Fees: types.FeeConfig{Strategy: FeeStrategyFixed, GasPrice: 2}}, req); got != 200 {
+		t.Errorf("expected the fixed gas price, got %d", got)
+	}
+
+	priority := types.Config{Fees: types.FeeConfig{
+		Strategy: FeeStrategyPriority,
+		Tiers:    map[string]float64{sdktypes.MsgTypeURL(&emissionstypes.InsertWorkerPayloadRequest{}): 3},
+	}}
+	payloadReq := types.FeeRequest{Gas: 100, Msgs: []sdktypes.Msg{&banktypes.MsgSend{}, &emissionstypes.InsertWorkerPayloadRequest{}}}
+	if got := fee(priority, payloadReq); got != 3000 {
+		t.Errorf("expected the tier of the payload, got %d", got)
+	}
+	if got := fee(priority, types.FeeRequest{Gas: 100, Msgs: []sdktypes.Msg{&banktypes.MsgSend{}}}); got != 1000 {
+		t.Errorf("expected the base fee without a tier, got %d", got)
+	}
+
+	strategy, err := NewFeeStrategy(&types.Config{Fees: types.FeeConfig{Strategy: FeeStrategyEscalate, EscalationStep: 1, MaxEscalations: 2}}, gasPrice)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []int64{1000, 2000, 4000, 4000, 2000}
+	for i, attempt := range []int64{0, 1, 2, 3, 0} {
+		fees, err := strategy.Fee(types.FeeRequest{Gas: 100, Attempt: attempt})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if fees.Int64() != expected[i] {
+			t.Errorf("expected fee %d on attempt %d, got %d", expected[i], attempt, fees.Int64())
+		}
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	values := []float64{5, 1, 4, 2, 3}
+	if got := percentile(values, 50); got != 3 {
+		t.Errorf("expected the median 3, got %v", got)
+	}
+	if got := percentile(values, 100); got != 5 {
+		t.Errorf("expected the max 5, got %v", got)
+	}
+	if got := percentile(values, 0); got != 1 {
+		t.Errorf("expected the min 1, got %v", got)
+	}
+	if got := percentile(nil, 50); got != 0 {
+		t.Errorf("expected 0 without values, got %v", got)
+	}
+}
@@ -5,13 +5,11 @@ import (
 	"math"
 	"regexp"
 	"strconv"
-	"time"
 
-	"github.com/allora-network/allora-simulator/lib"
 	types "github.com/allora-network/allora-simulator/types"
 
 	cosmossdk_io_math "cosmossdk.io/math"
-	"github.com/rs/zerolog/log"
+	sdktypes "github.com/cosmos/cosmos-sdk/types"
 )
 
 const (
@@ -36,6 +34,33 @@ func EstimateGas(txSize int, config *types.Config) (uint64, error) {
 	return totalGas, nil
 }
 
+// EstimateMsgsGas estimates the gas limit of a transaction carrying msgs, gas adjustment included
+func EstimateMsgsGas(config *types.Config, msgs ...sdktypes.Msg) (uint64, error) {
+	totalTxSize := 0
+	for _, msg := range msgs {
+		totalTxSize += len(msg.String())
+	}
+	return estimateAdjustedGas(totalTxSize, config)
+}
+
+// Gas of a transaction of txSize, gas adjustment included
+func estimateAdjustedGas(txSize int, config *types.Config) (uint64, error) {
+	gas, err := EstimateGas(txSize, config)
+	if err != nil {
+		return 0, err
+	}
+	// Apply adjustment safely
+	if config.GasAdjustment > 0 {
+		gasFloat := float64(gas) * config.GasAdjustment
+		if gasFloat < math.MaxUint64 {
+			gas = uint64(gasFloat)
+		} else {
+			gas = math.MaxUint64
+		}
+	}
+	return gas, nil
+}
+
 // CalculateFees safely computes the fee amount.
 func CalculateFees(gas uint64, minGasPrice float64) (cosmossdk_io_math.Int, error) {
 	if gas == 0 {
@@ -81,19 +106,3 @@ func parseInsufficientFeeError(errorMessage, denom string) (got uint64, required
 	}
 	return 0, 0, fmt.Errorf("fee values not found in error message")
 }
-
-// Update the gas price periodically
-func RunGasRoutine(
-	config *types.Config,
-) {
-	for {
-		gasPrice, err := lib.GetGasPrice(config)
-		if err != nil {
-			log.Error().Err(err).Msgf("Error getting base fee, will retry: %v", err)
-			// Continue to the sleep and try again
-		} else {
-			lib.SetCurrentGasPrice(gasPrice)
-		}
-		time.Sleep(2 * time.Second)
-	}
-}
@@ -0,0 +1,52 @@
+package common
+
+import (
+	"time"
+
+	"github.com/allora-network/allora-simulator/lib"
+	"github.com/allora-network/allora-simulator/types"
+	"github.com/rs/zerolog/log"
+)
+
+// Sleep waits for d, or until stop is closed. Returns false when stopped. A nil stop never closes.
+func Sleep(stop <-chan struct{}, d time.Duration) bool {
+	select {
+	case <-stop:
+		return false
+	case <-time.After(d):
+		return true
+	}
+}
+
+// RunRoundLoop calls round for every topic every intervalEpochs epochs of that topic, with the block
+// height of the round, until stop is closed. Topics are timed by their own epoch length, and the first
+// round of a topic happens one interval after the loop started.
+func RunRoundLoop(
+	config *types.Config,
+	intervalEpochs int64,
+	topicIds []uint64,
+	epochLength func(topicId uint64) int64,
+	stop <-chan struct{},
+	round func(topicId uint64, height int64),
+) {
+	nextRounds := make(map[uint64]int64, len(topicIds))
+	for {
+		height, err := lib.GetLatestBlockHeight(config)
+		if err != nil {
+			log.Error().Err(err).Msgf("Error getting latest block height, will retry: %v", err)
+		} else {
+			for _, topicId := range topicIds {
+				interval := max(intervalEpochs, 1) * epochLength(topicId)
+				if nextRounds[topicId] == 0 {
+					nextRounds[topicId] = height + interval
+				} else if height >= nextRounds[topicId] {
+					round(topicId, height)
+					nextRounds[topicId] = height + interval
+				}
+			}
+		}
+		if !Sleep(stop, 4*time.Second) {
+			return
+		}
+	}
+}
@@ -0,0 +1,294 @@
+package common
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"slices"
+	"sync"
+	"sync/atomic"
+
+	cosmosmath "cosmossdk.io/math"
+	emissionstypes "github.com/allora-network/allora-chain/x/emissions/types"
+	"github.com/allora-network/allora-simulator/lib"
+	"github.com/allora-network/allora-simulator/types"
+	sdktypes "github.com/cosmos/cosmos-sdk/types"
+	"github.com/rs/zerolog/log"
+)
+
+// Stake distributions
+const (
+	StakeDistributionConstant  = "constant"
+	StakeDistributionUniform   = "uniform"
+	StakeDistributionLognormal = "lognormal"
+	StakeDistributionPareto    = "pareto"
+)
+
+// Stake of every reputer when no distribution is configured
+const defaultStake uint64 = 9e4
+
+// ValidateStakingConfig checks the stake distributions and probabilities of the staking config
+func ValidateStakingConfig(config types.StakingConfig) error {
+	if err := validateStakeDistribution(config.Stake); err != nil {
+		return fmt.Errorf("invalid stake: %w", err)
+	}
+	if err := validateStakeDistribution(config.DelegationStake); err != nil {
+		return fmt.Errorf("invalid delegation_stake: %w", err)
+	}
+	probabilities := map[string]float64{
+		"add_stake_probability":     config.AddStakeProbability,
+		"remove_stake_probability":  config.RemoveStakeProbability,
+		"stake_change_fraction":     config.StakeChangeFraction,
+		"delegate_probability":      config.DelegateProbability,
+		"undelegate_probability":    config.UndelegateProbability,
+		"claim_rewards_probability": config.ClaimRewardsProbability,
+	}
+	for name, p := range probabilities {
+		if p < 0 || p > 1 {
+			return fmt.Errorf("%s must be between 0 and 1, got %v", name, p)
+		}
+	}
+	if config.AddStakeProbability+config.RemoveStakeProbability > 1 {
+		return fmt.Errorf("add_stake_probability and remove_stake_probability must sum to at most 1")
+	}
+	if config.Delegators < 0 {
+		return fmt.Errorf("delegators must not be negative, got %d", config.Delegators)
+	}
+	return nil
+}
+
+func validateStakeDistribution(dist types.StakeDistribution) error {
+	switch dist.Distribution {
+	case "", StakeDistributionConstant:
+	case StakeDistributionUniform:
+		if dist.Max < dist.Min {
+			return fmt.Errorf("uniform max %d is below min %d", dist.Max, dist.Min)
+		}
+	case StakeDistributionLognormal:
+		if dist.Sigma < 0 {
+			return fmt.Errorf("lognormal sigma must not be negative, got %v", dist.Sigma)
+		}
+	case StakeDistributionPareto:
+		if dist.Alpha <= 0 {
+			return fmt.Errorf("pareto alpha must be positive, got %v", dist.Alpha)
+		}
+	default:
+		return fmt.Errorf("unknown stake distribution: %s", dist.Distribution)
+	}
+	return nil
+}
+
+// DrawStake draws a stake amount from a distribution, of at least 1
+func DrawStake(dist types.StakeDistribution) cosmosmath.Int {
+	amount := float64(dist.Amount)
+	if dist.Amount == 0 {
+		amount = float64(defaultStake)
+	}
+
+	value := amount
+	switch dist.Distribution {
+	case StakeDistributionUniform:
+		value = float64(dist.Min) + rand.Float64()*float64(dist.Max-dist.Min)
+	case StakeDistributionLognormal:
+		value = amount * math.Exp(rand.NormFloat64()*dist.Sigma)
+	case StakeDistributionPareto:
+		// Inverse transform sampling, 1 - U is in (0, 1]
+		value = amount / math.Pow(1-rand.Float64(), 1/dist.Alpha)
+	}
+	if dist.Max > 0 {
+		value = math.Min(value, float64(dist.Max))
+	}
+	value = math.Min(math.Max(value, 1), math.MaxInt64)
+	return cosmosmath.NewInt(int64(value))
+}
+
+// StakingState holds the delegations made by the delegators of every topic.
+// It is owned by a single staking loop.
+type StakingState struct {
+	delegators []*types.Actor
+	mu         sync.Mutex
+	// Reputers each delegator delegated to, per topic
+	delegations map[uint64]map[string][]string
+}
+
+func NewStakingState(delegators []*types.Actor) *StakingState {
+	return &StakingState{
+		delegators:  delegators,
+		delegations: make(map[uint64]map[string][]string),
+	}
+}
+
+// Delegations returns the reputers a delegator delegated to in a topic
+func (s *StakingState) Delegations(topicId uint64, delegator string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string{}, s.delegations[topicId][delegator]...)
+}
+
+func (s *StakingState) setDelegations(topicId uint64, delegator string, reputers []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.delegations[topicId] == nil {
+		s.delegations[topicId] = make(map[string][]string)
+	}
+	s.delegations[topicId][delegator] = reputers
+}
+
+type stakingCounts struct {
+	added, removed, delegated, undelegated, claimed atomic.Int32
+}
+
+// Round makes every reputer of a topic add or remove stake, and every delegator delegate,
+// remove a delegation or claim its rewards, with the probabilities of the config
+func (s *StakingState) Round(config *types.StakingConfig, topicId uint64, reputers []*types.Actor) {
+	counts := &stakingCounts{}
+	var wg sync.WaitGroup
+	for _, reputer := range reputers {
+		wg.Add(1)
+		go func(reputer *types.Actor) {
+			defer wg.Done()
+			changeReputerStake(config, topicId, reputer, counts)
+		}(reputer)
+	}
+	for _, delegator := range s.delegators {
+		wg.Add(1)
+		go func(delegator *types.Actor) {
+			defer wg.Done()
+			s.changeDelegations(config, topicId, delegator, reputers, counts)
+		}(delegator)
+	}
+	wg.Wait()
+
+	log.Info().Msgf("Staking round in topic %d: %d stake additions, %d stake removals, %d delegations, %d delegation removals, %d reward claims",
+		topicId, counts.added.Load(), counts.removed.Load(), counts.delegated.Load(), counts.undelegated.Load(), counts.claimed.Load())
+}
+
+// Fraction of an amount, rounded down
+func fractionOf(amount cosmosmath.Int, fraction float64) cosmosmath.Int {
+	return amount.ToLegacyDec().Mul(cosmosmath.LegacyNewDecWithPrec(int64(fraction*1e6), 6)).TruncateInt()
+}
+
+func changeReputerStake(config *types.StakingConfig, topicId uint64, reputer *types.Actor, counts *stakingCounts) {
+	draw := rand.Float64()
+	add := draw < config.AddStakeProbability
+	remove := !add && draw < config.AddStakeProbability+config.RemoveStakeProbability
+	if !add && !remove {
+		return
+	}
+
+	selfStake, err := lib.GetReputerSelfStakeInTopic(reputer.TxParams.Config, topicId, reputer.Addr)
+	if err != nil {
+		log.Error().Err(err).Msgf("Error getting self stake of reputer %s in topic %d", reputer.Addr, topicId)
+		return
+	}
+	amount := fractionOf(selfStake, config.StakeChangeFraction)
+
+	var msg sdktypes.Msg
+	if add {
+		if !amount.IsPositive() {
+			amount = DrawStake(config.Stake)
+		}
+		msg = &emissionstypes.AddStakeRequest{Sender: reputer.Addr, TopicId: topicId, Amount: amount}
+	} else {
+		if !amount.IsPositive() {
+			return
+		}
+		msg = &emissionstypes.RemoveStakeRequest{Sender: reputer.Addr, TopicId: topicId, Amount: amount}
+	}
+	if err := sendStakingMsgs(reputer, msg); err != nil {
+		log.Error().Err(err).Msgf("Error changing stake of reputer %s in topic %d", reputer.Addr, topicId)
+		return
+	}
+	if add {
+		counts.added.Add(1)
+	} else {
+		counts.removed.Add(1)
+	}
+}
+
+func (s *StakingState) changeDelegations(
+	config *types.StakingConfig,
+	topicId uint64,
+	delegator *types.Actor,
+	reputers []*types.Actor,
+	counts *stakingCounts,
+) {
+	delegations := s.Delegations(topicId, delegator.Addr)
+
+	if len(delegations) > 0 && rand.Float64() < config.ClaimRewardsProbability {
+		msgs := make([]sdktypes.Msg, len(delegations))
+		for i, reputer := range delegations {
+			msgs[i] = &emissionstypes.RewardDelegateStakeRequest{Sender: delegator.Addr, TopicId: topicId, Reputer: reputer}
+		}
+		if err := sendStakingMsgs(delegator, msgs...); err != nil {
+			log.Error().Err(err).Msgf("Error claiming delegation rewards of %s in topic %d", delegator.Addr, topicId)
+		} else {
+			counts.claimed.Add(int32(len(msgs)))
+		}
+	}
+
+	if len(delegations) > 0 && rand.Float64() < config.UndelegateProbability {
+		idx := rand.Intn(len(delegations))
+		reputer := delegations[idx]
+		stake, err := lib.GetDelegatorStakeInReputer(delegator.TxParams.Config, topicId, delegator.Addr, reputer)
+		if err != nil {
+			log.Error().Err(err).Msgf("Error getting stake of delegator %s upon %s in topic %d", delegator.Addr, reputer, topicId)
+		} else if stake.IsPositive() {
+			err = sendStakingMsgs(delegator, &emissionstypes.RemoveDelegateStakeRequest{
+				Sender:  delegator.Addr,
+				Reputer: reputer,
+				TopicId: topicId,
+				Amount:  stake,
+			})
+			if err != nil {
+				log.Error().Err(err).Msgf("Error removing delegation of %s upon %s in topic %d", delegator.Addr, reputer, topicId)
+			} else {
+				counts.undelegated.Add(1)
+				delegations = append(delegations[:idx], delegations[idx+1:]...)
+			}
+		}
+	}
+
+	if len(reputers) > 0 && rand.Float64() < config.DelegateProbability {
+		reputer := reputers[rand.Intn(len(reputers))].Addr
+		err := sendStakingMsgs(delegator, &emissionstypes.DelegateStakeRequest{
+			Sender:  delegator.Addr,
+			TopicId: topicId,
+			Reputer: reputer,
+			Amount:  DrawStake(config.DelegationStake),
+		})
+		if err != nil {
+			log.Error().Err(err).Msgf("Error delegating stake of %s upon %s in topic %d", delegator.Addr, reputer, topicId)
+		} else {
+			counts.delegated.Add(1)
+			if !slices.Contains(delegations, reputer) {
+				delegations = append(delegations, reputer)
+			}
+		}
+	}
+
+	s.setDelegations(topicId, delegator.Addr, delegations)
+}
+
+// Reputers send their payloads from the same accounts meanwhile, the sends hold the lock of the actor.
+// A transaction the chain did not accept is an error, so that it is neither counted nor recorded.
+func sendStakingMsgs(actor *types.Actor, msgs ...sdktypes.Msg) error {
+	_, err := SendFromActor(actor, true, msgs...)
+	return err
+}
+
+// RunStakingLoop runs a staking round in every topic every config.Staking.IntervalEpochs epochs of the topic,
+// with the reputers currently registered in each topic, until stop is closed
+func RunStakingLoop(
+	config *types.Config,
+	epochLength func(topicId uint64) int64,
+	delegators []*types.Actor,
+	topicIds []uint64,
+	getReputers func(topicId uint64) []*types.Actor,
+	stop <-chan struct{},
+) {
+	state := NewStakingState(delegators)
+	RunRoundLoop(config, config.Staking.IntervalEpochs, topicIds, epochLength, stop, func(topicId uint64, height int64) {
+		state.Round(&config.Staking, topicId, getReputers(topicId))
+	})
+}
@@ -0,0 +1,65 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/allora-network/allora-simulator/types"
+)
+
+func TestDrawStake(t *testing.T) {
+	if stake := DrawStake(types.StakeDistribution{}); stake.Uint64() != defaultStake {
+		t.Errorf("expected default stake %d, got %s", defaultStake, stake)
+	}
+
+	uniform := types.StakeDistribution{Distribution: StakeDistributionUniform, Min: 100, Max: 200}
+	pareto := types.StakeDistribution{Distribution: StakeDistributionPareto, Amount: 1000, Alpha: 1.5, Max: 5000}
+	for i := 0; i < 1000; i++ {
+		if stake := DrawStake(uniform).Uint64(); stake < 100 || stake > 200 {
+			t.Fatalf("uniform stake %d out of [100, 200]", stake)
+		}
+		if stake := DrawStake(pareto).Uint64(); stake < 1000 || stake > 5000 {
+			t.Fatalf("pareto stake %d out of [1000, 5000]", stake)
+		}
+	}
+}
+
+func TestValidateStakingConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  types.StakingConfig
+		wantErr bool
+	}{
+		{"empty", types.StakingConfig{}, false},
+		{"lognormal", types.StakingConfig{Stake: types.StakeDistribution{Distribution: StakeDistributionLognormal, Sigma: 1}}, false},
+		{"unknown distribution", types.StakingConfig{Stake: types.StakeDistribution{Distribution: "zipf"}}, true},
+		{"pareto without alpha", types.StakingConfig{DelegationStake: types.StakeDistribution{Distribution: StakeDistributionPareto}}, true},
+		{"probability above 1", types.StakingConfig{DelegateProbability: 1.5}, true},
+		{"add and remove above 1", types.StakingConfig{AddStakeProbability: 0.6, RemoveStakeProbability: 0.6}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateStakingConfig(tt.config); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateStakingConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestChangeDelegationsFailedTxs(t *testing.T) {
+	delegator := newUnreachableActors(t, 1)[0]
+	reputers := newTestActors(2)
+	state := NewStakingState([]*types.Actor{delegator})
+	state.setDelegations(1, delegator.Addr, []string{reputers[0].Addr})
+
+	counts := &stakingCounts{}
+	config := &types.StakingConfig{ClaimRewardsProbability: 1, DelegateProbability: 1}
+	state.changeDelegations(config, 1, delegator, reputers[1:], counts)
+
+	if counts.claimed.Load() != 0 || counts.delegated.Load() != 0 {
+		t.Errorf("expected no counted changes when every broadcast fails, got %d claims and %d delegations",
+			counts.claimed.Load(), counts.delegated.Load())
+	}
+	if delegations := state.Delegations(1, delegator.Addr); len(delegations) != 1 || delegations[0] != reputers[0].Addr {
+		t.Errorf("expected only the earlier delegation to be recorded, got %v", delegations)
+	}
+}
@@ -4,9 +4,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"math"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	cosmosmath "cosmossdk.io/math"
@@ -30,6 +30,8 @@ func BuildAndSignTransaction(
 	ctx context.Context,
 	txParams *types.TransactionParams,
 	sequence uint64,
+	attempt int64,
+	requiredFee uint64,
 	encodingConfig moduletestutil.TestEncodingConfig,
 	msgs ...sdktypes.Msg,
 ) ([]byte, error) {
@@ -47,38 +49,20 @@ func BuildAndSignTransaction(
 	}
 
 	// Estimate gas limit
-	totalTxSize := 0
-	for _, msg := range msgs {
-		totalTxSize += len(msg.String())
-	}
-	gas, err := EstimateGas(totalTxSize, txParams.Config)
+	gas, err := EstimateMsgsGas(txParams.Config, msgs...)
 	if err != nil {
 		return nil, err
 	}
-	// Apply adjustment safely
-	if txParams.Config.GasAdjustment > 0 {
-		gasFloat := float64(gas) * txParams.Config.GasAdjustment
-		if gasFloat < math.MaxUint64 {
-			gas = uint64(gasFloat)
-		} else {
-			gas = math.MaxUint64
-		}
-	}
 	txBuilder.SetGasLimit(gas)
 
-	var fees cosmosmath.Int
-	if txParams.Config.OverrideFee > 0 {
-		// Set the gas price to the override value
-		fees = cosmosmath.NewIntFromUint64(txParams.Config.OverrideFee)
-		// Reset the override fee
-		txParams.Config.OverrideFee = 0
-	} else {
-		// Calculate fee
-		minGasPrice := lib.GetCurrentGasPrice()
-		fees, err = CalculateFees(gas, minGasPrice)
-		if err != nil {
-			return nil, err
-		}
+	fees, err := calculateTxFees(txParams, types.FeeRequest{
+		Gas:         gas,
+		Msgs:        msgs,
+		Attempt:     attempt,
+		RequiredFee: requiredFee,
+	})
+	if err != nil {
+		return nil, err
 	}
 	feeCoin := sdktypes.NewCoin(txParams.Config.Denom, fees)
 	txBuilder.SetFeeAmount(sdktypes.NewCoins(feeCoin))
@@ -134,6 +118,54 @@ func BuildAndSignTransaction(
 	return txBytes, nil
 }
 
+// Fee of a transaction: the fee of the actor's strategy, raised to the fee the chain required
+// and capped at the max fees when they are set
+func calculateTxFees(txParams *types.TransactionParams, req types.FeeRequest) (cosmosmath.Int, error) {
+	if txParams.Fees == nil {
+		return cosmosmath.Int{}, fmt.Errorf("no fee strategy to price the transaction")
+	}
+	fees, err := txParams.Fees.Fee(req)
+	if err != nil {
+		return cosmosmath.Int{}, err
+	}
+	if required := cosmosmath.NewIntFromUint64(req.RequiredFee); fees.LT(required) {
+		fees = required
+	}
+	if maxFees := txParams.Config.MaxFees; maxFees > 0 && fees.GT(cosmosmath.NewIntFromUint64(maxFees)) {
+		log.Warn().Msgf("Fee %s is greater than max fees %d, paying the max fees", fees, maxFees)
+		fees = cosmosmath.NewIntFromUint64(maxFees)
+	}
+	return fees, nil
+}
+
+// Locks of the actors sending transactions, by address
+var actorLocks sync.Map
+
+// LockActor serializes the sends of an actor, so that loops sending from the same actor never read and
+// write its sequence concurrently. The returned function releases the lock.
+func LockActor(actor *types.Actor) func() {
+	lock, _ := actorLocks.LoadOrStore(actor.Addr, &sync.Mutex{})
+	mu := lock.(*sync.Mutex)
+	mu.Lock()
+	return mu.Unlock
+}
+
+// ErrTxNotAccepted is returned when the chain did not accept a transaction on any of its retries
+var ErrTxNotAccepted = errors.New("transaction not accepted")
+
+// SendFromActor sends msgs from an actor with SendDataWithRetry under the lock of the actor,
+// and records the sequence the actor reached. Unlike SendDataWithRetry, a transaction the chain
+// did not accept is an error.
+func SendFromActor(actor *types.Actor, waitForTx bool, msgs ...sdktypes.Msg) (*coretypes.ResultBroadcastTx, error) {
+	defer LockActor(actor)()
+	resp, updatedSeq, err := SendDataWithRetry(actor.TxParams, waitForTx, msgs...)
+	actor.TxParams.Sequence = updatedSeq
+	if err == nil && (resp == nil || resp.Code != 0) {
+		err = fmt.Errorf("%w after %d retries", ErrTxNotAccepted, maxRetries)
+	}
+	return resp, err
+}
+
 // Loop handles the main transaction broadcasting logic
 func SendDataWithRetry(
 	txParams *types.TransactionParams,
@@ -141,18 +173,20 @@ func SendDataWithRetry(
 	msgs ...sdktypes.Msg,
 ) (*coretypes.ResultBroadcastTx, uint64, error) {
 	sequence := txParams.Sequence
+	// Fee the chain asked for, kept per call so actors sharing the config don't pay each other's fees
+	var requiredFee uint64
 
 	for retryCount := int64(0); retryCount <= maxRetries; retryCount++ {
 		currentSequence := sequence
 
-		resp, _, err := sendTransactionViaRPC(txParams, currentSequence, waitForTx, msgs...)
+		resp, _, err := sendTransactionViaRPC(txParams, currentSequence, retryCount, requiredFee, waitForTx, msgs...)
 		if err != nil {
 			log.Error().Msgf("Transaction failed: %v", err)
 			log.Info().Msgf("Handling error and retrying...")
 
 			// if sequence mismatch, handle it and retry
 			if strings.Contains(err.Error(), "account sequence mismatch") {
-				resp, newSeq, err := handleSequenceMismatch(txParams, sequence, waitForTx, err, msgs...)
+				resp, newSeq, err := handleSequenceMismatch(txParams, sequence, retryCount, requiredFee, waitForTx, err, msgs...)
 				if err == nil {
 					sequence = newSeq
 					return resp, sequence, nil
@@ -165,7 +199,7 @@ func SendDataWithRetry(
 				time.Sleep(delay)
 				continue
 			}
-			// if fee is too low, set override fee and retry
+			// if fee is too low, retry with the required fee
 			if strings.Contains(err.Error(), "insufficient fee") {
 				got, required, err := parseInsufficientFeeError(err.Error(), txParams.Config.Denom)
 				if err != nil {
@@ -173,12 +207,7 @@ func SendDataWithRetry(
 					continue
 				}
 				log.Debug().Msgf("Retrying tx with required fee, got %d, required %d", got, required)
-				if required > txParams.Config.MaxFees {
-					log.Error().Msgf("Required fee %d is greater than max fees %d", required, txParams.Config.MaxFees)
-					txParams.Config.OverrideFee = txParams.Config.MaxFees
-				} else {
-					txParams.Config.OverrideFee = required
-				}
+				requiredFee = required
 				delay := calculateLinearBackoffDelay(retryDelay, retryCount+1)
 				time.Sleep(delay)
 				continue
@@ -211,21 +240,59 @@ func SendDataWithRetry(
 	return nil, sequence, nil
 }
 
+// SendDataOnce broadcasts msgs once and waits for them to be included in a block.
+// Unlike SendDataWithRetry it does not retry rejected transactions and a rejection is not an error,
+// the code and codespace of the response tell why the chain rejected the transaction.
+func SendDataOnce(
+	txParams *types.TransactionParams,
+	msgs ...sdktypes.Msg,
+) (*coretypes.ResultBroadcastTx, uint64, error) {
+	sequence := txParams.Sequence
+	resp, _, err := sendTransactionViaRPC(txParams, sequence, 0, 0, true, msgs...)
+	if err != nil && strings.Contains(err.Error(), "account sequence mismatch") {
+		expectedSeq, parseErr := extractExpectedSequence(err.Error())
+		if parseErr == nil {
+			sequence = expectedSeq
+			resp, _, err = sendTransactionViaRPC(txParams, sequence, 1, 0, true, msgs...)
+		}
+	}
+	if resp == nil {
+		return nil, sequence, err
+	}
+	if err != nil {
+		// Rejected by CheckTx, the sequence was not used
+		return resp, sequence, nil
+	}
+	return resp, sequence + 1, nil
+}
+
+// BroadcastOnce broadcasts msgs at sequence without waiting for them to be included in a block.
+// A transaction rejected by CheckTx returns its response along with the error.
+func BroadcastOnce(
+	txParams *types.TransactionParams,
+	sequence uint64,
+	attempt int64,
+	msgs ...sdktypes.Msg,
+) (*coretypes.ResultBroadcastTx, error) {
+	resp, _, err := sendTransactionViaRPC(txParams, sequence, attempt, 0, false, msgs...)
+	return resp, err
+}
+
 // sendTransactionViaRPC sends a transaction using the provided TransactionParams and sequence number.
-func sendTransactionViaRPC(txParams *types.TransactionParams, sequence uint64, waitForTx bool, msgs ...sdktypes.Msg) (*coretypes.ResultBroadcastTx, string, error) {
+// attempt and requiredFee tell the fee strategy how the previous attempts went.
+func sendTransactionViaRPC(txParams *types.TransactionParams, sequence uint64, attempt int64, requiredFee uint64, waitForTx bool, msgs ...sdktypes.Msg) (*coretypes.ResultBroadcastTx, string, error) {
 	encodingConfig := moduletestutil.MakeTestEncodingConfig()
 	encodingConfig.Codec = cdc
 
 	ctx := context.Background()
 
 	// Build and sign the transaction
-	txBytes, err := BuildAndSignTransaction(ctx, txParams, sequence, encodingConfig, msgs...)
+	txBytes, err := BuildAndSignTransaction(ctx, txParams, sequence, attempt, requiredFee, encodingConfig, msgs...)
 	if err != nil {
 		return nil, "", err
 	}
 
-	// Broadcast the transaction via RPC
-	resp, err := broadcastTransaction(txBytes, txParams.Config.Nodes.RPC[0], waitForTx)
+	resp, err := broadcastTransaction(txParams.Config, txBytes, waitForTx)
 	if err != nil {
 		return resp, string(txBytes), fmt.Errorf("failed to broadcast transaction: %w", err)
 	}
@@ -233,13 +300,22 @@ func sendTransactionViaRPC(txParams *types.TransactionParams, sequence uint64, w
 	return resp, string(txBytes), nil
 }
 
-// broadcastTransaction broadcasts the transaction bytes to the given RPC endpoint.
-func broadcastTransaction(txBytes []byte, rpcEndpoint string, waitForTx bool) (*coretypes.ResultBroadcastTx, error) {
-	client, err := client.GetClient(rpcEndpoint)
+// broadcastTransaction broadcasts the transaction bytes through the transport of the config,
+// the tx service of the gRPC endpoint or the first RPC endpoint.
+func broadcastTransaction(config *types.Config, txBytes []byte, waitForTx bool) (*coretypes.ResultBroadcastTx, error) {
+	var broadcaster interface {
+		BroadcastTx(txBytes []byte, waitForTx bool) (*coretypes.ResultBroadcastTx, error)
+	}
+	var err error
+	if lib.UsesGRPC(config) {
+		broadcaster, err = client.GetGRPCClient(config.Nodes.GRPC)
+	} else {
+		broadcaster, err = client.GetClient(config.Nodes.RPC[0])
+	}
 	if err != nil {
 		return nil, err
 	}
-	resp, err := client.BroadcastTx(txBytes, waitForTx)
+	resp, err := broadcaster.BroadcastTx(txBytes, waitForTx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to broadcast transaction: %w", err)
 	}
@@ -248,13 +324,13 @@ func broadcastTransaction(txBytes []byte, rpcEndpoint string, waitForTx bool) (*
 }
 
 // handleSequenceMismatch handles the case where a transaction fails due to sequence mismatch
-func handleSequenceMismatch(txParams *types.TransactionParams, sequence uint64, waitForTx bool, err error, msgs ...sdktypes.Msg) (*coretypes.ResultBroadcastTx, uint64, error) {
+func handleSequenceMismatch(txParams *types.TransactionParams, sequence uint64, attempt int64, requiredFee uint64, waitForTx bool, err error, msgs ...sdktypes.Msg) (*coretypes.ResultBroadcastTx, uint64, error) {
 	expectedSeq, parseErr := extractExpectedSequence(err.Error())
 	if parseErr != nil {
 		return nil, sequence, nil
 	}
 
-	resp, _, err := sendTransactionViaRPC(txParams, expectedSeq, waitForTx, msgs...)
+	resp, _, err := sendTransactionViaRPC(txParams, expectedSeq, attempt, requiredFee, waitForTx, msgs...)
 	if err != nil {
 		return nil, expectedSeq, err
 	}
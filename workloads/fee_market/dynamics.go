@@ -0,0 +1,203 @@
+package fee_market
+
+import (
+	"math"
+	"sort"
+)
+
+// The base fee has recovered once it is back within this fraction above its level before the peak
+const recoveryTolerance = 0.1
+
+// StageDynamics is how the base fee moved during a stage.
+// Rates are relative changes of the gas price from one block to the next.
+type StageDynamics struct {
+	Stage          int
+	TargetFullness float64
+	Blocks         int
+	MeanFullness   float64
+	StartPrice     float64
+	EndPrice       float64
+	// Mean and max rate over the blocks where the price rose, NaN when it never did
+	MeanRise float64
+	MaxRise  float64
+	// Mean and max rate of decrease over the blocks where the price fell, NaN when it never did
+	MeanFall float64
+	MaxFall  float64
+}
+
+// StrategyOutcomes counts what became of the txs of a strategy during a stage, or the whole run when Stage is -1
+type StrategyOutcomes struct {
+	Strategy  string
+	Stage     int
+	Sent      int
+	Included  int
+	PricedOut int
+	Dropped   int
+	Failed    int
+	// Mean blocks from sending to inclusion of the included txs
+	MeanInclusionBlocks float64
+}
+
+// PricedOutRate is the fraction of the txs priced out at CheckTx or dropped from the mempool
+func (o StrategyOutcomes) PricedOutRate() float64 {
+	if o.Sent == 0 {
+		return math.NaN()
+	}
+	return float64(o.PricedOut+o.Dropped) / float64(o.Sent)
+}
+
+// FeeDynamics summarizes the base fee and the load of a run
+type FeeDynamics struct {
+	Stages []StageDynamics
+	// Lowest price before the peak and the peak
+	MinPrice   float64
+	PeakPrice  float64
+	PeakHeight int64
+	// Blocks from the lowest price to the peak
+	BlocksToPeak int64
+	// Blocks from the peak until the price is back near its level before the peak, -1 when it never is
+	RecoveryBlocks int64
+	Strategies     []StrategyOutcomes
+}
+
+// AnalyzeFeeDynamics measures how fast the base fee rose and fell and how each strategy fared
+func AnalyzeFeeDynamics(blocks []BlockSample, txs []TxOutcome) *FeeDynamics {
+	dynamics := &FeeDynamics{RecoveryBlocks: -1}
+	if len(blocks) == 0 {
+		return dynamics
+	}
+
+	peak := 0
+	for i, b := range blocks {
+		if b.GasPrice > blocks[peak].GasPrice {
+			peak = i
+		}
+	}
+	low := 0
+	for i := 0; i <= peak; i++ {
+		if blocks[i].GasPrice < blocks[low].GasPrice {
+			low = i
+		}
+	}
+	dynamics.MinPrice = blocks[low].GasPrice
+	dynamics.PeakPrice = blocks[peak].GasPrice
+	dynamics.PeakHeight = blocks[peak].Height
+	dynamics.BlocksToPeak = blocks[peak].Height - blocks[low].Height
+	for _, b := range blocks[peak:] {
+		if b.GasPrice <= dynamics.MinPrice*(1+recoveryTolerance) {
+			dynamics.RecoveryBlocks = b.Height - blocks[peak].Height
+			break
+		}
+	}
+
+	dynamics.Stages = analyzeStages(blocks)
+	dynamics.Strategies = countOutcomes(txs)
+	return dynamics
+}
+
+func analyzeStages(blocks []BlockSample) []StageDynamics {
+	stages := make([]StageDynamics, 0)
+	var rises, falls, fullness []float64
+	flush := func() {
+		stage := &stages[len(stages)-1]
+		stage.MeanFullness = mean(fullness)
+		stage.MeanRise, stage.MaxRise = mean(rises), maxOf(rises)
+		stage.MeanFall, stage.MaxFall = mean(falls), maxOf(falls)
+		rises, falls, fullness = nil, nil, nil
+	}
+	for i, b := range blocks {
+		if len(stages) == 0 || stages[len(stages)-1].Stage != b.Stage {
+			if len(stages) > 0 {
+				flush()
+			}
+			stages = append(stages, StageDynamics{Stage: b.Stage, TargetFullness: b.TargetFullness, StartPrice: b.GasPrice})
+		}
+		stage := &stages[len(stages)-1]
+		stage.Blocks++
+		stage.EndPrice = b.GasPrice
+		fullness = append(fullness, b.Fullness)
+		// The first block of a stage moves from the last price of the previous one
+		if i > 0 && blocks[i-1].GasPrice > 0 {
+			rate := b.GasPrice/blocks[i-1].GasPrice - 1
+			if rate > 0 {
+				rises = append(rises, rate)
+			} else if rate < 0 {
+				falls = append(falls, -rate)
+			}
+		}
+	}
+	flush()
+	return stages
+}
+
+// Outcomes by strategy, per stage then over the whole run
+func countOutcomes(txs []TxOutcome) []StrategyOutcomes {
+	type key struct {
+		strategy string
+		stage    int
+	}
+	counts := make(map[key]*StrategyOutcomes)
+	inclusionBlocks := make(map[key][]float64)
+	for _, tx := range txs {
+		for _, k := range []key{{tx.Strategy, tx.Stage}, {tx.Strategy, -1}} {
+			outcomes, ok := counts[k]
+			if !ok {
+				outcomes = &StrategyOutcomes{Strategy: k.strategy, Stage: k.stage}
+				counts[k] = outcomes
+			}
+			outcomes.Sent++
+			switch tx.Outcome {
+			case OutcomeIncluded:
+				outcomes.Included++
+				inclusionBlocks[k] = append(inclusionBlocks[k], float64(tx.Blocks))
+			case OutcomePricedOut:
+				outcomes.PricedOut++
+			case OutcomeDropped:
+				outcomes.Dropped++
+			default:
+				outcomes.Failed++
+			}
+		}
+	}
+
+	result := make([]StrategyOutcomes, 0, len(counts))
+	for k, outcomes := range counts {
+		outcomes.MeanInclusionBlocks = mean(inclusionBlocks[k])
+		result = append(result, *outcomes)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Strategy != result[j].Strategy {
+			return result[i].Strategy < result[j].Strategy
+		}
+		// The whole run last
+		if (result[i].Stage == -1) != (result[j].Stage == -1) {
+			return result[j].Stage == -1
+		}
+		return result[i].Stage < result[j].Stage
+	})
+	return result
+}
+
+// Mean of values, NaN when there are none
+func mean(values []float64) float64 {
+	if len(values) == 0 {
+		return math.NaN()
+	}
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+// Max of values, NaN when there are none
+func maxOf(values []float64) float64 {
+	if len(values) == 0 {
+		return math.NaN()
+	}
+	m := values[0]
+	for _, v := range values[1:] {
+		m = math.Max(m, v)
+	}
+	return m
+}
@@ -0,0 +1,68 @@
+package fee_market
+
+import (
+	"math"
+	"testing"
+)
+
+func TestAnalyzeFeeDynamics(t *testing.T) {
+	prices := []float64{10, 10, 12, 15, 15, 12, 10.5, 10}
+	blocks := make([]BlockSample, len(prices))
+	for i, price := range prices {
+		stage := 0
+		if i >= 4 {
+			stage = 1
+		}
+		blocks[i] = BlockSample{Height: int64(100 + i), Stage: stage, GasPrice: price, Fullness: 1 - float64(stage)}
+	}
+	txs := []TxOutcome{
+		{Strategy: "low", Stage: 0, Outcome: OutcomePricedOut},
+		{Strategy: "low", Stage: 0, Outcome: OutcomeDropped},
+		{Strategy: "low", Stage: 1, Outcome: OutcomeIncluded, Blocks: 3},
+		{Strategy: "high", Stage: 0, Outcome: OutcomeIncluded, Blocks: 1},
+	}
+
+	dynamics := AnalyzeFeeDynamics(blocks, txs)
+	if dynamics.PeakPrice != 15 || dynamics.PeakHeight != 103 || dynamics.MinPrice != 10 {
+		t.Errorf("expected a peak of 15 at 103 from 10, got %v at %d from %v", dynamics.PeakPrice, dynamics.PeakHeight, dynamics.MinPrice)
+	}
+	if dynamics.BlocksToPeak != 3 {
+		t.Errorf("expected 3 blocks to the peak, got %d", dynamics.BlocksToPeak)
+	}
+	// 10.5 is within 10% of the low
+	if dynamics.RecoveryBlocks != 3 {
+		t.Errorf("expected 3 blocks to recover, got %d", dynamics.RecoveryBlocks)
+	}
+
+	if len(dynamics.Stages) != 2 {
+		t.Fatalf("expected 2 stages, got %d", len(dynamics.Stages))
+	}
+	ramp, cooldown := dynamics.Stages[0], dynamics.Stages[1]
+	if math.Abs(ramp.MeanRise-0.225) > 1e-9 || math.Abs(ramp.MaxRise-0.25) > 1e-9 || !math.IsNaN(ramp.MeanFall) {
+		t.Errorf("expected rises of 20%% and 25%% and no fall in the ramp, got %+v", ramp)
+	}
+	if cooldown.Blocks != 4 || cooldown.StartPrice != 15 || cooldown.EndPrice != 10 || math.Abs(cooldown.MaxFall-0.2) > 1e-9 {
+		t.Errorf("unexpected cooldown %+v", cooldown)
+	}
+
+	expected := []StrategyOutcomes{
+		{Strategy: "high", Stage: 0, Sent: 1, Included: 1},
+		{Strategy: "high", Stage: -1, Sent: 1, Included: 1},
+		{Strategy: "low", Stage: 0, Sent: 2, PricedOut: 1, Dropped: 1},
+		{Strategy: "low", Stage: 1, Sent: 1, Included: 1},
+		{Strategy: "low", Stage: -1, Sent: 3, Included: 1, PricedOut: 1, Dropped: 1},
+	}
+	if len(dynamics.Strategies) != len(expected) {
+		t.Fatalf("expected %d strategy outcomes, got %d", len(expected), len(dynamics.Strategies))
+	}
+	for i, want := range expected {
+		got := dynamics.Strategies[i]
+		got.MeanInclusionBlocks = 0
+		if got != want {
+			t.Errorf("expected %+v, got %+v", want, got)
+		}
+	}
+	if rate := dynamics.Strategies[4].PricedOutRate(); math.Abs(rate-2.0/3) > 1e-9 {
+		t.Errorf("expected 2 of 3 txs of low priced out, got %v", rate)
+	}
+}
@@ -0,0 +1,133 @@
+package fee_market
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+)
+
+// Files written to the run directory
+const (
+	BlocksFile = "blocks.csv"
+	TxsFile    = "txs.csv"
+	ReportFile = "fee_dynamics.md"
+)
+
+// Outcomes of the txs of the load
+const (
+	OutcomeIncluded = "included"
+	// Rejected by CheckTx for a fee under the base fee
+	OutcomePricedOut = "priced_out"
+	// Still not included after the inclusion blocks, evicted or outbid in the mempool
+	OutcomeDropped = "dropped"
+	OutcomeFailed  = "failed"
+)
+
+// BlockSample is the state of the fee market after a block
+type BlockSample struct {
+	Height int64
+	Stage  int
+	// Fullness the load aimed at during the block
+	TargetFullness float64
+	// Gas price of the fee market once the block is committed
+	GasPrice  float64
+	GasUsed   int64
+	GasWanted int64
+	Txs       int
+	// Gas used over the max block utilization
+	Fullness float64
+}
+
+// TxOutcome is what became of one tx of the load
+type TxOutcome struct {
+	Strategy string
+	Stage    int
+	// Height the tx was sent after
+	Height int64
+	// Priced out attempts before this one
+	Attempt int64
+	Outcome string
+	// Blocks until the tx was included, 0 when it was not
+	Blocks int64
+}
+
+// recorder collects the samples and outcomes of a run, txs report from many goroutines
+type recorder struct {
+	mu     sync.Mutex
+	blocks []BlockSample
+	txs    []TxOutcome
+}
+
+func (r *recorder) recordBlock(sample BlockSample) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.blocks = append(r.blocks, sample)
+}
+
+func (r *recorder) recordTx(outcome TxOutcome) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.txs = append(r.txs, outcome)
+}
+
+// Copies of the samples and outcomes recorded so far
+func (r *recorder) results() ([]BlockSample, []TxOutcome) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]BlockSample{}, r.blocks...), append([]TxOutcome{}, r.txs...)
+}
+
+// Write the block samples and tx outcomes of a run as csv files of dir
+func writeResults(dir string, blocks []BlockSample, txs []TxOutcome) error {
+	blockRows := make([][]string, len(blocks))
+	for i, b := range blocks {
+		blockRows[i] = []string{
+			strconv.FormatInt(b.Height, 10),
+			strconv.Itoa(b.Stage),
+			strconv.FormatFloat(b.TargetFullness, 'g', -1, 64),
+			strconv.FormatFloat(b.GasPrice, 'g', -1, 64),
+			strconv.FormatInt(b.GasUsed, 10),
+			strconv.FormatInt(b.GasWanted, 10),
+			strconv.Itoa(b.Txs),
+			strconv.FormatFloat(b.Fullness, 'g', -1, 64),
+		}
+	}
+	blockHeader := []string{"block_height", "stage", "target_fullness", "gas_price", "gas_used", "gas_wanted", "txs", "fullness"}
+	if err := writeCSV(filepath.Join(dir, BlocksFile), blockHeader, blockRows); err != nil {
+		return err
+	}
+
+	txRows := make([][]string, len(txs))
+	for i, tx := range txs {
+		txRows[i] = []string{
+			tx.Strategy,
+			strconv.Itoa(tx.Stage),
+			strconv.FormatInt(tx.Height, 10),
+			strconv.FormatInt(tx.Attempt, 10),
+			tx.Outcome,
+			strconv.FormatInt(tx.Blocks, 10),
+		}
+	}
+	txHeader := []string{"strategy", "stage", "block_height", "attempt", "outcome", "blocks"}
+	return writeCSV(filepath.Join(dir, TxsFile), txHeader, txRows)
+}
+
+func writeCSV(path string, header []string, rows [][]string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	defer file.Close()
+
+	w := csv.NewWriter(file)
+	if err := w.Write(header); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := w.WriteAll(rows); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package fee_market
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/allora-network/allora-simulator/workloads/research/analysis"
+)
+
+func formatValue(v float64) string {
+	if math.IsNaN(v) {
+		return "n/a"
+	}
+	return strconv.FormatFloat(v, 'g', 4, 64)
+}
+
+func formatPercent(v float64) string {
+	if math.IsNaN(v) {
+		return "n/a"
+	}
+	return strconv.FormatFloat(100*v, 'f', 2, 64) + "%"
+}
+
+func writeTable(b *strings.Builder, header []string, rows [][]string) {
+	b.WriteString("\n| " + strings.Join(header, " | ") + " |\n")
+	b.WriteString("|" + strings.Repeat("---|", len(header)) + "\n")
+	for _, row := range rows {
+		b.WriteString("| " + strings.Join(row, " | ") + " |\n")
+	}
+}
+
+// writeReport writes the fee dynamics report of a run to dir, with its charts, and returns its path
+func writeReport(dir string, maxBlockUtilization, txGas uint64, blocks []BlockSample, dynamics *FeeDynamics) (string, error) {
+	chartsDir := filepath.Join(dir, "charts")
+	if err := os.MkdirAll(chartsDir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create charts directory: %w", err)
+	}
+	heights := make([]float64, len(blocks))
+	price := analysis.Series{Name: "gas price"}
+	fullness := analysis.Series{Name: "fullness"}
+	target := analysis.Series{Name: "target fullness"}
+	for i, b := range blocks {
+		heights[i] = float64(b.Height)
+		price.Values = append(price.Values, b.GasPrice)
+		fullness.Values = append(fullness.Values, b.Fullness)
+		target.Values = append(target.Values, b.TargetFullness)
+	}
+	charts := map[string]string{
+		"gas_price": analysis.LineChart("Base fee", "block", "gas price", heights, []analysis.Series{price}),
+		"fullness":  analysis.LineChart("Block fullness", "block", "gas used / max block utilization", heights, []analysis.Series{fullness, target}),
+	}
+	for name, svg := range charts {
+		if err := os.WriteFile(filepath.Join(chartsDir, name+".svg"), []byte(svg), 0o644); err != nil {
+			return "", fmt.Errorf("failed to write chart %s: %w", name, err)
+		}
+	}
+
+	path := filepath.Join(dir, ReportFile)
+	if err := os.WriteFile(path, []byte(renderReport(maxBlockUtilization, txGas, dynamics)), 0o644); err != nil {
+		return "", fmt.Errorf("failed to write report: %w", err)
+	}
+	return path, nil
+}
+
+func renderReport(maxBlockUtilization, txGas uint64, dynamics *FeeDynamics) string {
+	var b strings.Builder
+	b.WriteString("# Fee market dynamics\n\n")
+	fmt.Fprintf(&b, "Max block utilization: %d gas, %d gas per load tx.\n", maxBlockUtilization, txGas)
+
+	b.WriteString("\n## Base fee\n\n")
+	recovery := "never"
+	if dynamics.RecoveryBlocks >= 0 {
+		recovery = fmt.Sprintf("%d blocks", dynamics.RecoveryBlocks)
+	}
+	fmt.Fprintf(&b, "The base fee rose from %s to a peak of %s at block %d in %d blocks, and took %s to fall back within %s of its low.\n",
+		formatValue(dynamics.MinPrice), formatValue(dynamics.PeakPrice), dynamics.PeakHeight, dynamics.BlocksToPeak,
+		recovery, formatPercent(recoveryTolerance))
+	b.WriteString("\nRise and fall rates are relative changes of the gas price from one block to the next.\n")
+	stageRows := make([][]string, len(dynamics.Stages))
+	for i, s := range dynamics.Stages {
+		stageRows[i] = []string{
+			strconv.Itoa(s.Stage),
+			formatValue(s.TargetFullness),
+			strconv.Itoa(s.Blocks),
+			formatValue(s.MeanFullness),
+			formatValue(s.StartPrice),
+			formatValue(s.EndPrice),
+			formatPercent(s.MeanRise),
+			formatPercent(s.MaxRise),
+			formatPercent(s.MeanFall),
+			formatPercent(s.MaxFall),
+		}
+	}
+	writeTable(&b, []string{"Stage", "Target fullness", "Blocks", "Mean fullness", "Start price", "End price", "Mean rise", "Max rise", "Mean fall", "Max fall"}, stageRows)
+	b.WriteString("\n![gas_price](charts/gas_price.svg)\n\n![fullness](charts/fullness.svg)\n")
+
+	b.WriteString("\n## Strategies\n\n")
+	b.WriteString("Priced out txs were rejected at CheckTx for a fee under the base fee, dropped ones were not included in time. Both are sent again by their actor.\n")
+	strategyRows := make([][]string, len(dynamics.Strategies))
+	for i, o := range dynamics.Strategies {
+		stage := strconv.Itoa(o.Stage)
+		if o.Stage == -1 {
+			stage = "all"
+		}
+		strategyRows[i] = []string{
+			o.Strategy,
+			stage,
+			strconv.Itoa(o.Sent),
+			strconv.Itoa(o.Included),
+			strconv.Itoa(o.PricedOut),
+			strconv.Itoa(o.Dropped),
+			strconv.Itoa(o.Failed),
+			formatPercent(o.PricedOutRate()),
+			formatValue(o.MeanInclusionBlocks),
+		}
+	}
+	writeTable(&b, []string{"Strategy", "Stage", "Sent", "Included", "Priced out", "Dropped", "Failed", "Priced out rate", "Mean inclusion blocks"}, strategyRows)
+	return b.String()
+}
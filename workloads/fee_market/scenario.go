@@ -0,0 +1,198 @@
+package fee_market
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	cosmosmath "cosmossdk.io/math"
+	"github.com/allora-network/allora-simulator/client"
+	"github.com/allora-network/allora-simulator/lib"
+	"github.com/allora-network/allora-simulator/types"
+	"github.com/allora-network/allora-simulator/workloads/common"
+	sdktypes "github.com/cosmos/cosmos-sdk/types"
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	"github.com/rs/zerolog/log"
+)
+
+const defaultResultsDir = "results"
+
+// Start runs the stages of the scenario, recording the fee market after every block, and writes the results
+// and the fee dynamics report to a new run directory. It returns the path of the report.
+func Start(config *types.Config, state *State) (string, error) {
+	ctx := context.Background()
+	rpc, err := client.GetClient(config.Nodes.RPC[0])
+	if err != nil {
+		return "", fmt.Errorf("failed to create rpc client: %w", err)
+	}
+	maxBlockUtilization, err := lib.GetFeeMarketMaxBlockUtilization(config)
+	if err != nil {
+		return "", fmt.Errorf("failed to get the max block utilization: %w", err)
+	}
+	if maxBlockUtilization == 0 {
+		return "", fmt.Errorf("the fee market has no max block utilization")
+	}
+	txGas, err := common.EstimateMsgsGas(config, state.loadMsg(state.actors[0], config.Denom))
+	if err != nil {
+		return "", fmt.Errorf("failed to estimate the gas of the load: %w", err)
+	}
+
+	baseDir := config.FeeMarket.OutputDir
+	if baseDir == "" {
+		baseDir = defaultResultsDir
+	}
+	runDir := filepath.Join(baseDir, "fee_market_"+time.Now().UTC().Format("20060102_150405"))
+	if err := os.MkdirAll(runDir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create results directory: %w", err)
+	}
+
+	rec := &recorder{}
+	inclusionBlocks := getInclusionBlocks(config.FeeMarket)
+	var wg sync.WaitGroup
+	height, err := rpc.LatestBlockHeight(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get the latest block height: %w", err)
+	}
+	for i, stage := range getStages(config.FeeMarket) {
+		txsPerBlock := int(math.Round(stage.Fullness * float64(maxBlockUtilization) / float64(txGas)))
+		log.Info().Msgf("Stage %d: %d blocks at fullness %v, %d txs per block", i, stage.Blocks, stage.Fullness, txsPerBlock)
+		for blocks := 0; blocks < stage.Blocks; {
+			if err := rpc.WaitForBlockHeight(ctx, height+1); err != nil {
+				return "", fmt.Errorf("failed to wait for block %d: %w", height+1, err)
+			}
+			latest, err := rpc.LatestBlockHeight(ctx)
+			if err != nil {
+				return "", fmt.Errorf("failed to get the latest block height: %w", err)
+			}
+			// Blocks may have passed while waiting, every one of them is sampled
+			for height < latest {
+				height++
+				blocks++
+				state.recordBlock(ctx, rpc, rec, i, stage.Fullness, height, maxBlockUtilization)
+			}
+
+			actors := state.takeIdleActors(txsPerBlock)
+			if len(actors) < txsPerBlock {
+				log.Warn().Msgf("Only %d of %d actors are idle at block %d, add actors to reach fullness %v", len(actors), txsPerBlock, height, stage.Fullness)
+			}
+			for _, actor := range actors {
+				wg.Add(1)
+				go func(actor *loadActor) {
+					defer wg.Done()
+					defer actor.busy.Store(false)
+					rec.recordTx(state.send(ctx, config, rpc, actor, i, height, inclusionBlocks))
+				}(actor)
+			}
+		}
+	}
+	log.Info().Msg("Waiting for the last txs of the load")
+	wg.Wait()
+
+	blocks, txs := rec.results()
+	if err := writeResults(runDir, blocks, txs); err != nil {
+		return "", err
+	}
+	return writeReport(runDir, maxBlockUtilization, txGas, blocks, AnalyzeFeeDynamics(blocks, txs))
+}
+
+// Sample the gas of a block and the gas price after it. The gas price of the actors is refreshed along.
+func (s *State) recordBlock(ctx context.Context, rpc *client.Client, rec *recorder, stage int, targetFullness float64, height int64, maxBlockUtilization uint64) {
+	gas, err := rpc.BlockGas(ctx, height)
+	if err != nil {
+		log.Error().Err(err).Msgf("Failed to get the gas of block %d", height)
+		return
+	}
+	gasPrice, err := s.gasPrice.Refresh()
+	if err != nil {
+		log.Error().Err(err).Msgf("Failed to get the gas price after block %d", height)
+		return
+	}
+	fullness := float64(gas.Used) / float64(maxBlockUtilization)
+	log.Debug().Msgf("Block %d: gas price %v, gas used %d, fullness %.2f", height, gasPrice, gas.Used, fullness)
+	rec.recordBlock(BlockSample{
+		Height:         height,
+		Stage:          stage,
+		TargetFullness: targetFullness,
+		GasPrice:       gasPrice,
+		GasUsed:        gas.Used,
+		GasWanted:      gas.Wanted,
+		Txs:            gas.Txs,
+		Fullness:       fullness,
+	})
+}
+
+// Bank send of 1 denom back to the faucet, the load of an actor
+func (s *State) loadMsg(actor *loadActor, denom string) sdktypes.Msg {
+	return &banktypes.MsgSend{
+		FromAddress: actor.actor.Addr,
+		ToAddress:   s.faucet.Addr,
+		Amount:      sdktypes.NewCoins(sdktypes.NewCoin(denom, cosmosmath.OneInt())),
+	}
+}
+
+// Send one tx of an actor and wait up to inclusionBlocks blocks for it to be included
+func (s *State) send(ctx context.Context, config *types.Config, rpc *client.Client, actor *loadActor, stage int, height int64, inclusionBlocks int64) TxOutcome {
+	txParams := actor.actor.TxParams
+	outcome := TxOutcome{Strategy: actor.strategy, Stage: stage, Height: height, Attempt: actor.attempt}
+
+	resp, err := common.BroadcastOnce(txParams, txParams.Sequence, actor.attempt, s.loadMsg(actor, config.Denom))
+	if err != nil {
+		if resp != nil && isInsufficientFee(resp.Codespace, resp.Code) {
+			actor.attempt++
+			outcome.Outcome = OutcomePricedOut
+			return outcome
+		}
+		log.Error().Err(err).Msgf("Load tx of %s failed", actor.actor.Addr)
+		if strings.Contains(err.Error(), "account sequence mismatch") {
+			refreshSequence(actor)
+		}
+		outcome.Outcome = OutcomeFailed
+		return outcome
+	}
+
+	hash := resp.Hash
+	for blocks := int64(0); blocks <= inclusionBlocks; blocks++ {
+		tx, err := rpc.Client.Tx(ctx, hash, false)
+		if err == nil {
+			txParams.Sequence++
+			if tx.TxResult.Code != 0 {
+				log.Error().Msgf("Load tx of %s failed: %s", actor.actor.Addr, tx.TxResult.Log)
+				outcome.Outcome = OutcomeFailed
+				return outcome
+			}
+			actor.attempt = 0
+			outcome.Outcome = OutcomeIncluded
+			outcome.Blocks = tx.Height - height
+			return outcome
+		}
+		if err := rpc.WaitForNextBlock(ctx); err != nil {
+			log.Error().Err(err).Msg("Failed to wait for the next block")
+			break
+		}
+	}
+	// Whether it was evicted or still waits, the chain tells which sequence comes next
+	actor.attempt++
+	refreshSequence(actor)
+	outcome.Outcome = OutcomeDropped
+	return outcome
+}
+
+func isInsufficientFee(codespace string, code uint32) bool {
+	return codespace == sdkerrors.ErrInsufficientFee.Codespace() && code == sdkerrors.ErrInsufficientFee.ABCICode()
+}
+
+func refreshSequence(actor *loadActor) {
+	txParams := actor.actor.TxParams
+	sequence, _, err := lib.GetAccountInfo(actor.actor.Addr, txParams.Config)
+	if err != nil {
+		log.Error().Err(err).Msgf("Failed to get the sequence of %s", actor.actor.Addr)
+		return
+	}
+	txParams.Sequence = sequence
+}
@@ -0,0 +1,138 @@
+package fee_market
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync/atomic"
+
+	"github.com/allora-network/allora-simulator/lib"
+	"github.com/allora-network/allora-simulator/types"
+	"github.com/allora-network/allora-simulator/workloads/common"
+)
+
+// Blocks a tx may wait in the mempool when the config does not say
+const defaultInclusionBlocks = 5
+
+// Stages run when the config has none: ramp up past full blocks, then let the base fee fall back
+var defaultStages = []types.FeeMarketStage{
+	{Fullness: 0, Blocks: 10},
+	{Fullness: 0.25, Blocks: 10},
+	{Fullness: 0.5, Blocks: 10},
+	{Fullness: 0.75, Blocks: 10},
+	{Fullness: 1, Blocks: 10},
+	{Fullness: 1.25, Blocks: 10},
+	{Fullness: 0, Blocks: 30},
+}
+
+// ValidateFeeMarketConfig checks the stages and strategies of the fee market scenario
+func ValidateFeeMarketConfig(config types.FeeMarketConfig) error {
+	if config.NumActors <= 0 {
+		return fmt.Errorf("num_actors must be positive, got %d", config.NumActors)
+	}
+	if len(config.Strategies) > config.NumActors {
+		return fmt.Errorf("every one of the %d strategies needs an actor, got %d actors", len(config.Strategies), config.NumActors)
+	}
+	for name, strategy := range config.Strategies {
+		if err := common.ValidateFeeConfig(strategy); err != nil {
+			return fmt.Errorf("invalid strategy %s: %w", name, err)
+		}
+	}
+	for i, stage := range config.Stages {
+		if stage.Fullness < 0 {
+			return fmt.Errorf("stage %d: fullness must not be negative, got %v", i, stage.Fullness)
+		}
+		if stage.Blocks <= 0 {
+			return fmt.Errorf("stage %d: blocks must be positive, got %d", i, stage.Blocks)
+		}
+	}
+	if config.InclusionBlocks < 0 {
+		return fmt.Errorf("inclusion_blocks must not be negative, got %d", config.InclusionBlocks)
+	}
+	return nil
+}
+
+func getStages(config types.FeeMarketConfig) []types.FeeMarketStage {
+	if len(config.Stages) == 0 {
+		return defaultStages
+	}
+	return config.Stages
+}
+
+func getInclusionBlocks(config types.FeeMarketConfig) int64 {
+	if config.InclusionBlocks == 0 {
+		return defaultInclusionBlocks
+	}
+	return config.InclusionBlocks
+}
+
+// Names of the strategies, sorted. Without strategies the fees config is the only one.
+func getStrategyNames(config *types.Config) []string {
+	if len(config.FeeMarket.Strategies) == 0 {
+		if config.Fees.Strategy == "" {
+			return []string{common.FeeStrategyBaseFee}
+		}
+		return []string{config.Fees.Strategy}
+	}
+	names := make([]string, 0, len(config.FeeMarket.Strategies))
+	for name := range config.FeeMarket.Strategies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// loadActor sends the load of one strategy, one tx at a time
+type loadActor struct {
+	actor    *types.Actor
+	strategy string
+	// Priced out attempts of the tx being sent, it is sent again until it gets in
+	attempt int64
+	busy    atomic.Bool
+}
+
+type State struct {
+	faucet *types.Actor
+	// Refreshed after every block
+	gasPrice *lib.GasPriceOracle
+	actors   []*loadActor
+	// Index of the next actor to look at for load, round robin
+	next int
+}
+
+// CreateAndFundActors funds the load actors and gives each its strategy, round robin over the strategies
+func CreateAndFundActors(config *types.Config, gasPrice *lib.GasPriceOracle, faucetMnemonic []byte, rand io.Reader) (*State, error) {
+	faucet, actorsList, _ := common.CreateAndFundActors(config, gasPrice, faucetMnemonic, config.FeeMarket.NumActors, rand)
+	names := getStrategyNames(config)
+
+	state := &State{faucet: faucet, gasPrice: gasPrice, actors: make([]*loadActor, len(actorsList))}
+	for i, actor := range actorsList {
+		name := names[i%len(names)]
+		if strategy, ok := config.FeeMarket.Strategies[name]; ok {
+			strategyConfig := *config
+			strategyConfig.Fees = strategy
+			fees, err := common.NewFeeStrategy(&strategyConfig, gasPrice)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create strategy %s: %w", name, err)
+			}
+			actor.TxParams.Fees = fees
+		}
+		state.actors[i] = &loadActor{actor: actor, strategy: name}
+	}
+	return state, nil
+}
+
+// Take up to n idle actors, marked busy, round robin
+func (s *State) takeIdleActors(n int) []*loadActor {
+	taken := make([]*loadActor, 0, n)
+	for i := 0; i < len(s.actors) && len(taken) < n; i++ {
+		actor := s.actors[(s.next+i)%len(s.actors)]
+		if actor.busy.CompareAndSwap(false, true) {
+			taken = append(taken, actor)
+		}
+	}
+	if len(s.actors) > 0 {
+		s.next = (s.next + len(taken)) % len(s.actors)
+	}
+	return taken
+}
@@ -3,6 +3,8 @@ package research
 import (
 	"encoding/hex"
 	"fmt"
+	"math/rand"
+	"strconv"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -15,51 +17,92 @@ import (
 	"github.com/allora-network/allora-simulator/types"
 )
 
+// StartActorLoops runs the submission loops of the topics until they all ran config.Research.Epochs
+// epochs, forever without an epoch limit, or until an error or the timeout. Every routine it started,
+// payload sends included, is stopped and waited for before it returns.
 func StartActorLoops(
 	data *ResearchSimulationData,
 	config *types.Config,
+	writer *ResultsWriter,
 	topicIds []uint64,
 ) error {
 	log.Info().Msgf("Starting submission loop for %d topics", len(topicIds))
 
-	totalRoutines := len(topicIds)*2 + 1 // 2 routines per topic (worker + reputer) + 1 for gas routine
+	// Only the routines of the topics and the on-chain recorder end on their own
+	totalRoutines := len(topicIds)*2 + 1 // 2 routines per topic (worker + reputer) + 1 for on-chain recorder
 	errChan := make(chan error, totalRoutines)
+	stop := make(chan struct{})
 
 	var wg sync.WaitGroup
 	wg.Add(totalRoutines)
 
+	// Every routine, so that none writes results or sends from the actors once the loops returned
+	var routines sync.WaitGroup
+	defer func() {
+		close(stop)
+		routines.Wait()
+	}()
+	goRoutine := func(f func()) {
+		routines.Add(1)
+		go func() {
+			defer routines.Done()
+			f()
+		}()
+	}
+
 	// Run gas routine
-	go func() {
+	goRoutine(func() { data.GasPrice.RunUntil(stop) })
+
+	// Run on-chain recorder
+	goRoutine(func() {
 		defer wg.Done()
-		common.RunGasRoutine(config)
-	}()
+		if err := runOnChainRecorder(data, config, writer, topicIds, stop); err != nil {
+			select {
+			case errChan <- fmt.Errorf("on-chain recorder failed: %w", err):
+			default:
+				log.Error().Msgf("Error channel full - on-chain recorder error: %v", err)
+			}
+		}
+	})
+
+	// Run churn routine
+	if config.Churn.Enabled {
+		goRoutine(func() { runChurnLoop(data, config, writer, topicIds, stop) })
+	}
+
+	// Run staking routine
+	if config.Staking.Enabled {
+		goRoutine(func() {
+			common.RunStakingLoop(config, data.GetEpochLength, data.Delegators, topicIds, data.GetReputersForTopic, stop)
+		})
+	}
 
 	for _, topicId := range topicIds {
 		log.Info().Msgf("Starting submission loop for topic: %d", topicId)
 
 		// Start worker routine
-		go func(tid uint64) {
+		goRoutine(func() {
 			defer wg.Done()
-			if err := runWorkersProcess(data, config, tid); err != nil {
+			if err := runWorkersProcess(data, config, writer, topicId, stop, &routines); err != nil {
 				select {
-				case errChan <- fmt.Errorf("worker routine failed for topic %d: %w", tid, err):
+				case errChan <- fmt.Errorf("worker routine failed for topic %d: %w", topicId, err):
 				default:
-					log.Error().Msgf("Error channel full - worker error for topic %d: %v", tid, err)
+					log.Error().Msgf("Error channel full - worker error for topic %d: %v", topicId, err)
 				}
 			}
-		}(topicId)
+		})
 
 		// Start reputer routine
-		go func(tid uint64) {
+		goRoutine(func() {
 			defer wg.Done()
-			if err := runReputersProcess(data, config, tid); err != nil {
+			if err := runReputersProcess(data, config, writer, topicId, stop, &routines); err != nil {
 				select {
-				case errChan <- fmt.Errorf("reputer routine failed for topic %d: %w", tid, err):
+				case errChan <- fmt.Errorf("reputer routine failed for topic %d: %w", topicId, err):
 				default:
-					log.Error().Msgf("Error channel full - reputer error for topic %d: %v", tid, err)
+					log.Error().Msgf("Error channel full - reputer error for topic %d: %v", topicId, err)
 				}
 			}
-		}(topicId)
+		})
 	}
 
 	// Create a channel that closes when all goroutines are done
@@ -87,27 +130,36 @@ func StartActorLoops(
 	}
 }
 
-// Will check for nonce opened every 4s and if opened, will produce inferences and forecasts
+// Will check for nonce opened every 4s and if opened, will produce inferences and forecasts.
+// Returns after config.Research.Epochs epochs when set.
 func runWorkersProcess(
 	data *ResearchSimulationData,
 	config *types.Config,
+	writer *ResultsWriter,
 	topicId uint64,
+	stop <-chan struct{},
+	sends *sync.WaitGroup,
 ) error {
 	numberOfActiveEpochs := int64(0)
 	latestNonceHeightActedUpon := int64(0)
-	groundTruthState := &types.GroundTruthState{
-		CumulativeReturn: 0,
-		CurrentPrice:     config.Research.InitialPrice,
-		LastReturn:       0,
+	topicConfig := data.GetTopicConfig(topicId)
+	if topicConfig == nil {
+		return fmt.Errorf("no research config for topic: %d", topicId)
 	}
+	// The arms of a topic read the same ground truth
+	groundTruth, err := data.GetGroundTruthStream(topicId)
+	if err != nil {
+		return err
+	}
+	groundTruthState := groundTruth.At(numberOfActiveEpochs)
 	// Generate cold start epoch data
 	inferers := data.GetInferersForTopic(topicId)
 	if len(inferers) > 0 {
-		data.GenerateInfererSimulatedValuesForNextEpoch(&config.Research, topicId, numberOfActiveEpochs, groundTruthState)
+		data.GenerateInfererSimulatedValuesForNextEpoch(topicConfig, topicId, numberOfActiveEpochs, groundTruthState)
 	}
 	forecasters := data.GetForecastersForTopic(topicId)
 	if len(forecasters) > 0 {
-		data.GenerateForecasterSimulatedValuesForNextEpoch(&config.Research, topicId, numberOfActiveEpochs, groundTruthState)
+		data.GenerateForecasterSimulatedValuesForNextEpoch(topicConfig, topicId, numberOfActiveEpochs, groundTruthState)
 	}
 
 	for {
@@ -118,50 +170,65 @@ func runWorkersProcess(
 			log.Info().Msgf("Inferer nonce opened for topic: %d at height: %d", topicId, latestOpenInfererNonce)
 			latestNonceHeightActedUpon = latestOpenInfererNonce
 
-			// Get all inferers for the topic
-			inferers := data.GetInferersForTopic(topicId)
+			// Reputers will score this epoch against the ground truth workers predicted
+			data.RecordEpoch(topicId, latestOpenInfererNonce, &EpochRecord{
+				Epoch:       numberOfActiveEpochs,
+				GroundTruth: groundTruthState,
+			})
+			// A failed write loses the results of the epoch, not its submissions
+			if err := writeWorkerResults(data, writer, topicId, latestOpenInfererNonce); err != nil {
+				log.Error().Err(err).Msgf("Error writing worker results of topic %d at height %d", topicId, latestOpenInfererNonce)
+			}
+
+			// Get the inferers of the topic submitting this epoch
+			inferers := withValues(data.GetInferersForTopic(topicId), data.GetInfererSimulatedValues(topicId))
 
 			log.Info().Msgf("Building and committing inferer payload for topic: %d", topicId)
-			wasError := createAndSendInfererPayloads(data, topicId, inferers, latestOpenInfererNonce)
+			wasError := createAndSendInfererPayloads(data, topicId, inferers, latestOpenInfererNonce, sends)
 			if wasError {
 				log.Error().Msgf("Error building and committing inferer payload for topic: %d", topicId)
 			}
 
-			// Get all forecasters for the topic
-			forecasters := data.GetForecastersForTopic(topicId)
+			// Get the forecasters of the topic submitting this epoch
+			forecasters := withValues(data.GetForecastersForTopic(topicId), data.GetForecasterSimulatedValues(topicId))
 
 			log.Info().Msgf("Building and committing forecaster payload for topic: %d", topicId)
-			wasError = createAndSendForecasterPayloads(data, topicId, forecasters, latestOpenInfererNonce)
+			wasError = createAndSendForecasterPayloads(data, topicId, forecasters, latestOpenInfererNonce, sends)
 			if wasError {
 				log.Error().Msgf("Error building and committing forecaster payload for topic: %d", topicId)
 			}
 
 			// Update ground truth state
-			groundTruthState = GetNextGroundTruth(groundTruthState, config.Research.InitialPrice, config.Research.Drift, config.Research.Volatility)
+			groundTruthState = groundTruth.At(numberOfActiveEpochs + 1)
 
 			log.Info().Msgf("Successfully built and committed inferer payload for topic: %d for %v inferers", topicId, len(inferers))
 			numberOfActiveEpochs++
+			if lastEpochReached(config, numberOfActiveEpochs-1) {
+				log.Info().Msgf("Workers of topic %d submitted their last epoch", topicId)
+				return nil
+			}
 
 			// Generate inferer and forecaster values for the next epoch
-			data.GenerateInfererSimulatedValuesForNextEpoch(&config.Research, topicId, numberOfActiveEpochs, groundTruthState)
-			data.GenerateForecasterSimulatedValuesForNextEpoch(&config.Research, topicId, numberOfActiveEpochs, groundTruthState)
+			data.GenerateInfererSimulatedValuesForNextEpoch(topicConfig, topicId, numberOfActiveEpochs, groundTruthState)
+			data.GenerateForecasterSimulatedValuesForNextEpoch(topicConfig, topicId, numberOfActiveEpochs, groundTruthState)
+		}
+		if !common.Sleep(stop, 4*time.Second) {
+			return nil
 		}
-		time.Sleep(4 * time.Second)
 	}
 }
 
-// Will check for nonce opened every 4s and if opened, will produce reputation
+// Will check for nonce opened every 4s and if opened, will produce reputation.
+// Returns after the last of config.Research.Epochs epochs when set.
 func runReputersProcess(
 	data *ResearchSimulationData,
 	config *types.Config,
+	writer *ResultsWriter,
 	topicId uint64,
+	stop <-chan struct{},
+	sends *sync.WaitGroup,
 ) error {
 	latestNonceHeightActedUpon := int64(0)
-	groundTruthState := &types.GroundTruthState{
-		CumulativeReturn: 0,
-		CurrentPrice:     config.Research.InitialPrice,
-		LastReturn:       0,
-	}
 	for {
 		latestOpenReputerNonce, err := lib.GetOldestReputerNonceByTopicId(config, topicId)
 		if err != nil {
@@ -171,38 +238,55 @@ func runReputersProcess(
 				log.Info().Msgf("Reputer nonce opened for topic: %d at height: %d", topicId, latestOpenReputerNonce)
 				latestNonceHeightActedUpon = latestOpenReputerNonce
 
-				// Get all reputers for the topic
-				reputers := data.GetReputersForTopic(topicId)
-
-				log.Info().Msgf("Building and committing reputer payload for topic: %d", topicId)
-				wasError := createAndSendReputerPayloads(config, topicId, reputers, latestOpenReputerNonce, groundTruthState)
-				if wasError {
-					log.Error().Msgf("Error building and committing reputer payload for topic: %d", topicId)
+				// Score the epoch against the ground truth its workers predicted
+				epoch, ok := data.GetEpoch(topicId, latestOpenReputerNonce)
+				if !ok {
+					log.Error().Msgf("No ground truth recorded for topic: %d at height: %d, skipping reputer payload", topicId, latestOpenReputerNonce)
+				} else {
+					// Get all reputers for the topic
+					reputers := data.GetReputersForTopic(topicId)
+
+					log.Info().Msgf("Building and committing reputer payload for topic: %d", topicId)
+					wasError := createAndSendReputerPayloads(data, config, writer, topicId, reputers, latestOpenReputerNonce, epoch, sends)
+					if wasError {
+						log.Error().Msgf("Error building and committing reputer payload for topic: %d", topicId)
+					}
+
+					log.Info().Msgf("Successfully built and committed reputer payload for topic: %d for %v reputers", topicId, len(reputers))
+					if lastEpochReached(config, epoch.Epoch) {
+						log.Info().Msgf("Reputers of topic %d reported their last epoch", topicId)
+						return nil
+					}
 				}
-
-				// Update ground truth state
-				groundTruthState = GetNextGroundTruth(groundTruthState, config.Research.InitialPrice, config.Research.Drift, config.Research.Volatility)
-
-				log.Info().Msgf("Successfully built and committed reputer payload for topic: %d for %v reputers", topicId, len(reputers))
 			}
 		}
-		time.Sleep(4 * time.Second)
+		if !common.Sleep(stop, 4*time.Second) {
+			return nil
+		}
 	}
 }
 
+// Whether an epoch, counted from 0, is the last one of the run
+func lastEpochReached(config *types.Config, epoch int64) bool {
+	return config.Research.Epochs > 0 && epoch >= config.Research.Epochs-1
+}
+
 // Create and send inferer payloads
 func createAndSendInfererPayloads(
 	data *ResearchSimulationData,
 	topicId uint64,
 	inferers []*types.Actor,
 	infererNonce int64,
+	sends *sync.WaitGroup,
 ) bool {
 	completed := atomic.Int32{}
 
 	log.Info().Msgf("Starting inferer payload creation for %d inferers in topic: %d", len(inferers), topicId)
 
 	for _, inferer := range inferers {
+		sends.Add(1)
 		go func(inferer *types.Actor) {
+			defer sends.Done()
 			defer func() {
 				count := completed.Add(1)
 				if int(count)%1000 == 0 || count == int32(len(inferers)) {
@@ -218,14 +302,13 @@ func createAndSendInfererPayloads(
 				log.Error().Msgf("Error creating inferer data bundle: %v", err.Error())
 				return
 			}
-			_, updatedSeq, err := common.SendDataWithRetry(inferer.TxParams, true, &emissionstypes.InsertWorkerPayloadRequest{
+			_, err = common.SendFromActor(inferer, true, &emissionstypes.InsertWorkerPayloadRequest{
 				Sender:           inferer.Addr,
 				WorkerDataBundle: infererData,
 			})
 			if err != nil {
 				log.Error().Msgf("Error sending inferer payload: %v", err.Error())
 			}
-			inferer.TxParams.Sequence = updatedSeq
 		}(inferer)
 	}
 
@@ -283,18 +366,51 @@ func createInfererDataBundle(
 
 // Create and send reputer payloads
 func createAndSendReputerPayloads(
+	data *ResearchSimulationData,
 	config *types.Config,
+	writer *ResultsWriter,
 	topicId uint64,
 	reputers []*types.Actor,
 	reputerNonce int64,
-	groundTruthState *types.GroundTruthState,
+	epoch *EpochRecord,
+	sends *sync.WaitGroup,
 ) bool {
 	completed := atomic.Int32{}
 
 	log.Info().Msgf("Starting reputer payload creation for %d reputers in topic: %d", len(reputers), topicId)
 
+	// All reputers score the same network inferences
+	networkInferences, err := lib.GetNetworkInferencesAtBlock(config, topicId, reputerNonce)
+	if err != nil {
+		log.Error().Msgf("Error getting network inferences for topic: %d at height: %d: %v", topicId, reputerNonce, err)
+		return true
+	}
+	key := getEpochKey(data, topicId, reputerNonce)
+	if err := writer.WriteNetworkInferences(key, networkInferences); err != nil {
+		log.Error().Msgf("Error writing network inferences: %v", err)
+	}
+	if combined, err := strconv.ParseFloat(networkInferences.CombinedValue.String(), 64); err == nil {
+		data.SetLatestCombinedValue(topicId, combined)
+	}
+
+	// Intermittent reputers sit out some epochs, each reputer draws its noise for the epoch once
+	adversaries := data.GetTopicConfig(topicId).Adversaries
+	submitting := make([]*types.Actor, 0, len(reputers))
+	noises := make(map[string]*rand.Rand, len(reputers))
+	for _, reputer := range reputers {
+		params := data.GetResearchParams(topicId, reputer.Addr)
+		noise := data.actorNoise(topicId, epoch.Epoch, ActorTypeReputer, params)
+		if submitsThisEpoch(noise, adversaries, params) {
+			submitting = append(submitting, reputer)
+			noises[reputer.Addr] = noise
+		}
+	}
+	reputers = submitting
+
 	for _, reputer := range reputers {
-		go func(reputer *types.Actor) {
+		sends.Add(1)
+		go func(reputer *types.Actor, noise *rand.Rand) {
+			defer sends.Done()
 			defer func() {
 				count := completed.Add(1)
 				if int(count)%1000 == 0 || count == int32(len(reputers)) {
@@ -306,21 +422,23 @@ func createAndSendReputerPayloads(
 				}
 			}()
 
-			valueBundle, err := createReputerValueBundle(config, topicId, reputer, reputerNonce, groundTruthState)
+			valueBundle, err := createReputerValueBundle(data, noise, networkInferences, topicId, reputer, reputerNonce, epoch.GroundTruth)
 			if err != nil {
 				log.Error().Msgf("Error creating reputer value bundle: %v", err.Error())
 				return
 			}
+			if err := writer.WriteLosses(key, reputer.Addr, valueBundle.ValueBundle); err != nil {
+				log.Error().Msgf("Error writing reputer losses: %v", err)
+			}
 
-			_, updatedSeq, err := common.SendDataWithRetry(reputer.TxParams, true, &emissionstypes.InsertReputerPayloadRequest{
+			_, err = common.SendFromActor(reputer, true, &emissionstypes.InsertReputerPayloadRequest{
 				Sender:             reputer.Addr,
 				ReputerValueBundle: valueBundle,
 			})
 			if err != nil {
 				log.Error().Msgf("Error sending reputer payload: %v", err.Error())
 			}
-			reputer.TxParams.Sequence = updatedSeq
-		}(reputer)
+		}(reputer, noises[reputer.Addr])
 	}
 
 	return false
@@ -328,25 +446,29 @@ func createAndSendReputerPayloads(
 
 // Generate the same valueBundle for a reputer
 func createReputerValueBundle(
-	config *types.Config,
+	data *ResearchSimulationData,
+	noise *rand.Rand,
+	networkInferences *emissionstypes.ValueBundle,
 	topicId uint64,
 	reputer *types.Actor,
 	reputerNonce int64,
 	groundTruthState *types.GroundTruthState,
 ) (*emissionstypes.InputReputerValueBundle, error) {
 
-	// Get Network Inferences
-	networkInferences, err := lib.GetNetworkInferencesAtBlock(config, topicId, reputerNonce)
+	lossFn, err := GetLossFunction(data.GetTopicConfig(topicId).Topic.LossMethod, PreviousPrice(groundTruthState))
 	if err != nil {
 		return nil, err
 	}
+	params := data.GetResearchParams(topicId, reputer.Addr)
 
 	// Get Reputer Losses
 	lossBundle, err := GetReputerOutput(
+		noise,
+		lossFn,
 		groundTruthState.CurrentPrice,
 		networkInferences,
-		reputer.ResearchParams.Error,
-		reputer.ResearchParams.Bias,
+		params.Error,
+		params.Bias,
 	)
 	if err != nil {
 		return nil, err
@@ -387,13 +509,16 @@ func createAndSendForecasterPayloads(
 	topicId uint64,
 	forecasters []*types.Actor,
 	forecasterNonce int64,
+	sends *sync.WaitGroup,
 ) bool {
 	completed := atomic.Int32{}
 
 	log.Info().Msgf("Starting forecaster payload creation for %d forecasters in topic: %d", len(forecasters), topicId)
 
 	for _, forecaster := range forecasters {
+		sends.Add(1)
 		go func(forecaster *types.Actor) {
+			defer sends.Done()
 			defer func() {
 				count := completed.Add(1)
 				if int(count)%1000 == 0 || count == int32(len(forecasters)) {
@@ -410,14 +535,13 @@ func createAndSendForecasterPayloads(
 				return
 			}
 
-			_, updatedSeq, err := common.SendDataWithRetry(forecaster.TxParams, true, &emissionstypes.InsertWorkerPayloadRequest{
+			_, err = common.SendFromActor(forecaster, true, &emissionstypes.InsertWorkerPayloadRequest{
 				Sender:           forecaster.Addr,
 				WorkerDataBundle: workerData,
 			})
 			if err != nil {
 				log.Error().Msgf("Error sending forecaster payload: %v", err.Error())
 			}
-			forecaster.TxParams.Sequence = updatedSeq
 		}(forecaster)
 	}
 
@@ -473,3 +597,16 @@ func createForecasterDataBundle(
 
 	return workerDataBundle, nil
 }
+
+// Write the ground truth and the worker values of the epoch opened at a worker nonce
+func writeWorkerResults(data *ResearchSimulationData, writer *ResultsWriter, topicId uint64, nonce int64) error {
+	key := getEpochKey(data, topicId, nonce)
+	epoch, _ := data.GetEpoch(topicId, nonce)
+	if err := writer.WriteGroundTruth(key, epoch.GroundTruth); err != nil {
+		return err
+	}
+	if err := writer.WriteInferences(key, data.GetInfererSimulatedValues(topicId), data.GetInfererOutperformer(topicId)); err != nil {
+		return err
+	}
+	return writer.WriteForecasts(key, data.GetForecasterSimulatedValues(topicId))
+}
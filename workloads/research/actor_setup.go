@@ -1,7 +1,9 @@
 package research
 
 import (
+	"fmt"
 	"io"
+	"math/rand"
 	"sync"
 	"sync/atomic"
 
@@ -10,14 +12,14 @@ import (
 	cosmosmath "cosmossdk.io/math"
 	alloramath "github.com/allora-network/allora-chain/math"
 	emissionstypes "github.com/allora-network/allora-chain/x/emissions/types"
+	"github.com/allora-network/allora-simulator/lib"
 	"github.com/allora-network/allora-simulator/types"
 	"github.com/allora-network/allora-simulator/workloads/common"
 )
 
-const stakeToAdd uint64 = 9e4
-
 func CreateAndFundActors(
 	config *types.Config,
+	gasPrice *lib.GasPriceOracle,
 	faucetMnemonic []byte,
 	numActors int,
 	epochLength int64,
@@ -26,12 +28,23 @@ func CreateAndFundActors(
 	faucet *types.Actor,
 	simulationData *ResearchSimulationData,
 ) {
-	faucet, actorsList, _ := common.CreateAndFundActors(config, faucetMnemonic, numActors, rand)
+	faucet, actorsList, _ := common.CreateAndFundActors(config, gasPrice, faucetMnemonic, numActors, rand)
+
+	data := NewResearchSimulationData(faucet, epochLength, actorsList)
+	data.GasPrice = gasPrice
+	return faucet, data
+}
 
-	data := ResearchSimulationData{
+// NewResearchSimulationData returns empty simulation data for the given actors
+func NewResearchSimulationData(
+	faucet *types.Actor,
+	epochLength int64,
+	actors []*types.Actor,
+) *ResearchSimulationData {
+	return &ResearchSimulationData{
 		Faucet:                       faucet,
-		EpochLength:                  int64(epochLength),
-		Actors:                       actorsList,
+		EpochLength:                  epochLength,
+		Actors:                       actors,
 		RegisteredInferersByTopic:    map[uint64][]*types.Actor{},
 		RegisteredForecastersByTopic: map[uint64][]*types.Actor{},
 		RegisteredReputersByTopic:    map[uint64][]*types.Actor{},
@@ -41,9 +54,71 @@ func CreateAndFundActors(
 		InfererOutperformers:         make(map[uint64]string),
 		ForecasterSimulatedValues:    make(map[uint64]map[string][]*emissionstypes.InputForecastElement),
 		ForecasterOutperformers:      make(map[uint64]string),
+		TopicConfigs:                 make(map[uint64]*types.ResearchConfig),
+		ResearchParams:               make(map[uint64]map[string]*types.ResearchParams),
+		Epochs:                       make(map[uint64]map[int64]*EpochRecord),
+		TargetInferers:               make(map[uint64]string),
+		LatestCombinedValues:         make(map[uint64]float64),
+		GroundTruths:                 make(map[uint64]*GroundTruthStream),
+		Seed:                         rand.Int63(),
+		NoiseTopics:                  make(map[uint64]uint64),
+	}
+}
+
+// TopicActors are the actors playing each role in a research topic
+type TopicActors struct {
+	Inferers    []*types.Actor
+	Forecasters []*types.Actor
+	Reputers    []*types.Actor
+}
+
+// Number of actors per role needed to populate all topics.
+// Shared actors join every topic so only the largest topic counts, otherwise every topic has its own actors.
+func getRolePoolSizes(topics []ResearchTopic, shared bool) (inferers, forecasters, reputers int) {
+	for _, topic := range topics {
+		if shared {
+			inferers = max(inferers, topic.Inferers)
+			forecasters = max(forecasters, topic.Forecasters)
+			reputers = max(reputers, topic.Reputers)
+		} else {
+			inferers += topic.Inferers
+			forecasters += topic.Forecasters
+			reputers += topic.Reputers
+		}
 	}
+	return inferers, forecasters, reputers
+}
 
-	return faucet, &data
+// GetNumActors returns how many actors must be created and funded for the given topics
+func GetNumActors(topics []ResearchTopic, shared bool) int {
+	inferers, forecasters, reputers := getRolePoolSizes(topics, shared)
+	return inferers + forecasters + reputers
+}
+
+// AssignTopicActors divides actors into the roles of every topic
+func AssignTopicActors(actors []*types.Actor, topics []ResearchTopic, shared bool) []TopicActors {
+	numInferers, numForecasters, _ := getRolePoolSizes(topics, shared)
+	infererPool := actors[:numInferers]
+	forecasterPool := actors[numInferers : numInferers+numForecasters]
+	reputerPool := actors[numInferers+numForecasters:]
+
+	assigned := make([]TopicActors, len(topics))
+	infererIdx, forecasterIdx, reputerIdx := 0, 0, 0
+	for i, topic := range topics {
+		if shared {
+			// Every topic uses the first actors of each role pool
+			infererIdx, forecasterIdx, reputerIdx = 0, 0, 0
+		}
+		assigned[i] = TopicActors{
+			Inferers:    infererPool[infererIdx : infererIdx+topic.Inferers],
+			Forecasters: forecasterPool[forecasterIdx : forecasterIdx+topic.Forecasters],
+			Reputers:    reputerPool[reputerIdx : reputerIdx+topic.Reputers],
+		}
+		infererIdx += topic.Inferers
+		forecasterIdx += topic.Forecasters
+		reputerIdx += topic.Reputers
+	}
+	return assigned
 }
 
 // RegisterWorkers registers numWorkers as workers in topicId
@@ -58,6 +133,11 @@ func RegisterWorkers(
 	sem := make(chan struct{}, maxConcurrent)
 	completed := atomic.Int32{}
 
+	topicConfig := data.GetTopicConfig(topicId)
+	if topicConfig == nil {
+		return fmt.Errorf("no research config for topic: %d", topicId)
+	}
+
 	var wg sync.WaitGroup
 	log.Info().Msgf("Starting registration of %d workers in topic: %d", numWorkers, topicId)
 
@@ -96,12 +176,16 @@ func RegisterWorkers(
 			}
 			worker.TxParams.Sequence = updatedSeq
 
-			// Set the research params
-			worker.ResearchParams = InitializeWorkerResearchParams(worker.TxParams.Config.Research.Volatility)
-
+			// Set the research params, drawn independently for every topic the worker joins
+			params := InitializeWorkerResearchParams(topicConfig.Volatility)
 			if inferers {
+				data.SetResearchParams(topicId, worker.Addr, applyArchetype(params, topicConfig.Adversaries, ActorTypeInferer, idx))
+				if idx == topicConfig.Adversaries.TargetInferer {
+					data.SetTargetInferer(topicId, worker.Addr)
+				}
 				data.AddInfererRegistration(topicId, worker)
 			} else {
+				data.SetResearchParams(topicId, worker.Addr, applyArchetype(params, topicConfig.Adversaries, ActorTypeForecaster, idx))
 				data.AddForecasterRegistration(topicId, worker)
 			}
 		}(worker, i)
@@ -112,17 +196,32 @@ func RegisterWorkers(
 	return nil
 }
 
-// RegisterReputersAndStake registers numReputers as reputers in topicId and stakes them
+// DrawReputerStakes draws the initial stake of every reputer from its staking config
+func DrawReputerStakes(reputers []*types.Actor) []cosmosmath.Int {
+	stakes := make([]cosmosmath.Int, len(reputers))
+	for i, reputer := range reputers {
+		stakes[i] = common.DrawStake(reputer.TxParams.Config.Staking.Stake)
+	}
+	return stakes
+}
+
+// RegisterReputersAndStake registers numReputers as reputers in topicId and stakes them, each with its stake in stakes
 func RegisterReputersAndStake(
 	actors []*types.Actor,
 	topicId uint64,
 	data *ResearchSimulationData,
 	numReputers int,
+	stakes []cosmosmath.Int,
 ) error {
 	maxConcurrent := 1000
 	sem := make(chan struct{}, maxConcurrent)
 	completed := atomic.Int32{}
 
+	topicConfig := data.GetTopicConfig(topicId)
+	if topicConfig == nil {
+		return fmt.Errorf("no research config for topic: %d", topicId)
+	}
+
 	var wg sync.WaitGroup
 	log.Info().Msgf("Starting registration of %d reputers in topic: %d", numReputers, topicId)
 
@@ -156,7 +255,7 @@ func RegisterReputersAndStake(
 			stakeRequest := &emissionstypes.AddStakeRequest{
 				Sender:  reputer.Addr,
 				TopicId: topicId,
-				Amount:  cosmosmath.NewIntFromUint64(stakeToAdd),
+				Amount:  stakes[idx],
 			}
 
 			_, updatedSeq, err := common.SendDataWithRetry(reputer.TxParams, true, registerRequest, stakeRequest)
@@ -167,7 +266,7 @@ func RegisterReputersAndStake(
 			reputer.TxParams.Sequence = updatedSeq
 
 			// Set the research params
-			reputer.ResearchParams = InitializeReputerResearchParams()
+			data.SetResearchParams(topicId, reputer.Addr, applyArchetype(InitializeReputerResearchParams(), topicConfig.Adversaries, ActorTypeReputer, idx))
 
 			data.AddReputerRegistration(topicId, reputer)
 		}(reputer, i)
@@ -177,3 +276,29 @@ func RegisterReputersAndStake(
 
 	return nil
 }
+
+// Give the actors of an arm the research params, the per-epoch noise and the shill target of the actors at the
+// same index in the first arm of their topic. Actors whose registration failed in either arm keep what they have.
+func cloneArmParams(data *ResearchSimulationData, fromTopicId uint64, from TopicActors, toTopicId uint64, to TopicActors) {
+	clone := func(from, to []*types.Actor) {
+		for i := 0; i < len(from) && i < len(to); i++ {
+			params := data.GetResearchParams(fromTopicId, from[i].Addr)
+			if params == nil || data.GetResearchParams(toTopicId, to[i].Addr) == nil {
+				continue
+			}
+			cloned := *params
+			data.SetResearchParams(toTopicId, to[i].Addr, &cloned)
+		}
+	}
+	clone(from.Inferers, to.Inferers)
+	clone(from.Forecasters, to.Forecasters)
+	clone(from.Reputers, to.Reputers)
+
+	data.ShareNoise(fromTopicId, toTopicId)
+	target := data.GetTargetInferer(fromTopicId)
+	for i := 0; i < len(from.Inferers) && i < len(to.Inferers); i++ {
+		if from.Inferers[i].Addr == target {
+			data.SetTargetInferer(toTopicId, to.Inferers[i].Addr)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package research
+
+import (
+	"testing"
+
+	"github.com/allora-network/allora-simulator/types"
+)
+
+func TestAssignTopicActors(t *testing.T) {
+	topics := []ResearchTopic{
+		{Name: "A", Inferers: 3, Forecasters: 1, Reputers: 2},
+		{Name: "B", Inferers: 2, Forecasters: 2, Reputers: 1},
+	}
+
+	newActors := func(n int) []*types.Actor {
+		actors := make([]*types.Actor, n)
+		for i := range actors {
+			actors[i] = &types.Actor{Name: types.GetActorName(i)}
+		}
+		return actors
+	}
+
+	t.Run("Separate populations", func(t *testing.T) {
+		numActors := GetNumActors(topics, false)
+		if numActors != 11 {
+			t.Fatalf("GetNumActors = %d, want 11", numActors)
+		}
+		actors := newActors(numActors)
+		assigned := AssignTopicActors(actors, topics, false)
+
+		// Inferers come first, then forecasters, then reputers
+		if assigned[1].Inferers[0] != actors[3] || assigned[1].Forecasters[0] != actors[6] || assigned[1].Reputers[0] != actors[10] {
+			t.Errorf("second topic does not start after the first topic in each role pool")
+		}
+
+		seen := map[*types.Actor]bool{}
+		for _, topicActors := range assigned {
+			for _, role := range [][]*types.Actor{topicActors.Inferers, topicActors.Forecasters, topicActors.Reputers} {
+				for _, actor := range role {
+					if seen[actor] {
+						t.Errorf("actor %s assigned twice", actor)
+					}
+					seen[actor] = true
+				}
+			}
+		}
+	})
+
+	t.Run("Shared populations", func(t *testing.T) {
+		numActors := GetNumActors(topics, true)
+		if numActors != 7 {
+			t.Fatalf("GetNumActors = %d, want 7", numActors)
+		}
+		actors := newActors(numActors)
+		assigned := AssignTopicActors(actors, topics, true)
+
+		if len(assigned[0].Inferers) != 3 || len(assigned[1].Inferers) != 2 {
+			t.Fatalf("unexpected number of inferers per topic")
+		}
+		// Both topics share the first actors of each role pool
+		if assigned[0].Inferers[0] != assigned[1].Inferers[0] || assigned[0].Forecasters[0] != assigned[1].Forecasters[0] || assigned[0].Reputers[0] != assigned[1].Reputers[0] {
+			t.Errorf("topics do not share their actors")
+		}
+	})
+}
@@ -0,0 +1,115 @@
+package research
+
+import (
+	"fmt"
+
+	"github.com/allora-network/allora-simulator/lib"
+	"github.com/allora-network/allora-simulator/types"
+	"github.com/rs/zerolog/log"
+)
+
+// ActorTracker polls the chain for the EMA scores, regrets and stakes of every simulated actor of a topic
+type ActorTracker struct {
+	data   *ResearchSimulationData
+	config *types.Config
+	// One-in and one-out regrets need a query per pair of actors
+	pairwiseRegrets bool
+}
+
+func NewActorTracker(data *ResearchSimulationData, config *types.Config) *ActorTracker {
+	return &ActorTracker{
+		data:            data,
+		config:          config,
+		pairwiseRegrets: config.Research.TrackPairwiseRegrets,
+	}
+}
+
+// Poll queries the current values of all actors of a topic. Failed queries are logged and skipped.
+func (t *ActorTracker) Poll(topicId uint64) []OnChainValue {
+	inferers := t.data.GetInferersForTopic(topicId)
+	forecasters := t.data.GetForecastersForTopic(topicId)
+	reputers := t.data.GetReputersForTopic(topicId)
+
+	values := make([]OnChainValue, 0)
+	add := func(actorType, metric, actor, otherActor string, value fmt.Stringer, err error) {
+		if err != nil {
+			log.Error().Msgf("Error querying %s of %s %s in topic %d: %v", metric, actorType, actor, topicId, err)
+			return
+		}
+		values = append(values, OnChainValue{
+			ActorType:  actorType,
+			Metric:     metric,
+			Actor:      actor,
+			OtherActor: otherActor,
+			Value:      value.String(),
+		})
+	}
+
+	for _, inferer := range inferers {
+		score, err := lib.GetInfererScoreEma(t.config, topicId, inferer.Addr)
+		add(ActorTypeInferer, MetricEmaScore, inferer.Addr, "", score.Score, err)
+		regret, err := lib.GetInfererNetworkRegret(t.config, topicId, inferer.Addr)
+		add(ActorTypeInferer, MetricRegret, inferer.Addr, "", regret.Value, err)
+		regret, err = lib.GetNaiveInfererNetworkRegret(t.config, topicId, inferer.Addr)
+		add(ActorTypeInferer, MetricNaiveRegret, inferer.Addr, "", regret.Value, err)
+	}
+
+	for _, forecaster := range forecasters {
+		score, err := lib.GetForecasterScoreEma(t.config, topicId, forecaster.Addr)
+		add(ActorTypeForecaster, MetricEmaScore, forecaster.Addr, "", score.Score, err)
+		regret, err := lib.GetForecasterNetworkRegret(t.config, topicId, forecaster.Addr)
+		add(ActorTypeForecaster, MetricRegret, forecaster.Addr, "", regret.Value, err)
+	}
+
+	for _, reputer := range reputers {
+		score, err := lib.GetReputerScoreEma(t.config, topicId, reputer.Addr)
+		add(ActorTypeReputer, MetricEmaScore, reputer.Addr, "", score.Score, err)
+		stake, err := lib.GetReputerStakeInTopic(t.config, topicId, reputer.Addr)
+		add(ActorTypeReputer, MetricStake, reputer.Addr, "", stake, err)
+		stake, err = lib.GetReputerSelfStakeInTopic(t.config, topicId, reputer.Addr)
+		add(ActorTypeReputer, MetricSelfStake, reputer.Addr, "", stake, err)
+		stake, err = lib.GetReputerDelegatedStakeInTopic(t.config, topicId, reputer.Addr)
+		add(ActorTypeReputer, MetricDelegatedStake, reputer.Addr, "", stake, err)
+	}
+
+	if !t.pairwiseRegrets {
+		return values
+	}
+
+	for _, forecaster := range forecasters {
+		for _, inferer := range inferers {
+			regret, err := lib.GetOneInForecasterNetworkRegret(t.config, topicId, forecaster.Addr, inferer.Addr)
+			add(ActorTypeForecaster, MetricOneInForecasterRegret, forecaster.Addr, inferer.Addr, regret.Value, err)
+		}
+	}
+
+	// The other actor is the one withheld from the network inference
+	for _, oneOut := range inferers {
+		for _, inferer := range inferers {
+			if inferer.Addr == oneOut.Addr {
+				continue
+			}
+			regret, err := lib.GetOneOutInfererInfererNetworkRegret(t.config, topicId, oneOut.Addr, inferer.Addr)
+			add(ActorTypeInferer, MetricOneOutInfererRegret, inferer.Addr, oneOut.Addr, regret.Value, err)
+		}
+		for _, forecaster := range forecasters {
+			regret, err := lib.GetOneOutInfererForecasterNetworkRegret(t.config, topicId, oneOut.Addr, forecaster.Addr)
+			add(ActorTypeForecaster, MetricOneOutInfererRegret, forecaster.Addr, oneOut.Addr, regret.Value, err)
+		}
+	}
+	for _, oneOut := range forecasters {
+		for _, inferer := range inferers {
+			regret, err := lib.GetOneOutForecasterInfererNetworkRegret(t.config, topicId, oneOut.Addr, inferer.Addr)
+			add(ActorTypeInferer, MetricOneOutForecasterRegret, inferer.Addr, oneOut.Addr, regret.Value, err)
+		}
+		for _, forecaster := range forecasters {
+			if forecaster.Addr == oneOut.Addr {
+				continue
+			}
+			regret, err := lib.GetOneOutForecasterForecasterNetworkRegret(t.config, topicId, oneOut.Addr, forecaster.Addr)
+			add(ActorTypeForecaster, MetricOneOutForecasterRegret, forecaster.Addr, oneOut.Addr, regret.Value, err)
+		}
+	}
+
+	return values
+}
@@ -0,0 +1,175 @@
+package research
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"strconv"
+
+	alloramath "github.com/allora-network/allora-chain/math"
+	emissionstypes "github.com/allora-network/allora-chain/x/emissions/types"
+	"github.com/allora-network/allora-simulator/types"
+)
+
+// Archetypes of research actors, as written in the actors file
+const (
+	ArchetypeHonest        = "honest"
+	ArchetypeCopycat       = "copycat"
+	ArchetypeSybil         = "sybil"
+	ArchetypeOverReporter  = "over_reporter"
+	ArchetypeUnderReporter = "under_reporter"
+	ArchetypeShill         = "shill"
+	ArchetypeIntermittent  = "intermittent"
+)
+
+const (
+	defaultReportingBias     = 0.5
+	defaultSubmitProbability = 0.5
+	// Loss shills forecast for their target, relative to the lowest loss they forecast for another inferer
+	shillLossFactor = 0.1
+)
+
+// GetArchetype returns the archetype of the actor at index idx of the role population of a topic.
+// cluster is the sybil cluster of the actor, only meaningful for sybils.
+func GetArchetype(adversaries types.AdversaryConfig, actorType string, idx int) (archetype string, cluster int) {
+	switch actorType {
+	case ActorTypeInferer:
+		if idx < adversaries.Copycats {
+			return ArchetypeCopycat, 0
+		}
+		idx -= adversaries.Copycats
+		sybils := adversaries.SybilClusters * adversaries.SybilClusterSize
+		if idx < sybils {
+			return ArchetypeSybil, idx / adversaries.SybilClusterSize
+		}
+		idx -= sybils
+		if idx < adversaries.IntermittentInferers {
+			return ArchetypeIntermittent, 0
+		}
+	case ActorTypeForecaster:
+		if idx < adversaries.Shills {
+			return ArchetypeShill, 0
+		}
+		idx -= adversaries.Shills
+		if idx < adversaries.IntermittentForecasters {
+			return ArchetypeIntermittent, 0
+		}
+	case ActorTypeReputer:
+		if idx < adversaries.OverReporters {
+			return ArchetypeOverReporter, 0
+		}
+		idx -= adversaries.OverReporters
+		if idx < adversaries.UnderReporters {
+			return ArchetypeUnderReporter, 0
+		}
+		idx -= adversaries.UnderReporters
+		if idx < adversaries.IntermittentReputers {
+			return ArchetypeIntermittent, 0
+		}
+	}
+	return ArchetypeHonest, 0
+}
+
+// ValidateAdversaries checks the archetypes fit in the role populations of a topic
+func ValidateAdversaries(adversaries types.AdversaryConfig, topic ResearchTopic) error {
+	counts := []int{
+		adversaries.Copycats, adversaries.SybilClusters, adversaries.SybilClusterSize,
+		adversaries.OverReporters, adversaries.UnderReporters, adversaries.Shills, adversaries.TargetInferer,
+		adversaries.IntermittentInferers, adversaries.IntermittentForecasters, adversaries.IntermittentReputers,
+	}
+	for _, count := range counts {
+		if count < 0 {
+			return fmt.Errorf("adversary counts must not be negative")
+		}
+	}
+	if adversaries.SybilClusters > 0 && adversaries.SybilClusterSize < 2 {
+		return fmt.Errorf("sybil_cluster_size must be at least 2, got %d", adversaries.SybilClusterSize)
+	}
+	if adversaries.ReportingBias < 0 {
+		return fmt.Errorf("reporting_bias must not be negative, got %f", adversaries.ReportingBias)
+	}
+	if adversaries.SubmitProbability < 0 || adversaries.SubmitProbability > 1 {
+		return fmt.Errorf("submit_probability must be in [0, 1], got %f", adversaries.SubmitProbability)
+	}
+
+	inferers := adversaries.Copycats + adversaries.SybilClusters*adversaries.SybilClusterSize + adversaries.IntermittentInferers
+	if inferers > topic.Inferers {
+		return fmt.Errorf("%d adversarial inferers configured but topic %s has %d inferers", inferers, topic.Name, topic.Inferers)
+	}
+	forecasters := adversaries.Shills + adversaries.IntermittentForecasters
+	if forecasters > topic.Forecasters {
+		return fmt.Errorf("%d adversarial forecasters configured but topic %s has %d forecasters", forecasters, topic.Name, topic.Forecasters)
+	}
+	reputers := adversaries.OverReporters + adversaries.UnderReporters + adversaries.IntermittentReputers
+	if reputers > topic.Reputers {
+		return fmt.Errorf("%d adversarial reputers configured but topic %s has %d reputers", reputers, topic.Name, topic.Reputers)
+	}
+	if adversaries.Shills > 0 && adversaries.TargetInferer >= topic.Inferers {
+		return fmt.Errorf("target_inferer %d is out of the %d inferers of topic %s", adversaries.TargetInferer, topic.Inferers, topic.Name)
+	}
+	return nil
+}
+
+// Tag freshly drawn research params with the archetype of the actor at index idx of its role population
+func applyArchetype(params *types.ResearchParams, adversaries types.AdversaryConfig, actorType string, idx int) *types.ResearchParams {
+	params.Archetype, params.SybilCluster = GetArchetype(adversaries, actorType, idx)
+	params.Index = idx
+
+	reportingBias := adversaries.ReportingBias
+	if reportingBias == 0 {
+		reportingBias = defaultReportingBias
+	}
+	// Misreporting reputers shift every loss on top of their own noise
+	switch params.Archetype {
+	case ArchetypeOverReporter:
+		params.Bias += reportingBias
+	case ArchetypeUnderReporter:
+		params.Bias -= reportingBias
+	}
+	return params
+}
+
+// Whether an actor submits this epoch, only intermittent actors sit out epochs
+func submitsThisEpoch(noise *rand.Rand, adversaries types.AdversaryConfig, params *types.ResearchParams) bool {
+	if params == nil || params.Archetype != ArchetypeIntermittent {
+		return true
+	}
+	probability := adversaries.SubmitProbability
+	if probability == 0 {
+		probability = defaultSubmitProbability
+	}
+	return noise.Float64() < probability
+}
+
+// Rewrite a forecast so that the target inferer has the lowest forecasted loss
+func favourTarget(elements []*emissionstypes.InputForecastElement, target string) []*emissionstypes.InputForecastElement {
+	var targetElement *emissionstypes.InputForecastElement
+	lowest := math.Inf(1)
+	for _, element := range elements {
+		if element.Inferer == target {
+			targetElement = element
+			continue
+		}
+		loss, err := strconv.ParseFloat(element.Value.String(), 64)
+		if err != nil {
+			continue
+		}
+		lowest = math.Min(lowest, loss)
+	}
+	if targetElement == nil || math.IsInf(lowest, 1) {
+		return elements
+	}
+	targetElement.Value = alloramath.MustNewCappedBoundedExp40DecFromString(formatFloat(lowest * shillLossFactor))
+	return elements
+}
+
+// Actors that have a value this epoch, intermittent actors sitting out have none
+func withValues[V any](actors []*types.Actor, values map[string]V) []*types.Actor {
+	submitting := make([]*types.Actor, 0, len(actors))
+	for _, actor := range actors {
+		if _, ok := values[actor.Addr]; ok {
+			submitting = append(submitting, actor)
+		}
+	}
+	return submitting
+}
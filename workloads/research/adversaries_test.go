@@ -0,0 +1,138 @@
+package research
+
+import (
+	"strconv"
+	"testing"
+
+	alloramath "github.com/allora-network/allora-chain/math"
+	emissionstypes "github.com/allora-network/allora-chain/x/emissions/types"
+	"github.com/allora-network/allora-simulator/types"
+)
+
+func TestGetArchetype(t *testing.T) {
+	adversaries := types.AdversaryConfig{
+		Copycats:                1,
+		SybilClusters:           2,
+		SybilClusterSize:        2,
+		IntermittentInferers:    1,
+		Shills:                  1,
+		IntermittentForecasters: 1,
+		OverReporters:           1,
+		UnderReporters:          1,
+	}
+	tests := []struct {
+		actorType string
+		idx       int
+		archetype string
+		cluster   int
+	}{
+		{ActorTypeInferer, 0, ArchetypeCopycat, 0},
+		{ActorTypeInferer, 1, ArchetypeSybil, 0},
+		{ActorTypeInferer, 2, ArchetypeSybil, 0},
+		{ActorTypeInferer, 3, ArchetypeSybil, 1},
+		{ActorTypeInferer, 4, ArchetypeSybil, 1},
+		{ActorTypeInferer, 5, ArchetypeIntermittent, 0},
+		{ActorTypeInferer, 6, ArchetypeHonest, 0},
+		{ActorTypeForecaster, 0, ArchetypeShill, 0},
+		{ActorTypeForecaster, 1, ArchetypeIntermittent, 0},
+		{ActorTypeForecaster, 2, ArchetypeHonest, 0},
+		{ActorTypeReputer, 0, ArchetypeOverReporter, 0},
+		{ActorTypeReputer, 1, ArchetypeUnderReporter, 0},
+		{ActorTypeReputer, 2, ArchetypeHonest, 0},
+	}
+	for _, tt := range tests {
+		archetype, cluster := GetArchetype(adversaries, tt.actorType, tt.idx)
+		if archetype != tt.archetype || (archetype == ArchetypeSybil && cluster != tt.cluster) {
+			t.Errorf("GetArchetype(%s, %d) = %s/%d, want %s/%d", tt.actorType, tt.idx, archetype, cluster, tt.archetype, tt.cluster)
+		}
+	}
+
+	topic := ResearchTopic{Name: "test", Inferers: 5, Forecasters: 2, Reputers: 2}
+	if err := ValidateAdversaries(adversaries, topic); err == nil {
+		t.Errorf("ValidateAdversaries expected an error for 6 adversarial inferers in a population of 5")
+	}
+	topic.Inferers = 6
+	if err := ValidateAdversaries(adversaries, topic); err != nil {
+		t.Errorf("ValidateAdversaries unexpected error: %v", err)
+	}
+}
+
+func TestFavourTarget(t *testing.T) {
+	elements := []*emissionstypes.InputForecastElement{
+		{Inferer: "a", Value: alloramath.MustNewCappedBoundedExp40DecFromString("2")},
+		{Inferer: "target", Value: alloramath.MustNewCappedBoundedExp40DecFromString("5")},
+		{Inferer: "b", Value: alloramath.MustNewCappedBoundedExp40DecFromString("0.5")},
+	}
+	favourTarget(elements, "target")
+	if got := elements[1].Value.String(); got != "0.05" {
+		t.Errorf("target forecasted loss = %s, want 0.05", got)
+	}
+}
+
+func TestOfflineAdversaries(t *testing.T) {
+	config := &types.Config{
+		InferersPerTopic:    5,
+		ForecastersPerTopic: 2,
+		ReputersPerTopic:    3,
+		Research: types.ResearchConfig{
+			InitialPrice:         100,
+			Volatility:           0.05,
+			BaseExperienceFactor: 0.1,
+			ExperienceGrowth:     0.05,
+			Topic:                types.TopicConfig{LossMethod: LossMethodMSE, EpochLength: 12},
+			Adversaries: types.AdversaryConfig{
+				Copycats:             1,
+				SybilClusters:        1,
+				SybilClusterSize:     2,
+				Shills:               1,
+				TargetInferer:        0,
+				OverReporters:        1,
+				IntermittentReputers: 1,
+				SubmitProbability:    1,
+			},
+		},
+	}
+	topics := GetResearchTopics(config)
+	actors := newOfflineActors(GetNumActors(topics, false))
+	data := NewResearchSimulationData(nil, 12, actors)
+	topicActors := AssignTopicActors(actors, topics, false)
+	data.AddTopic(1, topics[0].Config)
+	registerOfflineActors(data, 1, topics[0].Config, topicActors[0])
+
+	inferers := topicActors[0].Inferers
+	copycat, sybilA, sybilB := inferers[0].Addr, inferers[1].Addr, inferers[2].Addr
+	if got := data.GetTargetInferer(1); got != copycat {
+		t.Errorf("target inferer = %s, want %s", got, copycat)
+	}
+
+	data.SetLatestCombinedValue(1, 123.5)
+	gts := &types.GroundTruthState{CurrentPrice: 100}
+	data.GenerateInfererSimulatedValuesForNextEpoch(topics[0].Config, 1, 0, gts)
+	values := data.GetInfererSimulatedValues(1)
+	if got := values[copycat].String(); got != "123.5" {
+		t.Errorf("copycat value = %s, want the latest combined value 123.5", got)
+	}
+	if values[sybilA].String() != values[sybilB].String() {
+		t.Errorf("sybils submitted %s and %s, want one shared value", values[sybilA], values[sybilB])
+	}
+	if len(values) != 5 {
+		t.Errorf("got %d inferences, want 5 with a submit probability of 1", len(values))
+	}
+
+	data.GenerateForecasterSimulatedValuesForNextEpoch(topics[0].Config, 1, 0, gts)
+	shill := data.GetForecasterSimulatedValue(1, topicActors[0].Forecasters[0].Addr)
+	forecasted := make(map[string]float64)
+	for _, element := range shill {
+		forecasted[element.Inferer], _ = strconv.ParseFloat(element.Value.String(), 64)
+	}
+	for inferer, loss := range forecasted {
+		if inferer != copycat && loss < forecasted[copycat] {
+			t.Errorf("shill forecasted %f for %s, below the %f of its target", loss, inferer, forecasted[copycat])
+		}
+	}
+
+	over := data.GetResearchParams(1, topicActors[0].Reputers[0].Addr)
+	if over.Archetype != ArchetypeOverReporter || over.Bias < defaultReportingBias-0.5 {
+		t.Errorf("over reporter params %+v, want its bias shifted by %f", over, defaultReportingBias)
+	}
+}
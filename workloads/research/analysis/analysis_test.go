@@ -0,0 +1,104 @@
+package analysis
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/allora-network/allora-simulator/types"
+	"github.com/allora-network/allora-simulator/workloads/research"
+)
+
+func TestSpearman(t *testing.T) {
+	tests := []struct {
+		name     string
+		x, y     []float64
+		expected float64
+	}{
+		{name: "monotonic", x: []float64{1, 2, 3, 4}, y: []float64{10, 100, 1000, 10000}, expected: 1},
+		{name: "reversed", x: []float64{1, 2, 3, 4}, y: []float64{4, 3, 2, 1}, expected: -1},
+		{name: "ties", x: []float64{1, 2, 2, 3}, y: []float64{1, 2, 3, 4}, expected: 0.9486832980505138},
+		{name: "unknown pairs skipped", x: []float64{1, math.NaN(), 2, 3}, y: []float64{1, 5, 2, 3}, expected: 1},
+		{name: "too few pairs", x: []float64{1, 2, math.NaN()}, y: []float64{1, 2, 3}, expected: math.NaN()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _ := spearman(tt.x, tt.y)
+			if math.IsNaN(tt.expected) {
+				if !math.IsNaN(got) {
+					t.Errorf("spearman = %v, want NaN", got)
+				}
+				return
+			}
+			if math.Abs(got-tt.expected) > 1e-9 {
+				t.Errorf("spearman = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestAnalyzeOfflineRun(t *testing.T) {
+	outputDir := t.TempDir()
+	config := &types.Config{
+		InferersPerTopic:    4,
+		ForecastersPerTopic: 2,
+		ReputersPerTopic:    2,
+		Research: types.ResearchConfig{
+			InitialPrice:         100,
+			Volatility:           0.05,
+			BaseExperienceFactor: 0.1,
+			ExperienceGrowth:     0.05,
+			Topic:                types.TopicConfig{LossMethod: research.LossMethodMSE, EpochLength: 12},
+			Offline: types.OfflineConfig{
+				Epochs:    10,
+				Weighting: research.OfflineWeightingInverseLoss,
+				OutputDir: outputDir,
+			},
+		},
+	}
+	if err := research.RunOfflineSimulation(config); err != nil {
+		t.Fatalf("RunOfflineSimulation unexpected error: %v", err)
+	}
+	runDirs, _ := filepath.Glob(filepath.Join(outputDir, "offline_*"))
+	if len(runDirs) != 1 {
+		t.Fatalf("found run directories %v, want exactly one", runDirs)
+	}
+
+	run, err := LoadRun(runDirs[0])
+	if err != nil {
+		t.Fatalf("LoadRun unexpected error: %v", err)
+	}
+	analysis, err := Analyze(run)
+	if err != nil {
+		t.Fatalf("Analyze unexpected error: %v", err)
+	}
+
+	if len(analysis.Actors) != 4+2+2 {
+		t.Errorf("got %d actor summaries, want %d", len(analysis.Actors), 4+2+2)
+	}
+	for _, actor := range analysis.Actors {
+		if actor.Epochs == 0 || math.IsNaN(actor.RealisedLoss) {
+			t.Errorf("%s %s has no realised loss", actor.ActorType, actor.Actor)
+		}
+	}
+	if len(analysis.Networks) != 1 || analysis.Networks[0].Epochs != 10 {
+		t.Errorf("unexpected network summaries %+v", analysis.Networks)
+	}
+
+	for _, format := range []string{FormatMarkdown, FormatHTML} {
+		path, err := WriteReport(analysis, runDirs[0], format)
+		if err != nil {
+			t.Fatalf("WriteReport %s unexpected error: %v", format, err)
+		}
+		if info, err := os.Stat(path); err != nil || info.Size() == 0 {
+			t.Errorf("report %s is missing or empty", path)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(runDirs[0], "charts", "network_topic_1.svg")); err != nil {
+		t.Errorf("missing network chart: %v", err)
+	}
+	if _, err := WriteReport(analysis, runDirs[0], "pdf"); err == nil {
+		t.Errorf("WriteReport with an unknown format expected an error")
+	}
+}
@@ -0,0 +1,431 @@
+package analysis
+
+import (
+	"math"
+	"sort"
+
+	"github.com/allora-network/allora-simulator/workloads/research"
+)
+
+// ActorSummary compares the hidden skill of an actor to its realised loss and on-chain outcomes
+type ActorSummary struct {
+	TopicId            uint64
+	Actor              string
+	ActorType          string
+	Archetype          string
+	Error              float64
+	Bias               float64
+	ContextSensitivity float64
+	// Share of the epochs the inferer was the outperformer
+	OutperformShare float64
+	Epochs          int
+	// Mean loss of the submitted values against the ground truth.
+	// For reputers, mean absolute log10 error of the reported combined loss.
+	RealisedLoss float64
+	MeanScore    float64
+	TotalReward  float64
+	LastEmaScore float64
+	// Total stake of reputers, including delegated stake, averaged over the epochs and at the last epoch
+	MeanStake float64
+	LastStake float64
+}
+
+// SkillCorrelation is the rank correlation between the skill of the actors of a role and their on-chain outcomes
+type SkillCorrelation struct {
+	TopicId   uint64
+	ActorType string
+	Actors    int
+	// Skill measured as minus the realised loss
+	LossVsScore  float64
+	LossVsReward float64
+	// Skill measured as minus the hidden error param
+	ErrorVsScore  float64
+	ErrorVsReward float64
+}
+
+// ArchetypeSummary averages the outcomes of the actors of an archetype, to compare adversaries to honest actors
+type ArchetypeSummary struct {
+	TopicId      uint64
+	ActorType    string
+	Archetype    string
+	Actors       int
+	Epochs       float64
+	RealisedLoss float64
+	MeanScore    float64
+	// Mean total reward per actor
+	Reward float64
+	// Reward relative to the honest actors of the same role, NaN without honest actors
+	RewardVsHonest float64
+}
+
+// StakeSummary measures how concentrated the stake of the reputers of a topic is, and how it relates to their outcomes
+type StakeSummary struct {
+	TopicId  uint64
+	Reputers int
+	// Stake of all reputers at the last epoch
+	TotalStake float64
+	// Herfindahl-Hirschman index of the last stakes, 1/Reputers when equal and 1 when a single reputer holds all stake
+	HHI float64
+	// Share of the total stake held by the largest reputer
+	TopShare float64
+	// Spearman rank correlation of the mean stake with the mean score and total reward
+	StakeVsScore  float64
+	StakeVsReward float64
+}
+
+// NetworkSummary compares the combined network inference to the naive one
+type NetworkSummary struct {
+	TopicId      uint64
+	Epochs       int
+	CombinedRMSE float64
+	NaiveRMSE    float64
+	// Relative RMSE reduction of the combined value over the naive value
+	Improvement float64
+}
+
+// EpochPoint is the ground truth and network values of an epoch, for charts
+type EpochPoint struct {
+	Epoch       int64
+	GroundTruth float64
+	Combined    float64
+	Naive       float64
+}
+
+// Analysis of a research run
+type Analysis struct {
+	Run          *Run
+	Actors       []*ActorSummary
+	Correlations []SkillCorrelation
+	Archetypes   []ArchetypeSummary
+	Stakes       []StakeSummary
+	Networks     []NetworkSummary
+	EpochSeries  map[uint64][]EpochPoint
+}
+
+// Analyze computes the actor, correlation and network summaries of a run
+func Analyze(run *Run) (*Analysis, error) {
+	analysis := &Analysis{
+		Run:         run,
+		EpochSeries: make(map[uint64][]EpochPoint),
+	}
+
+	for _, topic := range run.Topics {
+		if _, err := research.GetLossFunction(topic.LossMethod, 0); err != nil {
+			return nil, err
+		}
+		previous := run.PreviousGroundTruth[topic.Id]
+		lossAt := func(epoch int64) research.LossFunction {
+			lossFn, _ := research.GetLossFunction(topic.LossMethod, previous[epoch])
+			return lossFn
+		}
+
+		actors := analyzeActors(run, topic.Id, lossAt)
+		analysis.Actors = append(analysis.Actors, actors...)
+		for _, actorType := range []string{research.ActorTypeInferer, research.ActorTypeForecaster, research.ActorTypeReputer} {
+			analysis.Correlations = append(analysis.Correlations, correlateSkill(topic.Id, actorType, actors))
+			analysis.Archetypes = append(analysis.Archetypes, summarizeArchetypes(topic.Id, actorType, actors)...)
+		}
+		analysis.Stakes = append(analysis.Stakes, summarizeStake(topic.Id, actors))
+
+		network, series := analyzeNetwork(run, topic.Id)
+		analysis.Networks = append(analysis.Networks, network)
+		analysis.EpochSeries[topic.Id] = series
+	}
+
+	return analysis, nil
+}
+
+// lossAt is the loss function of the topic in an epoch
+func analyzeActors(run *Run, topicId uint64, lossAt func(epoch int64) research.LossFunction) []*ActorSummary {
+	groundTruth := run.GroundTruth[topicId]
+	summaries := make(map[string]*ActorSummary)
+	ordered := make([]*ActorSummary, 0)
+	for _, actor := range run.Actors {
+		if actor.TopicId != topicId {
+			continue
+		}
+		summary := &ActorSummary{
+			TopicId:            topicId,
+			Actor:              actor.Addr,
+			ActorType:          actor.ActorType,
+			Archetype:          actor.Archetype,
+			Error:              actor.Error,
+			Bias:               actor.Bias,
+			ContextSensitivity: actor.ContextSensitivity,
+			RealisedLoss:       math.NaN(),
+			MeanScore:          math.NaN(),
+			TotalReward:        math.NaN(),
+			LastEmaScore:       math.NaN(),
+			MeanStake:          math.NaN(),
+			LastStake:          math.NaN(),
+		}
+		summaries[actor.ActorType+"/"+actor.Addr] = summary
+		ordered = append(ordered, summary)
+	}
+	get := func(actorType, actor string) *ActorSummary {
+		return summaries[actorType+"/"+actor]
+	}
+
+	// Inferers: loss of their value against the ground truth
+	inferenceLosses := make(map[string][]float64)
+	outperforms := make(map[string]int)
+	trueLosses := make(map[int64]map[string]float64)
+	for _, inference := range run.Inferences {
+		truth, ok := groundTruth[inference.Epoch]
+		if inference.TopicId != topicId || !ok {
+			continue
+		}
+		loss := research.GetLosses(lossAt(inference.Epoch), truth, inference.Value)
+		inferenceLosses[inference.Inferer] = append(inferenceLosses[inference.Inferer], loss)
+		if inference.Outperform {
+			outperforms[inference.Inferer]++
+		}
+		if trueLosses[inference.Epoch] == nil {
+			trueLosses[inference.Epoch] = make(map[string]float64)
+		}
+		trueLosses[inference.Epoch][inference.Inferer] = loss
+	}
+	for inferer, losses := range inferenceLosses {
+		if summary := get(research.ActorTypeInferer, inferer); summary != nil {
+			summary.Epochs = len(losses)
+			summary.RealisedLoss = mean(losses)
+			summary.OutperformShare = float64(outperforms[inferer]) / float64(len(losses))
+		}
+	}
+
+	// Forecasters: loss of their forecasted losses against the true inferer losses
+	forecastLosses := make(map[string]map[int64][]float64)
+	for _, element := range run.Forecasts {
+		trueLoss, ok := trueLosses[element.Epoch][element.Inferer]
+		if element.TopicId != topicId || !ok {
+			continue
+		}
+		if forecastLosses[element.Forecaster] == nil {
+			forecastLosses[element.Forecaster] = make(map[int64][]float64)
+		}
+		forecastLosses[element.Forecaster][element.Epoch] = append(
+			forecastLosses[element.Forecaster][element.Epoch],
+			research.GetLosses(lossAt(element.Epoch), trueLoss, element.Value),
+		)
+	}
+	for forecaster, epochs := range forecastLosses {
+		if summary := get(research.ActorTypeForecaster, forecaster); summary != nil {
+			epochLosses := make([]float64, 0, len(epochs))
+			for _, losses := range epochs {
+				epochLosses = append(epochLosses, mean(losses))
+			}
+			summary.Epochs = len(epochLosses)
+			summary.RealisedLoss = mean(epochLosses)
+		}
+	}
+
+	// Reputers: log error of the reported combined loss against its true value
+	reputerErrors := make(map[string][]float64)
+	for _, loss := range run.Losses {
+		network, ok := run.NetworkInferences[topicId][loss.Epoch]
+		truth, hasTruth := groundTruth[loss.Epoch]
+		if loss.TopicId != topicId || loss.Kind != research.LossKindCombined || !ok || !hasTruth || loss.Loss <= 0 {
+			continue
+		}
+		trueLoss := research.GetLosses(lossAt(loss.Epoch), truth, network.Combined)
+		reputerErrors[loss.Reputer] = append(reputerErrors[loss.Reputer], math.Abs(math.Log10(loss.Loss)-math.Log10(trueLoss)))
+	}
+	for reputer, errors := range reputerErrors {
+		if summary := get(research.ActorTypeReputer, reputer); summary != nil {
+			summary.Epochs = len(errors)
+			summary.RealisedLoss = mean(errors)
+		}
+	}
+
+	// On-chain outcomes
+	scores := make(map[*ActorSummary][]float64)
+	stakes := make(map[*ActorSummary][]float64)
+	lastEmaEpoch := make(map[*ActorSummary]int64)
+	lastStakeEpoch := make(map[*ActorSummary]int64)
+	for _, value := range run.OnChain {
+		if value.TopicId != topicId {
+			continue
+		}
+		summary := get(value.ActorType, value.Actor)
+		if summary == nil {
+			continue
+		}
+		switch value.Metric {
+		case research.MetricScore:
+			scores[summary] = append(scores[summary], value.Value)
+		case research.MetricReward:
+			if math.IsNaN(summary.TotalReward) {
+				summary.TotalReward = 0
+			}
+			summary.TotalReward += value.Value
+		case research.MetricEmaScore:
+			if last, ok := lastEmaEpoch[summary]; !ok || value.Epoch >= last {
+				lastEmaEpoch[summary] = value.Epoch
+				summary.LastEmaScore = value.Value
+			}
+		case research.MetricStake:
+			stakes[summary] = append(stakes[summary], value.Value)
+			if last, ok := lastStakeEpoch[summary]; !ok || value.Epoch >= last {
+				lastStakeEpoch[summary] = value.Epoch
+				summary.LastStake = value.Value
+			}
+		}
+	}
+	for summary, values := range scores {
+		summary.MeanScore = mean(values)
+	}
+	for summary, values := range stakes {
+		summary.MeanStake = mean(values)
+	}
+
+	return ordered
+}
+
+func correlateSkill(topicId uint64, actorType string, actors []*ActorSummary) SkillCorrelation {
+	var lossSkill, errorSkill, scores, rewards []float64
+	for _, actor := range actors {
+		if actor.ActorType != actorType {
+			continue
+		}
+		lossSkill = append(lossSkill, -actor.RealisedLoss)
+		errorSkill = append(errorSkill, -actor.Error)
+		scores = append(scores, actor.MeanScore)
+		rewards = append(rewards, actor.TotalReward)
+	}
+
+	correlation := SkillCorrelation{TopicId: topicId, ActorType: actorType, Actors: len(lossSkill)}
+	correlation.LossVsScore, _ = spearman(lossSkill, scores)
+	correlation.LossVsReward, _ = spearman(lossSkill, rewards)
+	correlation.ErrorVsScore, _ = spearman(errorSkill, scores)
+	correlation.ErrorVsReward, _ = spearman(errorSkill, rewards)
+	return correlation
+}
+
+// Mean of the known values
+func meanKnown(values []float64) float64 {
+	known := make([]float64, 0, len(values))
+	for _, v := range values {
+		if !math.IsNaN(v) {
+			known = append(known, v)
+		}
+	}
+	return mean(known)
+}
+
+func summarizeArchetypes(topicId uint64, actorType string, actors []*ActorSummary) []ArchetypeSummary {
+	type outcomes struct {
+		epochs, losses, scores, rewards []float64
+	}
+	byArchetype := make(map[string]*outcomes)
+	for _, actor := range actors {
+		if actor.ActorType != actorType {
+			continue
+		}
+		o, ok := byArchetype[actor.Archetype]
+		if !ok {
+			o = &outcomes{}
+			byArchetype[actor.Archetype] = o
+		}
+		o.epochs = append(o.epochs, float64(actor.Epochs))
+		o.losses = append(o.losses, actor.RealisedLoss)
+		o.scores = append(o.scores, actor.MeanScore)
+		o.rewards = append(o.rewards, actor.TotalReward)
+	}
+
+	honestReward := math.NaN()
+	if honest, ok := byArchetype[research.ArchetypeHonest]; ok {
+		honestReward = meanKnown(honest.rewards)
+	}
+
+	archetypes := make([]string, 0, len(byArchetype))
+	for archetype := range byArchetype {
+		archetypes = append(archetypes, archetype)
+	}
+	sort.Strings(archetypes)
+
+	summaries := make([]ArchetypeSummary, 0, len(archetypes))
+	for _, archetype := range archetypes {
+		o := byArchetype[archetype]
+		summary := ArchetypeSummary{
+			TopicId:      topicId,
+			ActorType:    actorType,
+			Archetype:    archetype,
+			Actors:       len(o.epochs),
+			Epochs:       mean(o.epochs),
+			RealisedLoss: meanKnown(o.losses),
+			MeanScore:    meanKnown(o.scores),
+			Reward:       meanKnown(o.rewards),
+		}
+		summary.RewardVsHonest = summary.Reward / honestReward
+		summaries = append(summaries, summary)
+	}
+	return summaries
+}
+
+func summarizeStake(topicId uint64, actors []*ActorSummary) StakeSummary {
+	var lastStakes, meanStakes, scores, rewards []float64
+	reputers := 0
+	for _, actor := range actors {
+		if actor.ActorType != research.ActorTypeReputer {
+			continue
+		}
+		reputers++
+		if !math.IsNaN(actor.LastStake) {
+			lastStakes = append(lastStakes, actor.LastStake)
+		}
+		meanStakes = append(meanStakes, actor.MeanStake)
+		scores = append(scores, actor.MeanScore)
+		rewards = append(rewards, actor.TotalReward)
+	}
+
+	summary := StakeSummary{
+		TopicId:  topicId,
+		Reputers: reputers,
+		HHI:      math.NaN(),
+		TopShare: math.NaN(),
+	}
+	for _, stake := range lastStakes {
+		summary.TotalStake += stake
+	}
+	if summary.TotalStake > 0 {
+		summary.HHI, summary.TopShare = 0, 0
+		for _, stake := range lastStakes {
+			share := stake / summary.TotalStake
+			summary.HHI += share * share
+			summary.TopShare = math.Max(summary.TopShare, share)
+		}
+	}
+	summary.StakeVsScore, _ = spearman(meanStakes, scores)
+	summary.StakeVsReward, _ = spearman(meanStakes, rewards)
+	return summary
+}
+
+func analyzeNetwork(run *Run, topicId uint64) (NetworkSummary, []EpochPoint) {
+	series := make([]EpochPoint, 0)
+	for epoch, network := range run.NetworkInferences[topicId] {
+		truth, ok := run.GroundTruth[topicId][epoch]
+		if !ok {
+			continue
+		}
+		series = append(series, EpochPoint{Epoch: epoch, GroundTruth: truth, Combined: network.Combined, Naive: network.Naive})
+	}
+	sort.Slice(series, func(i, j int) bool { return series[i].Epoch < series[j].Epoch })
+
+	truths := make([]float64, len(series))
+	combined := make([]float64, len(series))
+	naive := make([]float64, len(series))
+	for i, point := range series {
+		truths[i] = point.GroundTruth
+		combined[i] = point.Combined
+		naive[i] = point.Naive
+	}
+
+	summary := NetworkSummary{
+		TopicId:      topicId,
+		Epochs:       len(series),
+		CombinedRMSE: rmse(combined, truths),
+		NaiveRMSE:    rmse(naive, truths),
+	}
+	summary.Improvement = 1 - summary.CombinedRMSE/summary.NaiveRMSE
+	return summary, series
+}
@@ -0,0 +1,271 @@
+package analysis
+
+import (
+	"fmt"
+	"html"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/allora-network/allora-simulator/workloads/research"
+)
+
+// Report formats
+const (
+	FormatMarkdown = "md"
+	FormatHTML     = "html"
+)
+
+type reportTable struct {
+	header []string
+	rows   [][]string
+}
+
+type reportChart struct {
+	name string
+	svg  string
+}
+
+type reportSection struct {
+	title  string
+	text   string
+	tables []reportTable
+	charts []reportChart
+}
+
+func formatValue(v float64) string {
+	if math.IsNaN(v) {
+		return "n/a"
+	}
+	return strconv.FormatFloat(v, 'g', 4, 64)
+}
+
+func buildSections(analysis *Analysis) []reportSection {
+	sections := make([]reportSection, 0)
+
+	network := reportTable{header: []string{"Topic", "Epochs", "Combined RMSE", "Naive RMSE", "Improvement"}}
+	for _, n := range analysis.Networks {
+		network.rows = append(network.rows, []string{
+			strconv.FormatUint(n.TopicId, 10),
+			strconv.Itoa(n.Epochs),
+			formatValue(n.CombinedRMSE),
+			formatValue(n.NaiveRMSE),
+			formatValue(n.Improvement),
+		})
+	}
+	networkSection := reportSection{
+		title:  "Network inference",
+		text:   "RMSE of the combined and naive network values against the ground truth. Improvement is the relative RMSE reduction of the combined value.",
+		tables: []reportTable{network},
+	}
+	for _, topic := range analysis.Run.Topics {
+		series := analysis.EpochSeries[topic.Id]
+		xs := make([]float64, len(series))
+		truth := Series{Name: "ground truth"}
+		combined := Series{Name: "combined"}
+		naive := Series{Name: "naive"}
+		for i, point := range series {
+			xs[i] = float64(point.Epoch)
+			truth.Values = append(truth.Values, point.GroundTruth)
+			combined.Values = append(combined.Values, point.Combined)
+			naive.Values = append(naive.Values, point.Naive)
+		}
+		networkSection.charts = append(networkSection.charts, reportChart{
+			name: fmt.Sprintf("network_topic_%d", topic.Id),
+			svg:  LineChart(fmt.Sprintf("Topic %d (%s)", topic.Id, topic.Name), "epoch", "value", xs, []Series{truth, combined, naive}),
+		})
+	}
+	sections = append(sections, networkSection)
+
+	correlations := reportTable{header: []string{"Topic", "Role", "Actors", "ρ(-loss, score)", "ρ(-loss, reward)", "ρ(-error, score)", "ρ(-error, reward)"}}
+	for _, c := range analysis.Correlations {
+		correlations.rows = append(correlations.rows, []string{
+			strconv.FormatUint(c.TopicId, 10),
+			c.ActorType,
+			strconv.Itoa(c.Actors),
+			formatValue(c.LossVsScore),
+			formatValue(c.LossVsReward),
+			formatValue(c.ErrorVsScore),
+			formatValue(c.ErrorVsReward),
+		})
+	}
+	sections = append(sections, reportSection{
+		title: "Skill versus on-chain outcomes",
+		text: "Spearman rank correlation between the skill of the actors of a role and their mean on-chain score or total reward. " +
+			"Skill is minus the realised loss or minus the hidden error param. n/a when fewer than 3 actors have both values.",
+		tables: []reportTable{correlations},
+	})
+
+	archetypes := reportTable{header: []string{"Topic", "Role", "Archetype", "Actors", "Mean epochs", "Realised loss", "Mean score", "Reward", "Reward vs honest"}}
+	for _, a := range analysis.Archetypes {
+		archetypes.rows = append(archetypes.rows, []string{
+			strconv.FormatUint(a.TopicId, 10),
+			a.ActorType,
+			a.Archetype,
+			strconv.Itoa(a.Actors),
+			formatValue(a.Epochs),
+			formatValue(a.RealisedLoss),
+			formatValue(a.MeanScore),
+			formatValue(a.Reward),
+			formatValue(a.RewardVsHonest),
+		})
+	}
+	sections = append(sections, reportSection{
+		title: "Archetypes",
+		text: "Mean outcomes of the actors of each archetype. " +
+			"A reward vs honest ratio below 1 means the protocol pays the archetype less than the honest actors of the same role.",
+		tables: []reportTable{archetypes},
+	})
+
+	stakes := reportTable{header: []string{"Topic", "Reputers", "Total stake", "HHI", "Top share", "ρ(stake, score)", "ρ(stake, reward)"}}
+	for _, st := range analysis.Stakes {
+		stakes.rows = append(stakes.rows, []string{
+			strconv.FormatUint(st.TopicId, 10),
+			strconv.Itoa(st.Reputers),
+			formatValue(st.TotalStake),
+			formatValue(st.HHI),
+			formatValue(st.TopShare),
+			formatValue(st.StakeVsScore),
+			formatValue(st.StakeVsReward),
+		})
+	}
+	sections = append(sections, reportSection{
+		title: "Stake concentration",
+		text: "Concentration of the reputer stake, delegations included, at the last epoch, and the Spearman rank correlation " +
+			"of the mean stake of each reputer with its mean score and total reward. An HHI of 1/reputers means equal stakes.",
+		tables: []reportTable{stakes},
+	})
+
+	actors := reportTable{header: []string{
+		"Topic", "Role", "Actor", "Archetype", "Error", "Bias", "Context sensitivity", "Outperform share",
+		"Epochs", "Realised loss", "Mean score", "Total reward", "Last EMA score", "Mean stake",
+	}}
+	for _, a := range analysis.Actors {
+		actors.rows = append(actors.rows, []string{
+			strconv.FormatUint(a.TopicId, 10),
+			a.ActorType,
+			a.Actor,
+			a.Archetype,
+			formatValue(a.Error),
+			formatValue(a.Bias),
+			formatValue(a.ContextSensitivity),
+			formatValue(a.OutperformShare),
+			strconv.Itoa(a.Epochs),
+			formatValue(a.RealisedLoss),
+			formatValue(a.MeanScore),
+			formatValue(a.TotalReward),
+			formatValue(a.LastEmaScore),
+			formatValue(a.MeanStake),
+		})
+	}
+	actorsSection := reportSection{
+		title: "Actors",
+		text: "Hidden research params of each actor against its realised loss and on-chain outcomes. " +
+			"The realised loss of reputers is the mean absolute log10 error of their reported combined loss.",
+		tables: []reportTable{actors},
+	}
+	for _, topic := range analysis.Run.Topics {
+		points := make([]ScatterPoint, 0)
+		for _, a := range analysis.Actors {
+			if a.TopicId == topic.Id && a.ActorType == research.ActorTypeInferer {
+				points = append(points, ScatterPoint{Label: a.Actor, X: a.RealisedLoss, Y: a.MeanScore})
+			}
+		}
+		actorsSection.charts = append(actorsSection.charts, reportChart{
+			name: fmt.Sprintf("inferers_topic_%d", topic.Id),
+			svg:  ScatterChart(fmt.Sprintf("Inferers of topic %d", topic.Id), "realised loss", "mean on-chain score", points),
+		})
+	}
+	sections = append(sections, actorsSection)
+
+	return sections
+}
+
+// WriteReport writes the analysis as a Markdown report with SVG chart files, or as a single HTML file.
+// Returns the path of the report.
+func WriteReport(analysis *Analysis, outDir string, format string) (string, error) {
+	if err := os.MkdirAll(outDir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create report directory: %w", err)
+	}
+	sections := buildSections(analysis)
+
+	var content, path string
+	switch format {
+	case FormatMarkdown:
+		chartsDir := filepath.Join(outDir, "charts")
+		if err := os.MkdirAll(chartsDir, 0o755); err != nil {
+			return "", fmt.Errorf("failed to create charts directory: %w", err)
+		}
+		for _, section := range sections {
+			for _, chart := range section.charts {
+				if err := os.WriteFile(filepath.Join(chartsDir, chart.name+".svg"), []byte(chart.svg), 0o644); err != nil {
+					return "", fmt.Errorf("failed to write chart %s: %w", chart.name, err)
+				}
+			}
+		}
+		content = renderMarkdown(analysis.Run, sections)
+		path = filepath.Join(outDir, "report.md")
+	case FormatHTML:
+		content = renderHTML(analysis.Run, sections)
+		path = filepath.Join(outDir, "report.html")
+	default:
+		return "", fmt.Errorf("unknown report format: %s", format)
+	}
+
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		return "", fmt.Errorf("failed to write report: %w", err)
+	}
+	return path, nil
+}
+
+func renderMarkdown(run *Run, sections []reportSection) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Research run analysis\n\nRun: `%s`\n", run.Dir)
+	for _, section := range sections {
+		fmt.Fprintf(&b, "\n## %s\n\n%s\n", section.title, section.text)
+		for _, table := range section.tables {
+			b.WriteString("\n| " + strings.Join(table.header, " | ") + " |\n")
+			b.WriteString("|" + strings.Repeat("---|", len(table.header)) + "\n")
+			for _, row := range table.rows {
+				b.WriteString("| " + strings.Join(row, " | ") + " |\n")
+			}
+		}
+		for _, chart := range section.charts {
+			fmt.Fprintf(&b, "\n![%s](charts/%s.svg)\n", chart.name, chart.name)
+		}
+	}
+	return b.String()
+}
+
+func renderHTML(run *Run, sections []reportSection) string {
+	var b strings.Builder
+	b.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>Research run analysis</title>\n")
+	b.WriteString("<style>body{font-family:sans-serif;margin:2em}table{border-collapse:collapse;margin:1em 0}td,th{border:1px solid #ccc;padding:4px 8px;text-align:right}</style>\n")
+	b.WriteString("</head>\n<body>\n")
+	fmt.Fprintf(&b, "<h1>Research run analysis</h1>\n<p>Run: <code>%s</code></p>\n", html.EscapeString(run.Dir))
+	for _, section := range sections {
+		fmt.Fprintf(&b, "<h2>%s</h2>\n<p>%s</p>\n", html.EscapeString(section.title), html.EscapeString(section.text))
+		for _, table := range section.tables {
+			b.WriteString("<table>\n<tr>")
+			for _, column := range table.header {
+				fmt.Fprintf(&b, "<th>%s</th>", html.EscapeString(column))
+			}
+			b.WriteString("</tr>\n")
+			for _, row := range table.rows {
+				b.WriteString("<tr>")
+				for _, value := range row {
+					fmt.Fprintf(&b, "<td>%s</td>", html.EscapeString(value))
+				}
+				b.WriteString("</tr>\n")
+			}
+			b.WriteString("</table>\n")
+		}
+		for _, chart := range section.charts {
+			b.WriteString(chart.svg)
+		}
+	}
+	b.WriteString("</body>\n</html>\n")
+	return b.String()
+}
@@ -0,0 +1,321 @@
+package analysis
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/allora-network/allora-simulator/types"
+	"github.com/allora-network/allora-simulator/workloads/research"
+)
+
+// Topic is the research model of a topic of the run
+type Topic struct {
+	Id          uint64
+	Name        string
+	LossMethod  string
+	EpochLength int64
+	Process     string
+}
+
+// Actor is an actor of a topic and its hidden research params
+type Actor struct {
+	TopicId            uint64
+	Addr               string
+	ActorType          string
+	Error              float64
+	Bias               float64
+	BiasWithVolatility float64
+	ContextSensitivity float64
+	Archetype          string
+}
+
+type Inference struct {
+	TopicId    uint64
+	Epoch      int64
+	Inferer    string
+	Value      float64
+	Outperform bool
+}
+
+type ForecastElement struct {
+	TopicId    uint64
+	Epoch      int64
+	Forecaster string
+	Inferer    string
+	Value      float64
+}
+
+type NetworkInference struct {
+	Combined float64
+	Naive    float64
+}
+
+type Loss struct {
+	TopicId uint64
+	Epoch   int64
+	Reputer string
+	Kind    string
+	Worker  string
+	Loss    float64
+}
+
+type OnChainValue struct {
+	TopicId    uint64
+	Epoch      int64
+	ActorType  string
+	Metric     string
+	Actor      string
+	OtherActor string
+	Value      float64
+}
+
+// Run is the data written by a research run, as loaded from its results directory
+type Run struct {
+	Dir         string
+	Topics      []*Topic
+	Actors      []*Actor
+	GroundTruth map[uint64]map[int64]float64
+	// Ground truth each epoch moved from, that the direction loss scores moves against
+	PreviousGroundTruth map[uint64]map[int64]float64
+	Inferences          []Inference
+	Forecasts           []ForecastElement
+	NetworkInferences   map[uint64]map[int64]NetworkInference
+	Losses              []Loss
+	// Empty for offline runs
+	OnChain []OnChainValue
+}
+
+// csvRow gives access to the columns of a row by name
+type csvRow struct {
+	file    string
+	line    int
+	columns map[string]int
+	values  []string
+	err     error
+}
+
+func (r *csvRow) str(column string) string {
+	i, ok := r.columns[column]
+	if !ok || i >= len(r.values) {
+		if r.err == nil {
+			r.err = fmt.Errorf("%s:%d: missing column %s", r.file, r.line, column)
+		}
+		return ""
+	}
+	return r.values[i]
+}
+
+func (r *csvRow) float(column string) float64 {
+	value, err := strconv.ParseFloat(r.str(column), 64)
+	if err != nil && r.err == nil {
+		r.err = fmt.Errorf("%s:%d: invalid %s: %w", r.file, r.line, column, err)
+	}
+	return value
+}
+
+func (r *csvRow) int(column string) int64 {
+	value, err := strconv.ParseInt(r.str(column), 10, 64)
+	if err != nil && r.err == nil {
+		r.err = fmt.Errorf("%s:%d: invalid %s: %w", r.file, r.line, column, err)
+	}
+	return value
+}
+
+func (r *csvRow) uint(column string) uint64 {
+	value, err := strconv.ParseUint(r.str(column), 10, 64)
+	if err != nil && r.err == nil {
+		r.err = fmt.Errorf("%s:%d: invalid %s: %w", r.file, r.line, column, err)
+	}
+	return value
+}
+
+func (r *csvRow) bool(column string) bool {
+	value, err := strconv.ParseBool(r.str(column))
+	if err != nil && r.err == nil {
+		r.err = fmt.Errorf("%s:%d: invalid %s: %w", r.file, r.line, column, err)
+	}
+	return value
+}
+
+// Read every row of a results file. Missing optional files are read as empty.
+func readResultsFile(dir, name string, optional bool, fn func(row *csvRow) error) error {
+	path := filepath.Join(dir, name)
+	file, err := os.Open(path)
+	if err != nil {
+		if optional && errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read header of %s: %w", path, err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, column := range header {
+		columns[column] = i
+	}
+
+	for line := 2; ; line++ {
+		values, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		row := &csvRow{file: name, line: line, columns: columns, values: values}
+		if err := fn(row); err != nil {
+			return err
+		}
+		if row.err != nil {
+			return row.err
+		}
+	}
+}
+
+// LoadRun reads the results files of a finished research run
+func LoadRun(dir string) (*Run, error) {
+	run := &Run{
+		Dir:                 dir,
+		GroundTruth:         make(map[uint64]map[int64]float64),
+		PreviousGroundTruth: make(map[uint64]map[int64]float64),
+		NetworkInferences:   make(map[uint64]map[int64]NetworkInference),
+	}
+
+	err := readResultsFile(dir, research.TopicsFile, false, func(row *csvRow) error {
+		run.Topics = append(run.Topics, &Topic{
+			Id:          row.uint("topic_id"),
+			Name:        row.str("name"),
+			LossMethod:  row.str("loss_method"),
+			EpochLength: row.int("epoch_length"),
+			Process:     row.str("ground_truth_process"),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(run.Topics) == 0 {
+		return nil, fmt.Errorf("no topics found in %s", dir)
+	}
+
+	err = readResultsFile(dir, research.ActorsFile, false, func(row *csvRow) error {
+		run.Actors = append(run.Actors, &Actor{
+			TopicId:            row.uint("topic_id"),
+			Addr:               row.str("actor"),
+			ActorType:          row.str("actor_type"),
+			Error:              row.float("error"),
+			Bias:               row.float("bias"),
+			BiasWithVolatility: row.float("bias_with_volatility"),
+			ContextSensitivity: row.float("context_sensitivity"),
+			Archetype:          row.str("archetype"),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = readResultsFile(dir, research.GroundTruthFile, false, func(row *csvRow) error {
+		topicId := row.uint("topic_id")
+		if run.GroundTruth[topicId] == nil {
+			run.GroundTruth[topicId] = make(map[int64]float64)
+			run.PreviousGroundTruth[topicId] = make(map[int64]float64)
+		}
+		state := &types.GroundTruthState{CurrentPrice: row.float("price"), LastReturn: row.float("return")}
+		run.GroundTruth[topicId][row.int("epoch")] = state.CurrentPrice
+		run.PreviousGroundTruth[topicId][row.int("epoch")] = research.PreviousPrice(state)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = readResultsFile(dir, research.InferencesFile, false, func(row *csvRow) error {
+		run.Inferences = append(run.Inferences, Inference{
+			TopicId:    row.uint("topic_id"),
+			Epoch:      row.int("epoch"),
+			Inferer:    row.str("inferer"),
+			Value:      row.float("value"),
+			Outperform: row.bool("outperform"),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = readResultsFile(dir, research.ForecastsFile, false, func(row *csvRow) error {
+		run.Forecasts = append(run.Forecasts, ForecastElement{
+			TopicId:    row.uint("topic_id"),
+			Epoch:      row.int("epoch"),
+			Forecaster: row.str("forecaster"),
+			Inferer:    row.str("inferer"),
+			Value:      row.float("value"),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = readResultsFile(dir, research.NetworkInferencesFile, false, func(row *csvRow) error {
+		topicId := row.uint("topic_id")
+		if run.NetworkInferences[topicId] == nil {
+			run.NetworkInferences[topicId] = make(map[int64]NetworkInference)
+		}
+		run.NetworkInferences[topicId][row.int("epoch")] = NetworkInference{
+			Combined: row.float("combined_value"),
+			Naive:    row.float("naive_value"),
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = readResultsFile(dir, research.LossesFile, false, func(row *csvRow) error {
+		run.Losses = append(run.Losses, Loss{
+			TopicId: row.uint("topic_id"),
+			Epoch:   row.int("epoch"),
+			Reputer: row.str("reputer"),
+			Kind:    row.str("kind"),
+			Worker:  row.str("worker"),
+			Loss:    row.float("loss"),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = readResultsFile(dir, research.OnChainFile, true, func(row *csvRow) error {
+		run.OnChain = append(run.OnChain, OnChainValue{
+			TopicId:    row.uint("topic_id"),
+			Epoch:      row.int("epoch"),
+			ActorType:  row.str("actor_type"),
+			Metric:     row.str("metric"),
+			Actor:      row.str("actor"),
+			OtherActor: row.str("other_actor"),
+			Value:      row.float("value"),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return run, nil
+}
@@ -0,0 +1,88 @@
+package analysis
+
+import (
+	"math"
+	"sort"
+)
+
+// Minimum number of actors for a rank correlation to be reported
+const minCorrelationSamples = 3
+
+func mean(values []float64) float64 {
+	if len(values) == 0 {
+		return math.NaN()
+	}
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+// Root mean squared difference between predictions and observations of the same length
+func rmse(predictions, observations []float64) float64 {
+	if len(predictions) == 0 || len(predictions) != len(observations) {
+		return math.NaN()
+	}
+	sum := 0.0
+	for i := range predictions {
+		diff := predictions[i] - observations[i]
+		sum += diff * diff
+	}
+	return math.Sqrt(sum / float64(len(predictions)))
+}
+
+// Ranks of the values starting at 1, tied values get the average of their ranks
+func ranks(values []float64) []float64 {
+	order := make([]int, len(values))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return values[order[a]] < values[order[b]] })
+
+	res := make([]float64, len(values))
+	for start := 0; start < len(order); {
+		end := start + 1
+		for end < len(order) && values[order[end]] == values[order[start]] {
+			end++
+		}
+		// Ranks start..end-1 are tied, 1-based
+		rank := float64(start+end+1) / 2
+		for _, i := range order[start:end] {
+			res[i] = rank
+		}
+		start = end
+	}
+	return res
+}
+
+func pearson(x, y []float64) float64 {
+	mx, my := mean(x), mean(y)
+	var cov, vx, vy float64
+	for i := range x {
+		cov += (x[i] - mx) * (y[i] - my)
+		vx += (x[i] - mx) * (x[i] - mx)
+		vy += (y[i] - my) * (y[i] - my)
+	}
+	if vx == 0 || vy == 0 {
+		return math.NaN()
+	}
+	return cov / math.Sqrt(vx*vy)
+}
+
+// Spearman rank correlation of the pairs where both values are known.
+// NaN when there are too few pairs or one of the series is constant.
+func spearman(x, y []float64) (float64, int) {
+	xs, ys := make([]float64, 0, len(x)), make([]float64, 0, len(y))
+	for i := range x {
+		if math.IsNaN(x[i]) || math.IsNaN(y[i]) {
+			continue
+		}
+		xs = append(xs, x[i])
+		ys = append(ys, y[i])
+	}
+	if len(xs) < minCorrelationSamples {
+		return math.NaN(), len(xs)
+	}
+	return pearson(ranks(xs), ranks(ys)), len(xs)
+}
@@ -0,0 +1,127 @@
+package analysis
+
+import (
+	"fmt"
+	"html"
+	"math"
+	"strings"
+)
+
+const (
+	chartWidth   = 720
+	chartHeight  = 360
+	chartPadding = 50
+)
+
+var chartColors = []string{"#1f77b4", "#ff7f0e", "#2ca02c", "#d62728", "#9467bd"}
+
+// Series is a named line of a line chart
+type Series struct {
+	Name   string
+	Values []float64
+}
+
+// ScatterPoint is a labelled point of a scatter chart
+type ScatterPoint struct {
+	Label string
+	X     float64
+	Y     float64
+}
+
+type chartScale struct {
+	min, max float64
+	from, to float64
+}
+
+func newChartScale(values []float64, from, to float64) chartScale {
+	scale := chartScale{min: math.Inf(1), max: math.Inf(-1), from: from, to: to}
+	for _, v := range values {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			continue
+		}
+		scale.min = math.Min(scale.min, v)
+		scale.max = math.Max(scale.max, v)
+	}
+	if math.IsInf(scale.min, 0) {
+		scale.min, scale.max = 0, 1
+	}
+	if scale.min == scale.max {
+		scale.min, scale.max = scale.min-1, scale.max+1
+	}
+	return scale
+}
+
+func (s chartScale) at(v float64) float64 {
+	return s.from + (v-s.min)/(s.max-s.min)*(s.to-s.from)
+}
+
+func writeChartFrame(b *strings.Builder, title, xLabel, yLabel string, x, y chartScale) {
+	fmt.Fprintf(b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif" font-size="11">`+"\n",
+		chartWidth, chartHeight, chartWidth, chartHeight)
+	fmt.Fprintf(b, `<rect width="%d" height="%d" fill="white"/>`+"\n", chartWidth, chartHeight)
+	fmt.Fprintf(b, `<text x="%d" y="20" text-anchor="middle" font-size="14">%s</text>`+"\n", chartWidth/2, html.EscapeString(title))
+
+	// Axes with their extreme values
+	fmt.Fprintf(b, `<line x1="%d" y1="%d" x2="%d" y2="%d" stroke="black"/>`+"\n", chartPadding, chartHeight-chartPadding, chartWidth-chartPadding, chartHeight-chartPadding)
+	fmt.Fprintf(b, `<line x1="%d" y1="%d" x2="%d" y2="%d" stroke="black"/>`+"\n", chartPadding, chartPadding, chartPadding, chartHeight-chartPadding)
+	fmt.Fprintf(b, `<text x="%d" y="%d" text-anchor="middle">%s</text>`+"\n", chartPadding, chartHeight-chartPadding+15, formatTick(x.min))
+	fmt.Fprintf(b, `<text x="%d" y="%d" text-anchor="middle">%s</text>`+"\n", chartWidth-chartPadding, chartHeight-chartPadding+15, formatTick(x.max))
+	fmt.Fprintf(b, `<text x="%d" y="%d" text-anchor="end">%s</text>`+"\n", chartPadding-4, chartHeight-chartPadding, formatTick(y.min))
+	fmt.Fprintf(b, `<text x="%d" y="%d" text-anchor="end">%s</text>`+"\n", chartPadding-4, chartPadding+4, formatTick(y.max))
+	fmt.Fprintf(b, `<text x="%d" y="%d" text-anchor="middle">%s</text>`+"\n", chartWidth/2, chartHeight-10, html.EscapeString(xLabel))
+	fmt.Fprintf(b, `<text x="15" y="%d" text-anchor="middle" transform="rotate(-90 15 %d)">%s</text>`+"\n", chartHeight/2, chartHeight/2, html.EscapeString(yLabel))
+}
+
+func formatTick(v float64) string {
+	return fmt.Sprintf("%.4g", v)
+}
+
+// LineChart draws series sharing the same x values
+func LineChart(title, xLabel, yLabel string, xs []float64, series []Series) string {
+	all := make([]float64, 0)
+	for _, s := range series {
+		all = append(all, s.Values...)
+	}
+	x := newChartScale(xs, chartPadding, chartWidth-chartPadding)
+	y := newChartScale(all, chartHeight-chartPadding, chartPadding)
+
+	var b strings.Builder
+	writeChartFrame(&b, title, xLabel, yLabel, x, y)
+	for i, s := range series {
+		color := chartColors[i%len(chartColors)]
+		points := make([]string, 0, len(s.Values))
+		for j, v := range s.Values {
+			if j >= len(xs) || math.IsNaN(v) {
+				continue
+			}
+			points = append(points, fmt.Sprintf("%.1f,%.1f", x.at(xs[j]), y.at(v)))
+		}
+		fmt.Fprintf(&b, `<polyline fill="none" stroke="%s" stroke-width="1.5" points="%s"/>`+"\n", color, strings.Join(points, " "))
+		fmt.Fprintf(&b, `<text x="%d" y="%d" fill="%s">%s</text>`+"\n", chartWidth-chartPadding-120, chartPadding+15*i, color, html.EscapeString(s.Name))
+	}
+	b.WriteString("</svg>\n")
+	return b.String()
+}
+
+// ScatterChart draws labelled points, points with unknown coordinates are skipped
+func ScatterChart(title, xLabel, yLabel string, points []ScatterPoint) string {
+	xs := make([]float64, len(points))
+	ys := make([]float64, len(points))
+	for i, p := range points {
+		xs[i], ys[i] = p.X, p.Y
+	}
+	x := newChartScale(xs, chartPadding, chartWidth-chartPadding)
+	y := newChartScale(ys, chartHeight-chartPadding, chartPadding)
+
+	var b strings.Builder
+	writeChartFrame(&b, title, xLabel, yLabel, x, y)
+	for _, p := range points {
+		if math.IsNaN(p.X) || math.IsNaN(p.Y) {
+			continue
+		}
+		fmt.Fprintf(&b, `<circle cx="%.1f" cy="%.1f" r="4" fill="%s"><title>%s</title></circle>`+"\n",
+			x.at(p.X), y.at(p.Y), chartColors[0], html.EscapeString(p.Label))
+	}
+	b.WriteString("</svg>\n")
+	return b.String()
+}
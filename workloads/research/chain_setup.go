@@ -14,11 +14,22 @@ import (
 func ConfigureChainParams(actor *types.Actor, config *types.Config) error {
 	log.Info().Msgf("Configuring chain parameters for research simulation")
 
+	err := UpdateChainParams(actor, &emissionstypes.OptionalParams{
+		MaxSamplesToScaleScores: []uint64{config.Research.GlobalParams.MaxSamplesToScaleScores},
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Info().Msgf("Successfully configured chain parameters")
+	return nil
+}
+
+// UpdateChainParams sets the emissions params given in params, the others keep their value
+func UpdateChainParams(actor *types.Actor, params *emissionstypes.OptionalParams) error {
 	updateParamRequest := &emissionstypes.UpdateParamsRequest{
 		Sender: actor.Addr,
-		Params: &emissionstypes.OptionalParams{
-			MaxSamplesToScaleScores: []uint64{config.Research.GlobalParams.MaxSamplesToScaleScores},
-		},
+		Params: params,
 	}
 
 	_, updatedSeq, err := common.SendDataWithRetry(actor.TxParams, true, updateParamRequest)
@@ -26,7 +37,5 @@ func ConfigureChainParams(actor *types.Actor, config *types.Config) error {
 		return fmt.Errorf("failed to update chain parameters: %w", err)
 	}
 	actor.TxParams.Sequence = updatedSeq
-
-	log.Info().Msgf("Successfully configured chain parameters")
 	return nil
 }
@@ -0,0 +1,72 @@
+package research
+
+import (
+	cosmosmath "cosmossdk.io/math"
+	"github.com/allora-network/allora-simulator/types"
+	"github.com/allora-network/allora-simulator/workloads/common"
+	"github.com/rs/zerolog/log"
+)
+
+// Make inferers, forecasters and reputers join, leave and return to every topic while the loops run.
+// Joining actors are honest and get fresh research params, every membership change is written to the results.
+func runChurnLoop(
+	data *ResearchSimulationData,
+	config *types.Config,
+	writer *ResultsWriter,
+	topicIds []uint64,
+	stop <-chan struct{},
+) {
+	state := common.NewChurnState(data.SpareActors)
+	common.RunRoundLoop(config, config.Churn.IntervalEpochs, topicIds, data.GetEpochLength, stop, func(topicId uint64, height int64) {
+		topicConfig := data.GetTopicConfig(topicId)
+		record := func(actor *types.Actor, actorType string, event string) {
+			if err := writer.WriteMembership(topicId, height, actor, actorType, event); err != nil {
+				log.Error().Err(err).Msgf("Error writing membership of %s in topic %d", actor.Addr, topicId)
+			}
+		}
+		join := func(actor *types.Actor, actorType string, params *types.ResearchParams, add func(uint64, *types.Actor)) {
+			data.SetResearchParams(topicId, actor.Addr, params)
+			if err := writer.WriteActor(topicId, actor, actorType, params); err != nil {
+				log.Error().Err(err).Msgf("Error writing actor %s of topic %d", actor.Addr, topicId)
+			}
+			add(topicId, actor)
+			record(actor, actorType, MembershipJoin)
+		}
+		role := func(actorType string, isReputer bool, add, remove func(uint64, *types.Actor)) common.ChurnRole {
+			return common.ChurnRole{
+				TopicId:    topicId,
+				Name:       actorType,
+				IsReputer:  isReputer,
+				Unregister: config.Churn.Unregister,
+				Remove:     func(actor *types.Actor) { remove(topicId, actor) },
+				Restore:    func(actor *types.Actor) { add(topicId, actor) },
+				Leave: func(actor *types.Actor) {
+					record(actor, actorType, MembershipLeave)
+				},
+				Return: func(actor *types.Actor) {
+					add(topicId, actor)
+					record(actor, actorType, MembershipReturn)
+				},
+			}
+		}
+
+		inferers := role(ActorTypeInferer, false, data.AddInfererRegistration, data.RemoveInfererRegistration)
+		inferers.Join = func(actor *types.Actor) {
+			join(actor, ActorTypeInferer, InitializeWorkerResearchParams(topicConfig.Volatility), data.AddInfererRegistration)
+		}
+		state.Round(config.Churn.Inferers, inferers, data.GetInferersForTopic(topicId))
+
+		forecasters := role(ActorTypeForecaster, false, data.AddForecasterRegistration, data.RemoveForecasterRegistration)
+		forecasters.Join = func(actor *types.Actor) {
+			join(actor, ActorTypeForecaster, InitializeWorkerResearchParams(topicConfig.Volatility), data.AddForecasterRegistration)
+		}
+		state.Round(config.Churn.Forecasters, forecasters, data.GetForecastersForTopic(topicId))
+
+		reputers := role(ActorTypeReputer, true, data.AddReputerRegistration, data.RemoveReputerRegistration)
+		reputers.Stake = func() cosmosmath.Int { return common.DrawStake(config.Staking.Stake) }
+		reputers.Join = func(actor *types.Actor) {
+			join(actor, ActorTypeReputer, InitializeReputerResearchParams(), data.AddReputerRegistration)
+		}
+		state.Round(config.Churn.Reputers, reputers, data.GetReputersForTopic(topicId))
+	})
+}
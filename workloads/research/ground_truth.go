@@ -0,0 +1,364 @@
+package research
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/allora-network/allora-simulator/types"
+	"github.com/allora-network/allora-simulator/workloads/common"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	GroundTruthRandomWalk        = "random_walk"
+	GroundTruthOrnsteinUhlenbeck = "ornstein_uhlenbeck"
+	GroundTruthJumpDiffusion     = "jump_diffusion"
+	GroundTruthGarch             = "garch"
+	GroundTruthRegimeSwitching   = "regime_switching"
+	GroundTruthSine              = "sine"
+	GroundTruthStep              = "step"
+	GroundTruthHistorical        = "historical"
+)
+
+// GroundTruthProcess generates the ground truth price series of a research topic.
+// Implementations are stateless: everything they need between epochs is carried in GroundTruthState.
+type GroundTruthProcess interface {
+	Name() string
+	InitialState() *types.GroundTruthState
+	Next(state *types.GroundTruthState) *types.GroundTruthState
+}
+
+// NewGroundTruthProcess builds the process selected in the research config.
+// An empty process name keeps the original geometric random walk.
+func NewGroundTruthProcess(config *types.ResearchConfig) (GroundTruthProcess, error) {
+	gt := config.GroundTruth
+	switch gt.Process {
+	case "", GroundTruthRandomWalk:
+		return &randomWalkProcess{config: config}, nil
+	case GroundTruthOrnsteinUhlenbeck:
+		if gt.MeanReversion <= 0 || gt.MeanReversion > 1 {
+			return nil, fmt.Errorf("mean_reversion must be in (0, 1], got %f", gt.MeanReversion)
+		}
+		return &ornsteinUhlenbeckProcess{config: config}, nil
+	case GroundTruthJumpDiffusion:
+		if gt.JumpIntensity < 0 {
+			return nil, fmt.Errorf("jump_intensity cannot be negative, got %f", gt.JumpIntensity)
+		}
+		return &jumpDiffusionProcess{config: config}, nil
+	case GroundTruthGarch:
+		if gt.GarchOmega <= 0 || gt.GarchAlpha < 0 || gt.GarchBeta < 0 || gt.GarchAlpha+gt.GarchBeta >= 1 {
+			return nil, fmt.Errorf("garch params must satisfy omega > 0, alpha >= 0, beta >= 0 and alpha + beta < 1")
+		}
+		return &garchProcess{config: config}, nil
+	case GroundTruthRegimeSwitching:
+		if gt.CalmToTurbulentProb < 0 || gt.CalmToTurbulentProb > 1 || gt.TurbulentToCalmProb < 0 || gt.TurbulentToCalmProb > 1 {
+			return nil, fmt.Errorf("regime transition probabilities must be in [0, 1]")
+		}
+		return &regimeSwitchingProcess{config: config}, nil
+	case GroundTruthSine, GroundTruthStep:
+		if gt.Period <= 0 {
+			return nil, fmt.Errorf("period must be positive for the %s process, got %d", gt.Process, gt.Period)
+		}
+		if math.Abs(gt.Amplitude) >= 1 {
+			return nil, fmt.Errorf("amplitude must be in (-1, 1) to keep prices positive, got %f", gt.Amplitude)
+		}
+		return &deterministicProcess{config: config, step: gt.Process == GroundTruthStep}, nil
+	case GroundTruthHistorical:
+		return newHistoricalProcess(config)
+	default:
+		return nil, fmt.Errorf("unknown ground truth process: %s", gt.Process)
+	}
+}
+
+// GroundTruthStream is the ground truth series of a topic, drawn once and read by epoch.
+// The arms of a topic read the same stream, whatever their pace.
+type GroundTruthStream struct {
+	mu      sync.Mutex
+	process GroundTruthProcess
+	states  []*types.GroundTruthState
+}
+
+func NewGroundTruthStream(process GroundTruthProcess) *GroundTruthStream {
+	return &GroundTruthStream{
+		process: process,
+		states:  []*types.GroundTruthState{process.InitialState()},
+	}
+}
+
+// At returns the ground truth of an epoch, drawing the epochs up to it on first read
+func (s *GroundTruthStream) At(epoch int64) *types.GroundTruthState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for int64(len(s.states)) <= epoch {
+		s.states = append(s.states, s.process.Next(s.states[len(s.states)-1]))
+	}
+	return s.states[epoch]
+}
+
+// NewGroundTruthState returns the state of a synthetic ground truth series before its first epoch
+func NewGroundTruthState(config *types.ResearchConfig) *types.GroundTruthState {
+	return &types.GroundTruthState{
+		CumulativeReturn: 0,
+		CurrentPrice:     config.InitialPrice,
+		LastReturn:       0,
+		Variance:         config.Volatility * config.Volatility,
+	}
+}
+
+// PreviousPrice is the price a state moved from with its last log return, the price itself before the first epoch
+func PreviousPrice(state *types.GroundTruthState) float64 {
+	return state.CurrentPrice * math.Exp(-state.LastReturn)
+}
+
+// Builds the next state from the return of this epoch, keeping the price log-normal around the initial price
+func applyReturn(config *types.ResearchConfig, state *types.GroundTruthState, returnT float64) *types.GroundTruthState {
+	newState := *state
+	newState.CumulativeReturn = state.CumulativeReturn + returnT
+	newState.LastReturn = returnT
+	newState.CurrentPrice = config.InitialPrice * math.Exp(newState.CumulativeReturn)
+	newState.Step = state.Step + 1
+	return &newState
+}
+
+// Geometric random walk with constant drift and volatility
+type randomWalkProcess struct {
+	config *types.ResearchConfig
+}
+
+func (p *randomWalkProcess) Name() string { return GroundTruthRandomWalk }
+
+func (p *randomWalkProcess) InitialState() *types.GroundTruthState {
+	return NewGroundTruthState(p.config)
+}
+
+func (p *randomWalkProcess) Next(state *types.GroundTruthState) *types.GroundTruthState {
+	next := GetNextGroundTruth(state, p.config.InitialPrice, p.config.Drift, p.config.Volatility)
+	next.Step = state.Step + 1
+	next.Variance = state.Variance
+	next.Turbulent = state.Turbulent
+	return next
+}
+
+// Mean-reverting Ornstein-Uhlenbeck process on the log price
+type ornsteinUhlenbeckProcess struct {
+	config *types.ResearchConfig
+}
+
+func (p *ornsteinUhlenbeckProcess) Name() string { return GroundTruthOrnsteinUhlenbeck }
+
+func (p *ornsteinUhlenbeckProcess) InitialState() *types.GroundTruthState {
+	return NewGroundTruthState(p.config)
+}
+
+func (p *ornsteinUhlenbeckProcess) Next(state *types.GroundTruthState) *types.GroundTruthState {
+	gt := p.config.GroundTruth
+	// Long run level defaults to the initial price
+	longRunLevel := 0.0
+	if gt.LongRunPrice > 0 {
+		longRunLevel = math.Log(gt.LongRunPrice / p.config.InitialPrice)
+	}
+
+	// dx = theta * (mu - x) + sigma * eps
+	returnT := gt.MeanReversion*(longRunLevel-state.CumulativeReturn) + rand.NormFloat64()*p.config.Volatility
+	return applyReturn(p.config, state, returnT)
+}
+
+// Merton jump diffusion: a random walk with Poisson distributed normal jumps
+type jumpDiffusionProcess struct {
+	config *types.ResearchConfig
+}
+
+func (p *jumpDiffusionProcess) Name() string { return GroundTruthJumpDiffusion }
+
+func (p *jumpDiffusionProcess) InitialState() *types.GroundTruthState {
+	return NewGroundTruthState(p.config)
+}
+
+func (p *jumpDiffusionProcess) Next(state *types.GroundTruthState) *types.GroundTruthState {
+	gt := p.config.GroundTruth
+	returnT := rand.NormFloat64()*p.config.Volatility + p.config.Drift
+
+	jumps := common.Poisson(gt.JumpIntensity)
+	for i := 0; i < jumps; i++ {
+		returnT += rand.NormFloat64()*gt.JumpVolatility + gt.JumpMean
+	}
+
+	return applyReturn(p.config, state, returnT)
+}
+
+// GARCH(1,1) stochastic volatility
+type garchProcess struct {
+	config *types.ResearchConfig
+}
+
+func (p *garchProcess) Name() string { return GroundTruthGarch }
+
+func (p *garchProcess) InitialState() *types.GroundTruthState {
+	return NewGroundTruthState(p.config)
+}
+
+func (p *garchProcess) Next(state *types.GroundTruthState) *types.GroundTruthState {
+	gt := p.config.GroundTruth
+
+	// sigma^2_t = omega + alpha * r^2_{t-1} + beta * sigma^2_{t-1}
+	shock := state.LastReturn - p.config.Drift
+	variance := gt.GarchOmega + gt.GarchAlpha*shock*shock + gt.GarchBeta*state.Variance
+
+	returnT := rand.NormFloat64()*math.Sqrt(variance) + p.config.Drift
+	newState := applyReturn(p.config, state, returnT)
+	newState.Variance = variance
+	return newState
+}
+
+// Markov switching between a calm and a turbulent regime
+type regimeSwitchingProcess struct {
+	config *types.ResearchConfig
+}
+
+func (p *regimeSwitchingProcess) Name() string { return GroundTruthRegimeSwitching }
+
+func (p *regimeSwitchingProcess) InitialState() *types.GroundTruthState {
+	return NewGroundTruthState(p.config)
+}
+
+func (p *regimeSwitchingProcess) Next(state *types.GroundTruthState) *types.GroundTruthState {
+	gt := p.config.GroundTruth
+
+	turbulent := state.Turbulent
+	if turbulent && rand.Float64() < gt.TurbulentToCalmProb {
+		turbulent = false
+	} else if !turbulent && rand.Float64() < gt.CalmToTurbulentProb {
+		turbulent = true
+	}
+
+	drift, volatility := p.config.Drift, p.config.Volatility
+	if turbulent {
+		drift, volatility = gt.TurbulentDrift, gt.TurbulentVolatility
+	}
+
+	newState := applyReturn(p.config, state, rand.NormFloat64()*volatility+drift)
+	newState.Turbulent = turbulent
+	return newState
+}
+
+// Deterministic sine or step series, useful to debug the network weighting
+type deterministicProcess struct {
+	config *types.ResearchConfig
+	step   bool
+}
+
+func (p *deterministicProcess) Name() string {
+	if p.step {
+		return GroundTruthStep
+	}
+	return GroundTruthSine
+}
+
+func (p *deterministicProcess) InitialState() *types.GroundTruthState {
+	return NewGroundTruthState(p.config)
+}
+
+func (p *deterministicProcess) Next(state *types.GroundTruthState) *types.GroundTruthState {
+	gt := p.config.GroundTruth
+	step := state.Step + 1
+
+	var level float64
+	if p.step {
+		// Alternate between the initial price and the shifted price every period
+		if (step/gt.Period)%2 == 1 {
+			level = gt.Amplitude
+		}
+	} else {
+		level = gt.Amplitude * math.Sin(2*math.Pi*float64(step)/float64(gt.Period))
+	}
+
+	newState := *state
+	newState.Step = step
+	newState.CurrentPrice = p.config.InitialPrice * (1 + level)
+	newState.LastReturn = math.Log(newState.CurrentPrice / state.CurrentPrice)
+	newState.CumulativeReturn = math.Log(newState.CurrentPrice / p.config.InitialPrice)
+	return &newState
+}
+
+// Replays a historical price series, one resampled price per epoch
+type historicalProcess struct {
+	config *types.ResearchConfig
+	prices []float64
+}
+
+func newHistoricalProcess(config *types.ResearchConfig) (*historicalProcess, error) {
+	gt := config.GroundTruth
+	if gt.HistoricalFile == "" {
+		return nil, fmt.Errorf("historical_file is required for the %s process", GroundTruthHistorical)
+	}
+
+	var interval time.Duration
+	if gt.HistoricalInterval != "" {
+		var err error
+		interval, err = time.ParseDuration(gt.HistoricalInterval)
+		if err != nil {
+			return nil, fmt.Errorf("invalid historical_interval: %w", err)
+		}
+	}
+
+	points, err := LoadPriceSeries(gt.HistoricalFile)
+	if err != nil {
+		return nil, err
+	}
+	prices := ResamplePriceSeries(points, interval)
+
+	if gt.StartOffset < 0 || gt.StartOffset >= int64(len(prices)) {
+		return nil, fmt.Errorf("start_offset %d is outside the %d epochs of the series", gt.StartOffset, len(prices))
+	}
+
+	scale := gt.Scale
+	if scale == 0 {
+		scale = 1
+	}
+	for i, price := range prices {
+		if price <= 0 {
+			return nil, fmt.Errorf("price series must be positive, got %f at epoch %d", price, i)
+		}
+		prices[i] = price * scale
+	}
+
+	log.Info().Msgf("Loaded %d epochs of historical prices from %s", len(prices), gt.HistoricalFile)
+	return &historicalProcess{config: config, prices: prices}, nil
+}
+
+func (p *historicalProcess) Name() string { return GroundTruthHistorical }
+
+func (p *historicalProcess) InitialState() *types.GroundTruthState {
+	return &types.GroundTruthState{
+		CurrentPrice: p.priceAt(0),
+	}
+}
+
+func (p *historicalProcess) Next(state *types.GroundTruthState) *types.GroundTruthState {
+	step := state.Step + 1
+	first := p.priceAt(0)
+
+	newState := *state
+	newState.Step = step
+	newState.CurrentPrice = p.priceAt(step)
+	newState.LastReturn = math.Log(newState.CurrentPrice / state.CurrentPrice)
+	newState.CumulativeReturn = math.Log(newState.CurrentPrice / first)
+	return &newState
+}
+
+// Price of the given epoch, looping back to the offset or holding the last price when exhausted
+func (p *historicalProcess) priceAt(step int64) float64 {
+	offset := p.config.GroundTruth.StartOffset
+	idx := offset + step
+	if idx >= int64(len(p.prices)) {
+		if p.config.GroundTruth.Loop {
+			idx = offset + (idx-offset)%(int64(len(p.prices))-offset)
+		} else {
+			idx = int64(len(p.prices)) - 1
+		}
+	}
+	return p.prices[idx]
+}
@@ -0,0 +1,247 @@
+package research
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/allora-network/allora-simulator/types"
+)
+
+func TestNewGroundTruthProcess(t *testing.T) {
+	tests := []struct {
+		name        string
+		groundTruth types.GroundTruthConfig
+		expected    string
+		expectErr   bool
+	}{
+		{
+			name:        "Default is random walk",
+			groundTruth: types.GroundTruthConfig{},
+			expected:    GroundTruthRandomWalk,
+		},
+		{
+			name:        "Ornstein-Uhlenbeck",
+			groundTruth: types.GroundTruthConfig{Process: GroundTruthOrnsteinUhlenbeck, MeanReversion: 0.2},
+			expected:    GroundTruthOrnsteinUhlenbeck,
+		},
+		{
+			name:        "Ornstein-Uhlenbeck without mean reversion",
+			groundTruth: types.GroundTruthConfig{Process: GroundTruthOrnsteinUhlenbeck},
+			expectErr:   true,
+		},
+		{
+			name:        "Non stationary GARCH",
+			groundTruth: types.GroundTruthConfig{Process: GroundTruthGarch, GarchOmega: 0.0001, GarchAlpha: 0.5, GarchBeta: 0.5},
+			expectErr:   true,
+		},
+		{
+			name:        "Step without period",
+			groundTruth: types.GroundTruthConfig{Process: GroundTruthStep, Amplitude: 0.1},
+			expectErr:   true,
+		},
+		{
+			name:        "Unknown process",
+			groundTruth: types.GroundTruthConfig{Process: "brownian_bridge"},
+			expectErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &types.ResearchConfig{InitialPrice: 100, Volatility: 0.1, GroundTruth: tt.groundTruth}
+			process, err := NewGroundTruthProcess(config)
+			if tt.expectErr {
+				if err == nil {
+					t.Errorf("NewGroundTruthProcess(%q) expected an error", tt.groundTruth.Process)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewGroundTruthProcess(%q) unexpected error: %v", tt.groundTruth.Process, err)
+			}
+			if process.Name() != tt.expected {
+				t.Errorf("NewGroundTruthProcess(%q) = %s, want %s", tt.groundTruth.Process, process.Name(), tt.expected)
+			}
+		})
+	}
+}
+
+func TestDeterministicGroundTruth(t *testing.T) {
+	config := &types.ResearchConfig{
+		InitialPrice: 100,
+		GroundTruth: types.GroundTruthConfig{
+			Process:   GroundTruthSine,
+			Amplitude: 0.1,
+			Period:    4,
+		},
+	}
+
+	process, err := NewGroundTruthProcess(config)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// sin(2*pi*step/4) over one period: 1, 0, -1, 0
+	expected := []float64{110, 100, 90, 100}
+	state := NewGroundTruthState(config)
+	for i, want := range expected {
+		state = process.Next(state)
+		if !almostEqual(state.CurrentPrice, want, 0.0001) {
+			t.Errorf("step %d: price = %v, want %v", i+1, state.CurrentPrice, want)
+		}
+	}
+
+	config.GroundTruth.Process = GroundTruthStep
+	process, err = NewGroundTruthProcess(config)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Shifted for steps 4 to 7, back to the initial price from step 8
+	expected = []float64{100, 100, 100, 110, 110, 110, 110, 100}
+	state = NewGroundTruthState(config)
+	for i, want := range expected {
+		state = process.Next(state)
+		if !almostEqual(state.CurrentPrice, want, 0.0001) {
+			t.Errorf("step %d: price = %v, want %v", i+1, state.CurrentPrice, want)
+		}
+	}
+}
+
+func TestOrnsteinUhlenbeckRevertsToLongRunPrice(t *testing.T) {
+	config := &types.ResearchConfig{
+		InitialPrice: 100,
+		Volatility:   0,
+		GroundTruth: types.GroundTruthConfig{
+			Process:       GroundTruthOrnsteinUhlenbeck,
+			MeanReversion: 0.5,
+			LongRunPrice:  200,
+		},
+	}
+
+	process, err := NewGroundTruthProcess(config)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Without noise the log distance to the long run level halves every step
+	state := NewGroundTruthState(config)
+	for i := 0; i < 30; i++ {
+		state = process.Next(state)
+	}
+	if !almostEqual(state.CurrentPrice, 200, 0.0001) {
+		t.Errorf("price after 30 steps = %v, want 200", state.CurrentPrice)
+	}
+}
+
+func TestGarchVarianceRecursion(t *testing.T) {
+	config := &types.ResearchConfig{
+		InitialPrice: 100,
+		Volatility:   0.1,
+		GroundTruth: types.GroundTruthConfig{
+			Process:    GroundTruthGarch,
+			GarchOmega: 0.001,
+			GarchAlpha: 0.1,
+			GarchBeta:  0.8,
+		},
+	}
+
+	process, err := NewGroundTruthProcess(config)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	state := NewGroundTruthState(config)
+	state.LastReturn = 0.2
+	next := process.Next(state)
+
+	// 0.001 + 0.1 * 0.2^2 + 0.8 * 0.1^2
+	if !almostEqual(next.Variance, 0.013, 1e-9) {
+		t.Errorf("variance = %v, want 0.013", next.Variance)
+	}
+	if !almostEqual(next.CurrentPrice, 100*math.Exp(next.CumulativeReturn), 1e-9) {
+		t.Errorf("price %v does not match cumulative return %v", next.CurrentPrice, next.CumulativeReturn)
+	}
+}
+
+func TestHistoricalGroundTruth(t *testing.T) {
+	// 12:00 and 13:00 are missing and carried forward from 11:00 when resampling hourly
+	csvSeries := `timestamp,price
+2024-01-01T10:00:00Z,10
+2024-01-01T11:00:00Z,20
+2024-01-01T14:00:00Z,40
+`
+	path := filepath.Join(t.TempDir(), "prices.csv")
+	if err := os.WriteFile(path, []byte(csvSeries), 0o600); err != nil {
+		t.Fatalf("failed to write price series: %v", err)
+	}
+
+	config := &types.ResearchConfig{
+		GroundTruth: types.GroundTruthConfig{
+			Process:            GroundTruthHistorical,
+			HistoricalFile:     path,
+			HistoricalInterval: "1h",
+			StartOffset:        1,
+			Scale:              0.5,
+			Loop:               true,
+		},
+	}
+
+	process, err := NewGroundTruthProcess(config)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Resampled series is 10, 20, 20, 20, 40, replayed from offset 1 and scaled by 0.5
+	state := process.InitialState()
+	if state.CurrentPrice != 10 {
+		t.Errorf("initial price = %v, want 10", state.CurrentPrice)
+	}
+	expected := []float64{10, 10, 20, 10, 10}
+	for i, want := range expected {
+		state = process.Next(state)
+		if !almostEqual(state.CurrentPrice, want, 1e-9) {
+			t.Errorf("step %d: price = %v, want %v", i+1, state.CurrentPrice, want)
+		}
+	}
+
+	config.GroundTruth.Loop = false
+	process, err = NewGroundTruthProcess(config)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Without looping the last price is held
+	state = process.InitialState()
+	for i := 0; i < 10; i++ {
+		state = process.Next(state)
+	}
+	if state.CurrentPrice != 20 {
+		t.Errorf("price after the end of the series = %v, want 20", state.CurrentPrice)
+	}
+}
+
+func TestLoadJSONPriceSeries(t *testing.T) {
+	jsonSeries := `[
+	{"timestamp": 1704103200, "price": 2},
+	{"timestamp": "2024-01-01T09:00:00Z", "price": "1.5"}
+]`
+	path := filepath.Join(t.TempDir(), "prices.json")
+	if err := os.WriteFile(path, []byte(jsonSeries), 0o600); err != nil {
+		t.Fatalf("failed to write price series: %v", err)
+	}
+
+	points, err := LoadPriceSeries(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(points) != 2 {
+		t.Fatalf("loaded %d points, want 2", len(points))
+	}
+	// Points are sorted by timestamp
+	if points[0].Price != 1.5 || points[1].Price != 2 {
+		t.Errorf("prices = [%v %v], want [1.5 2]", points[0].Price, points[1].Price)
+	}
+}
@@ -0,0 +1,151 @@
+package research
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PricePoint is a single observation of a historical price series
+type PricePoint struct {
+	Timestamp time.Time
+	Price     float64
+}
+
+type jsonPricePoint struct {
+	Timestamp json.RawMessage `json:"timestamp"`
+	Price     json.Number     `json:"price"`
+}
+
+// LoadPriceSeries reads a (timestamp, price) series from a CSV or JSON file, sorted by timestamp.
+// Timestamps can be unix seconds or RFC3339 strings.
+func LoadPriceSeries(path string) ([]PricePoint, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open price series: %w", err)
+	}
+	defer file.Close()
+
+	var points []PricePoint
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		points, err = parseCSVPriceSeries(file)
+	case ".json":
+		points, err = parseJSONPriceSeries(file)
+	default:
+		return nil, fmt.Errorf("unsupported price series format: %s", path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(points) == 0 {
+		return nil, fmt.Errorf("price series %s is empty", path)
+	}
+
+	sort.SliceStable(points, func(i, j int) bool {
+		return points[i].Timestamp.Before(points[j].Timestamp)
+	})
+	return points, nil
+}
+
+func parseCSVPriceSeries(r io.Reader) ([]PricePoint, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read csv price series: %w", err)
+	}
+
+	points := make([]PricePoint, 0, len(records))
+	for i, record := range records {
+		if len(record) < 2 {
+			return nil, fmt.Errorf("csv row %d: expected timestamp and price columns", i+1)
+		}
+		// Skip the header row if there is one
+		if i == 0 && strings.EqualFold(strings.TrimSpace(record[0]), "timestamp") {
+			continue
+		}
+
+		timestamp, err := parseTimestamp(record[0])
+		if err != nil {
+			return nil, fmt.Errorf("csv row %d: %w", i+1, err)
+		}
+		price, err := strconv.ParseFloat(strings.TrimSpace(record[1]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("csv row %d: invalid price: %w", i+1, err)
+		}
+		points = append(points, PricePoint{Timestamp: timestamp, Price: price})
+	}
+	return points, nil
+}
+
+func parseJSONPriceSeries(r io.Reader) ([]PricePoint, error) {
+	var raw []jsonPricePoint
+	decoder := json.NewDecoder(r)
+	decoder.UseNumber()
+	if err := decoder.Decode(&raw); err != nil {
+		return nil, fmt.Errorf("failed to decode json price series: %w", err)
+	}
+
+	points := make([]PricePoint, 0, len(raw))
+	for i, point := range raw {
+		// Timestamps can be either numbers or strings
+		timestampStr := strings.Trim(string(point.Timestamp), `"`)
+		timestamp, err := parseTimestamp(timestampStr)
+		if err != nil {
+			return nil, fmt.Errorf("json entry %d: %w", i, err)
+		}
+		price, err := point.Price.Float64()
+		if err != nil {
+			return nil, fmt.Errorf("json entry %d: invalid price: %w", i, err)
+		}
+		points = append(points, PricePoint{Timestamp: timestamp, Price: price})
+	}
+	return points, nil
+}
+
+func parseTimestamp(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(seconds, 0).UTC(), nil
+	}
+	timestamp, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid timestamp %q: expected unix seconds or RFC3339", value)
+	}
+	return timestamp, nil
+}
+
+// ResamplePriceSeries returns one price per interval, carrying the last observation forward.
+// A zero interval keeps one price per observation.
+func ResamplePriceSeries(points []PricePoint, interval time.Duration) []float64 {
+	if interval <= 0 {
+		prices := make([]float64, len(points))
+		for i, point := range points {
+			prices[i] = point.Price
+		}
+		return prices
+	}
+
+	start := points[0].Timestamp
+	end := points[len(points)-1].Timestamp
+	prices := make([]float64, 0, int(end.Sub(start)/interval)+1)
+
+	idx := 0
+	for t := start; !t.After(end); t = t.Add(interval) {
+		for idx+1 < len(points) && !points[idx+1].Timestamp.After(t) {
+			idx++
+		}
+		prices = append(prices, points[idx].Price)
+	}
+	return prices
+}
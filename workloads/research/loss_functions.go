@@ -0,0 +1,107 @@
+package research
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+const (
+	LossMethodMSE       = "mse"
+	LossMethodMAE       = "mae"
+	LossMethodHuber     = "huber"
+	LossMethodLogCosh   = "logcosh"
+	LossMethodMAPE      = "mape"
+	LossMethodDirection = "direction"
+)
+
+const (
+	// Transition point between the quadratic and linear parts of the Huber loss
+	huberDelta = 1.0
+	// Smallest loss reported, losses are perturbed in log space so they must stay positive
+	minLoss = 1e-12
+)
+
+// LossFunction computes the loss of a single prediction against the observed value
+type LossFunction func(yObs, yPred float64) float64
+
+var lossFunctions = map[string]LossFunction{
+	LossMethodMSE:     LossMSE,
+	LossMethodMAE:     LossMAE,
+	LossMethodHuber:   LossHuber,
+	LossMethodLogCosh: LossLogCosh,
+	"log_cosh":        LossLogCosh,
+	LossMethodMAPE:    LossMAPE,
+}
+
+// Loss functions scoring the move from the previous ground truth rather than the value
+var moveLossFunctions = map[string]func(previous float64) LossFunction{
+	LossMethodDirection: LossDirection,
+	"sign":              LossDirection,
+}
+
+// GetLossFunction returns the loss function matching a topic loss method, in an epoch whose ground truth
+// moved from previous. Only the direction loss depends on previous.
+func GetLossFunction(lossMethod string, previous float64) (LossFunction, error) {
+	method := strings.ToLower(strings.TrimSpace(lossMethod))
+	if moveLossFn, ok := moveLossFunctions[method]; ok {
+		return moveLossFn(previous), nil
+	}
+	lossFn, ok := lossFunctions[method]
+	if !ok {
+		return nil, fmt.Errorf("unsupported loss method: %s", lossMethod)
+	}
+	return lossFn, nil
+}
+
+// LossMSE calculates Mean Squared Error between observed and predicted values
+func LossMSE(yObs, yPred float64) float64 {
+	return math.Pow(yObs-yPred, 2)
+}
+
+// LossMAE calculates Mean Absolute Error between observed and predicted values
+func LossMAE(yObs, yPred float64) float64 {
+	return math.Abs(yObs - yPred)
+}
+
+// LossHuber is quadratic for small errors and linear for large ones
+func LossHuber(yObs, yPred float64) float64 {
+	diff := math.Abs(yObs - yPred)
+	if diff <= huberDelta {
+		return 0.5 * diff * diff
+	}
+	return huberDelta * (diff - 0.5*huberDelta)
+}
+
+// LossLogCosh calculates log(cosh(error)) in a way that does not overflow for large errors
+func LossLogCosh(yObs, yPred float64) float64 {
+	diff := math.Abs(yObs - yPred)
+	// log(cosh(x)) = x + log(1 + e^(-2x)) - log(2)
+	return diff + math.Log1p(math.Exp(-2*diff)) - math.Ln2
+}
+
+// LossMAPE calculates the absolute percentage error of the prediction
+func LossMAPE(yObs, yPred float64) float64 {
+	denominator := math.Abs(yObs)
+	if denominator < minLoss {
+		denominator = minLoss
+	}
+	return math.Abs(yObs-yPred) / denominator
+}
+
+// LossDirection is 0 when the prediction moves from previous in the same direction as the observed value
+// and 1 otherwise. Prices are scored against the previous ground truth, with a previous of 0 it compares
+// the signs of values such as returns.
+func LossDirection(previous float64) LossFunction {
+	return func(yObs, yPred float64) float64 {
+		if math.Signbit(yObs-previous) == math.Signbit(yPred-previous) {
+			return 0
+		}
+		return 1
+	}
+}
+
+// GetLosses calculates the loss between observed and predicted values
+func GetLosses(lossFn LossFunction, yObs, yPred float64) float64 {
+	return math.Max(lossFn(yObs, yPred), minLoss)
+}
@@ -0,0 +1,94 @@
+package research
+
+import (
+	"math"
+	"testing"
+
+	alloramath "github.com/allora-network/allora-chain/math"
+	emissionstypes "github.com/allora-network/allora-chain/x/emissions/types"
+	"github.com/allora-network/allora-simulator/types"
+)
+
+func TestLossFunctions(t *testing.T) {
+	tests := []struct {
+		name     string
+		lossFn   LossFunction
+		yObs     float64
+		yPred    float64
+		expected float64
+	}{
+		{name: "MSE", lossFn: LossMSE, yObs: 3, yPred: 1, expected: 4},
+		{name: "MSE exact", lossFn: LossMSE, yObs: 3, yPred: 3, expected: 0},
+		{name: "MAE", lossFn: LossMAE, yObs: 1, yPred: 3.5, expected: 2.5},
+		{name: "Huber quadratic", lossFn: LossHuber, yObs: 1, yPred: 1.5, expected: 0.125},
+		{name: "Huber linear", lossFn: LossHuber, yObs: 1, yPred: 4, expected: 2.5},
+		{name: "Log-cosh small", lossFn: LossLogCosh, yObs: 0, yPred: 0.5, expected: math.Log(math.Cosh(0.5))},
+		{name: "Log-cosh large", lossFn: LossLogCosh, yObs: 0, yPred: 1000, expected: 1000 - math.Ln2},
+		{name: "MAPE", lossFn: LossMAPE, yObs: 200, yPred: 150, expected: 0.25},
+		{name: "MAPE negative", lossFn: LossMAPE, yObs: -4, yPred: -5, expected: 0.25},
+		{name: "Direction match", lossFn: LossDirection(0), yObs: 0.02, yPred: 0.5, expected: 0},
+		{name: "Direction mismatch", lossFn: LossDirection(0), yObs: -0.02, yPred: 0.01, expected: 1},
+		{name: "Direction of a price match", lossFn: LossDirection(100), yObs: 101, yPred: 100.5, expected: 0},
+		{name: "Direction of a price mismatch", lossFn: LossDirection(100), yObs: 101, yPred: 99, expected: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := tt.lossFn(tt.yObs, tt.yPred)
+			if !almostEqual(result, tt.expected, 1e-9) {
+				t.Errorf("%s(%v, %v) = %v, want %v", tt.name, tt.yObs, tt.yPred, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestGetLossFunction(t *testing.T) {
+	for _, method := range []string{"mse", "MAE", "huber", "logcosh", "log_cosh", "mape", "direction", "sign"} {
+		if _, err := GetLossFunction(method, 0); err != nil {
+			t.Errorf("GetLossFunction(%q) unexpected error: %v", method, err)
+		}
+	}
+
+	if _, err := GetLossFunction("zptae", 0); err == nil {
+		t.Errorf("GetLossFunction(%q) expected an error", "zptae")
+	}
+}
+
+func TestDirectionLossOfPrices(t *testing.T) {
+	// Prices are always positive, the direction of their moves still separates the predictions
+	state := applyReturn(&types.ResearchConfig{InitialPrice: 100}, NewGroundTruthState(&types.ResearchConfig{InitialPrice: 100}), 0.01)
+	lossFn, err := GetLossFunction(LossMethodDirection, PreviousPrice(state))
+	if err != nil {
+		t.Fatal(err)
+	}
+	up := GetLosses(lossFn, state.CurrentPrice, 100.5)
+	down := GetLosses(lossFn, state.CurrentPrice, 99.5)
+	if up >= down {
+		t.Errorf("expected a prediction of the rise to lose less than one of a fall, got %v and %v", up, down)
+	}
+}
+
+func TestGetLossesIsPositive(t *testing.T) {
+	// A perfect prediction still yields a loss that can be perturbed in log space
+	loss := GetLosses(LossMSE, 1, 1)
+	if loss <= 0 || math.IsInf(math.Log10(loss), 0) {
+		t.Errorf("GetLosses on a perfect prediction = %v, want a small positive loss", loss)
+	}
+}
+
+func TestGetMeanLoss(t *testing.T) {
+	yObs := []LossObs{{Loss: 1}, {Loss: 4}}
+	yPred := []*emissionstypes.ForecastElement{
+		{Value: alloramath.MustNewDecFromString("2")},
+		{Value: alloramath.MustNewDecFromString("1")},
+	}
+
+	// MAE: (1 + 3) / 2
+	if result := GetMeanLoss(LossMAE, yObs, yPred); !almostEqual(result, 2, 1e-9) {
+		t.Errorf("GetMeanLoss(MAE) = %v, want 2", result)
+	}
+	// MSE: (1 + 9) / 2
+	if result := GetMeanLoss(LossMSE, yObs, yPred); !almostEqual(result, 5, 1e-9) {
+		t.Errorf("GetMeanLoss(MSE) = %v, want 5", result)
+	}
+}
@@ -0,0 +1,407 @@
+package research
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	alloramath "github.com/allora-network/allora-chain/math"
+	emissionstypes "github.com/allora-network/allora-chain/x/emissions/types"
+	"github.com/allora-network/allora-simulator/types"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	OfflineWeightingMean        = "mean"
+	OfflineWeightingInverseLoss = "inverse_loss"
+
+	defaultOfflineLossEmaAlpha = 0.1
+)
+
+// RunOfflineSimulation runs the research actor models for a fixed number of epochs without a chain.
+// The network inference is approximated locally and every epoch is written to the output directory.
+func RunOfflineSimulation(config *types.Config) error {
+	offline := config.Research.Offline
+	if offline.Epochs <= 0 {
+		return fmt.Errorf("offline epochs must be positive, got %d", offline.Epochs)
+	}
+	// Fail early on an invalid weighting
+	if _, err := newLocalNetwork(offline); err != nil {
+		return err
+	}
+
+	if err := ValidateResearchArms(config.Research.Arms); err != nil {
+		return err
+	}
+	topics := GetResearchTopics(config)
+	for _, topic := range topics {
+		if err := ValidateAdversaries(topic.Config.Adversaries, topic); err != nil {
+			return err
+		}
+	}
+
+	writer, err := NewResultsWriter(getRunDir(offline.OutputDir, "offline"))
+	if err != nil {
+		return err
+	}
+	if err := runOfflineTopics(config, writer, topics); err != nil {
+		writer.Close()
+		return err
+	}
+	log.Info().Msgf("Offline simulation results written to %s", writer.Dir())
+	return writer.Close()
+}
+
+func runOfflineTopics(config *types.Config, writer *ResultsWriter, topics []ResearchTopic) error {
+	offline := config.Research.Offline
+	actors := newOfflineActors(GetNumActors(topics, config.Research.SharedActors))
+	data := NewResearchSimulationData(nil, topics[0].Config.Topic.EpochLength, actors)
+	topicActors := AssignTopicActors(actors, topics, config.Research.SharedActors)
+
+	for i, topic := range topics {
+		// Offline topics are numbered from 1 like on a fresh chain
+		topicId := uint64(i + 1)
+		data.AddTopic(topicId, topic.Config)
+		registerOfflineActors(data, topicId, topic.Config, topicActors[i])
+		if topic.Arm > 0 {
+			// Arms replay the actors and the ground truth of the first arm of their topic
+			first := i - topic.Arm
+			cloneArmParams(data, uint64(first+1), topicActors[first], topicId, topicActors[i])
+			if err := data.ShareGroundTruth(uint64(first+1), topicId); err != nil {
+				return err
+			}
+		}
+		if err := writer.WriteTopic(topicId, topic); err != nil {
+			return err
+		}
+		if err := writer.WriteActors(data, topicId); err != nil {
+			return err
+		}
+
+		log.Info().Msgf("Running %d offline epochs for topic %d (%s)", offline.Epochs, topicId, topic.Name)
+		if err := runOfflineTopic(data, writer, topicId, offline); err != nil {
+			return fmt.Errorf("offline simulation failed for topic %d: %w", topicId, err)
+		}
+	}
+	return nil
+}
+
+// Offline actors never sign anything, their name doubles as their address
+func newOfflineActors(numActors int) []*types.Actor {
+	actors := make([]*types.Actor, numActors)
+	for i := range actors {
+		name := types.GetActorName(i)
+		actors[i] = &types.Actor{
+			Name: name,
+			Addr: name,
+		}
+	}
+	return actors
+}
+
+func registerOfflineActors(data *ResearchSimulationData, topicId uint64, config *types.ResearchConfig, actors TopicActors) {
+	adversaries := config.Adversaries
+	for i, inferer := range actors.Inferers {
+		data.SetResearchParams(topicId, inferer.Addr, applyArchetype(InitializeWorkerResearchParams(config.Volatility), adversaries, ActorTypeInferer, i))
+		if i == adversaries.TargetInferer {
+			data.SetTargetInferer(topicId, inferer.Addr)
+		}
+		data.AddInfererRegistration(topicId, inferer)
+	}
+	for i, forecaster := range actors.Forecasters {
+		data.SetResearchParams(topicId, forecaster.Addr, applyArchetype(InitializeWorkerResearchParams(config.Volatility), adversaries, ActorTypeForecaster, i))
+		data.AddForecasterRegistration(topicId, forecaster)
+	}
+	for i, reputer := range actors.Reputers {
+		data.SetResearchParams(topicId, reputer.Addr, applyArchetype(InitializeReputerResearchParams(), adversaries, ActorTypeReputer, i))
+		data.AddReputerRegistration(topicId, reputer)
+	}
+}
+
+func runOfflineTopic(
+	data *ResearchSimulationData,
+	writer *ResultsWriter,
+	topicId uint64,
+	offline types.OfflineConfig,
+) error {
+	topicConfig := data.GetTopicConfig(topicId)
+	groundTruth, err := data.GetGroundTruthStream(topicId)
+	if err != nil {
+		return err
+	}
+	network, err := newLocalNetwork(offline)
+	if err != nil {
+		return err
+	}
+
+	for epoch := int64(0); epoch < int64(offline.Epochs); epoch++ {
+		key := EpochKey{
+			TopicId:     topicId,
+			Epoch:       epoch,
+			BlockHeight: (epoch + 1) * topicConfig.Topic.EpochLength,
+		}
+		groundTruthState := groundTruth.At(epoch)
+
+		// Workers predict this epoch's ground truth
+		data.GenerateInfererSimulatedValuesForNextEpoch(topicConfig, topicId, epoch, groundTruthState)
+		data.GenerateForecasterSimulatedValuesForNextEpoch(topicConfig, topicId, epoch, groundTruthState)
+		inferences := data.GetInfererSimulatedValues(topicId)
+		forecasts := data.GetForecasterSimulatedValues(topicId)
+
+		networkInferences, err := network.networkInferences(inferences, forecasts)
+		if err != nil {
+			return err
+		}
+
+		// Reputers score the network against the same ground truth
+		lossFn, err := GetLossFunction(topicConfig.Topic.LossMethod, PreviousPrice(groundTruthState))
+		if err != nil {
+			return err
+		}
+		reputerLosses := make([]*emissionstypes.InputValueBundle, 0)
+		for _, reputer := range data.byIndex(topicId, data.GetReputersForTopic(topicId)) {
+			params := data.GetResearchParams(topicId, reputer.Addr)
+			noise := data.actorNoise(topicId, epoch, ActorTypeReputer, params)
+			if !submitsThisEpoch(noise, topicConfig.Adversaries, params) {
+				continue
+			}
+			losses, err := GetReputerOutput(noise, lossFn, groundTruthState.CurrentPrice, networkInferences, params.Error, params.Bias)
+			if err != nil {
+				return err
+			}
+			if err := writer.WriteLosses(key, reputer.Addr, &losses); err != nil {
+				return err
+			}
+			reputerLosses = append(reputerLosses, &losses)
+		}
+		network.update(reputerLosses)
+		if combined, err := strconv.ParseFloat(networkInferences.CombinedValue.String(), 64); err == nil {
+			data.SetLatestCombinedValue(topicId, combined)
+		}
+
+		if err := writer.WriteGroundTruth(key, groundTruthState); err != nil {
+			return err
+		}
+		if err := writer.WriteInferences(key, inferences, data.GetInfererOutperformer(topicId)); err != nil {
+			return err
+		}
+		if err := writer.WriteForecasts(key, forecasts); err != nil {
+			return err
+		}
+		if err := writer.WriteNetworkInferences(key, networkInferences); err != nil {
+			return err
+		}
+	}
+
+	return writer.Flush()
+}
+
+// localNetwork approximates the network inference synthesis of the chain
+type localNetwork struct {
+	weighting string
+	alpha     float64
+	// Smoothed losses reported by reputers, per inferer and forecaster
+	emaLosses map[string]float64
+}
+
+type workerValue struct {
+	worker string
+	value  float64
+}
+
+func newLocalNetwork(offline types.OfflineConfig) (*localNetwork, error) {
+	weighting := offline.Weighting
+	if weighting == "" {
+		weighting = OfflineWeightingMean
+	}
+	if weighting != OfflineWeightingMean && weighting != OfflineWeightingInverseLoss {
+		return nil, fmt.Errorf("unknown offline weighting: %s", offline.Weighting)
+	}
+
+	alpha := offline.LossEmaAlpha
+	if alpha == 0 {
+		alpha = defaultOfflineLossEmaAlpha
+	}
+	if alpha < 0 || alpha > 1 {
+		return nil, fmt.Errorf("loss_ema_alpha must be in (0, 1], got %f", offline.LossEmaAlpha)
+	}
+
+	return &localNetwork{
+		weighting: weighting,
+		alpha:     alpha,
+		emaLosses: make(map[string]float64),
+	}, nil
+}
+
+// Weighted average of the values. Workers without loss history get the average weight of the others.
+func (n *localNetwork) combine(values []workerValue) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+
+	weights := make([]float64, len(values))
+	knownWeight, numKnown := 0.0, 0
+	for i, v := range values {
+		weights[i] = math.NaN()
+		if n.weighting == OfflineWeightingMean {
+			weights[i] = 1
+			continue
+		}
+		if loss, ok := n.emaLosses[v.worker]; ok {
+			weights[i] = 1 / math.Max(loss, minLoss)
+			knownWeight += weights[i]
+			numKnown++
+		}
+	}
+
+	defaultWeight := 1.0
+	if numKnown > 0 {
+		defaultWeight = knownWeight / float64(numKnown)
+	}
+
+	var sum, totalWeight float64
+	for i, v := range values {
+		if math.IsNaN(weights[i]) {
+			weights[i] = defaultWeight
+		}
+		sum += weights[i] * v.value
+		totalWeight += weights[i]
+	}
+	return sum / totalWeight
+}
+
+// Inference implied by a forecaster: inferers weighted by the inverse of their forecasted loss
+func forecastImpliedValue(elements []*emissionstypes.InputForecastElement, inferences map[string]float64) (float64, bool) {
+	var sum, totalWeight float64
+	for _, element := range elements {
+		value, ok := inferences[element.Inferer]
+		if !ok {
+			continue
+		}
+		forecastedLoss, err := strconv.ParseFloat(element.Value.String(), 64)
+		if err != nil || forecastedLoss <= 0 {
+			continue
+		}
+		weight := 1 / forecastedLoss
+		sum += weight * value
+		totalWeight += weight
+	}
+	if totalWeight == 0 {
+		return 0, false
+	}
+	return sum / totalWeight, true
+}
+
+// Build the value bundle reputers score, as the chain would return it from its network inferences query
+func (n *localNetwork) networkInferences(
+	inferences map[string]*alloramath.BoundedExp40Dec,
+	forecasts map[string][]*emissionstypes.InputForecastElement,
+) (*emissionstypes.ValueBundle, error) {
+	infererValues := make([]workerValue, 0, len(inferences))
+	infererValueByAddr := make(map[string]float64, len(inferences))
+	for _, inferer := range sortedKeys(inferences) {
+		value, err := strconv.ParseFloat(inferences[inferer].String(), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid inference of %s: %w", inferer, err)
+		}
+		infererValues = append(infererValues, workerValue{worker: inferer, value: value})
+		infererValueByAddr[inferer] = value
+	}
+
+	forecasterValues := make([]workerValue, 0, len(forecasts))
+	for _, forecaster := range sortedKeys(forecasts) {
+		if value, ok := forecastImpliedValue(forecasts[forecaster], infererValueByAddr); ok {
+			forecasterValues = append(forecasterValues, workerValue{worker: forecaster, value: value})
+		}
+	}
+
+	allValues := append(append([]workerValue{}, infererValues...), forecasterValues...)
+	without := func(worker string) []workerValue {
+		values := make([]workerValue, 0, len(allValues))
+		for _, v := range allValues {
+			if v.worker != worker {
+				values = append(values, v)
+			}
+		}
+		return values
+	}
+
+	var err error
+	vb := &emissionstypes.ValueBundle{}
+	if vb.CombinedValue, err = toDec(n.combine(allValues)); err != nil {
+		return nil, err
+	}
+	if vb.NaiveValue, err = toDec(n.combine(infererValues)); err != nil {
+		return nil, err
+	}
+
+	for _, v := range infererValues {
+		value, err := toDec(v.value)
+		if err != nil {
+			return nil, err
+		}
+		vb.InfererValues = append(vb.InfererValues, &emissionstypes.WorkerAttributedValue{Worker: v.worker, Value: value})
+
+		oneOut, err := toDec(n.combine(without(v.worker)))
+		if err != nil {
+			return nil, err
+		}
+		vb.OneOutInfererValues = append(vb.OneOutInfererValues, &emissionstypes.WithheldWorkerAttributedValue{Worker: v.worker, Value: oneOut})
+	}
+
+	for _, v := range forecasterValues {
+		value, err := toDec(v.value)
+		if err != nil {
+			return nil, err
+		}
+		vb.ForecasterValues = append(vb.ForecasterValues, &emissionstypes.WorkerAttributedValue{Worker: v.worker, Value: value})
+
+		oneOut, err := toDec(n.combine(without(v.worker)))
+		if err != nil {
+			return nil, err
+		}
+		vb.OneOutForecasterValues = append(vb.OneOutForecasterValues, &emissionstypes.WithheldWorkerAttributedValue{Worker: v.worker, Value: oneOut})
+
+		oneIn, err := toDec(n.combine(append(append([]workerValue{}, infererValues...), v)))
+		if err != nil {
+			return nil, err
+		}
+		vb.OneInForecasterValues = append(vb.OneInForecasterValues, &emissionstypes.WorkerAttributedValue{Worker: v.worker, Value: oneIn})
+	}
+
+	return vb, nil
+}
+
+// Update the smoothed losses with the mean loss reported by reputers this epoch
+func (n *localNetwork) update(reputerLosses []*emissionstypes.InputValueBundle) {
+	sums := make(map[string]float64)
+	counts := make(map[string]int)
+	for _, losses := range reputerLosses {
+		for _, values := range [][]*emissionstypes.InputWorkerAttributedValue{losses.InfererValues, losses.ForecasterValues} {
+			for _, v := range values {
+				loss, err := strconv.ParseFloat(v.Value.String(), 64)
+				if err != nil {
+					continue
+				}
+				sums[v.Worker] += loss
+				counts[v.Worker]++
+			}
+		}
+	}
+
+	for worker, sum := range sums {
+		loss := sum / float64(counts[worker])
+		if previous, ok := n.emaLosses[worker]; ok {
+			loss = n.alpha*loss + (1-n.alpha)*previous
+		}
+		n.emaLosses[worker] = loss
+	}
+}
+
+func toDec(value float64) (alloramath.Dec, error) {
+	dec, err := alloramath.NewDecFromString(formatFloat(value))
+	if err != nil {
+		return alloramath.Dec{}, fmt.Errorf("invalid network value %f: %w", value, err)
+	}
+	return dec, nil
+}
@@ -0,0 +1,67 @@
+package research
+
+import (
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/allora-network/allora-simulator/types"
+)
+
+func TestRunOfflineSimulation(t *testing.T) {
+	outputDir := t.TempDir()
+	config := &types.Config{
+		InferersPerTopic:    3,
+		ForecastersPerTopic: 2,
+		ReputersPerTopic:    2,
+		Research: types.ResearchConfig{
+			InitialPrice:         100,
+			Volatility:           0.05,
+			BaseExperienceFactor: 0.1,
+			ExperienceGrowth:     0.05,
+			Topic:                types.TopicConfig{LossMethod: LossMethodMSE, EpochLength: 12},
+			Offline: types.OfflineConfig{
+				Epochs:    5,
+				Weighting: OfflineWeightingInverseLoss,
+				OutputDir: outputDir,
+			},
+		},
+	}
+
+	if err := RunOfflineSimulation(config); err != nil {
+		t.Fatalf("RunOfflineSimulation unexpected error: %v", err)
+	}
+
+	runDirs, err := filepath.Glob(filepath.Join(outputDir, "offline_*"))
+	if err != nil || len(runDirs) != 1 {
+		t.Fatalf("found run directories %v, want exactly one", runDirs)
+	}
+
+	// Header plus one row per epoch, inferer and reputer
+	expectedRows := map[string]int{
+		GroundTruthFile:       1 + 5,
+		InferencesFile:        1 + 5*3,
+		NetworkInferencesFile: 1 + 5,
+		ActorsFile:            1 + 3 + 2 + 2,
+	}
+	for name, want := range expectedRows {
+		file, err := os.Open(filepath.Join(runDirs[0], name))
+		if err != nil {
+			t.Fatalf("failed to open %s: %v", name, err)
+		}
+		rows, err := csv.NewReader(file).ReadAll()
+		file.Close()
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", name, err)
+		}
+		if len(rows) != want {
+			t.Errorf("%s has %d rows, want %d", name, len(rows), want)
+		}
+	}
+
+	config.Research.Offline.Weighting = "median"
+	if err := RunOfflineSimulation(config); err == nil {
+		t.Errorf("RunOfflineSimulation with an unknown weighting expected an error")
+	}
+}
@@ -0,0 +1,177 @@
+package research
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	alloramath "github.com/allora-network/allora-chain/math"
+	emissionstypes "github.com/allora-network/allora-chain/x/emissions/types"
+	"github.com/allora-network/allora-simulator/lib"
+	"github.com/allora-network/allora-simulator/types"
+	"github.com/allora-network/allora-simulator/workloads/common"
+	"github.com/rs/zerolog/log"
+)
+
+const defaultResultsDir = "results"
+
+// Directory the results of a research run are written to, one subdirectory per run
+func GetResultsDir(config *types.Config) string {
+	return getRunDir(config.Research.OutputDir, "research")
+}
+
+// Directory the results of a parameter sweep are written to, with a subdirectory per point
+func GetSweepDir(config *types.Config) string {
+	return getRunDir(config.Research.OutputDir, "sweep")
+}
+
+func getRunDir(baseDir string, prefix string) string {
+	if baseDir == "" {
+		baseDir = defaultResultsDir
+	}
+	return filepath.Join(baseDir, prefix+"_"+time.Now().UTC().Format("20060102_150405"))
+}
+
+// Key of the epoch opened at a worker nonce. The epoch is -1 if the nonce was not acted upon by this run.
+func getEpochKey(data *ResearchSimulationData, topicId uint64, nonce int64) EpochKey {
+	key := EpochKey{TopicId: topicId, Epoch: -1, BlockHeight: nonce}
+	if record, ok := data.GetEpoch(topicId, nonce); ok {
+		key.Epoch = record.Epoch
+	}
+	return key
+}
+
+func getActorTypeName(actorType emissionstypes.ActorType) string {
+	switch actorType {
+	case emissionstypes.ActorType_ACTOR_TYPE_FORECASTER:
+		return ActorTypeForecaster
+	case emissionstypes.ActorType_ACTOR_TYPE_REPUTER:
+		return ActorTypeReputer
+	default:
+		return ActorTypeInferer
+	}
+}
+
+// Will scan every new block for the scores and rewards the chain emits once an epoch closes,
+// then poll the tracker for the actors of the closed epochs
+func runOnChainRecorder(
+	data *ResearchSimulationData,
+	config *types.Config,
+	writer *ResultsWriter,
+	topicIds []uint64,
+	stop <-chan struct{},
+) error {
+	trackedTopics := make(map[uint64]bool, len(topicIds))
+	for _, topicId := range topicIds {
+		trackedTopics[topicId] = true
+	}
+	tracker := NewActorTracker(data, config)
+	lastPolledNonces := make(map[uint64]int64)
+
+	lastScannedHeight, err := lib.GetLatestBlockHeight(config)
+	if err != nil {
+		return fmt.Errorf("failed to get latest block height: %w", err)
+	}
+
+	for {
+		latestHeight, err := lib.GetLatestBlockHeight(config)
+		if err != nil {
+			log.Error().Msgf("Error getting latest block height - node availability issue?: %v", err)
+		} else {
+			for height := lastScannedHeight + 1; height <= latestHeight; height++ {
+				events, err := lib.GetEmissionsEventsAtBlock(config, height)
+				if err != nil {
+					// Retry from this height on the next tick
+					log.Error().Msgf("Error getting emissions events at height %d: %v", height, err)
+					break
+				}
+				closedEpochs, err := recordEmissionsEvents(data, writer, trackedTopics, events)
+				if err != nil {
+					return err
+				}
+				lastScannedHeight = height
+
+				for topicId, nonce := range closedEpochs {
+					if nonce <= lastPolledNonces[topicId] {
+						continue
+					}
+					lastPolledNonces[topicId] = nonce
+					values := tracker.Poll(topicId)
+					if err := writer.WriteOnChainValues(getEpochKey(data, topicId, nonce), height, values); err != nil {
+						return err
+					}
+					if epoch, ok := data.GetEpoch(topicId, nonce); ok && lastEpochReached(config, epoch.Epoch) {
+						delete(trackedTopics, topicId)
+					}
+				}
+			}
+			if err := writer.Flush(); err != nil {
+				return err
+			}
+			// Every topic had the outcomes of its last epoch recorded
+			if len(trackedTopics) == 0 {
+				return nil
+			}
+		}
+		if !common.Sleep(stop, 4*time.Second) {
+			return nil
+		}
+	}
+}
+
+// Record the scores, rewards and topic rewards of a block.
+// Returns the nonce of the epochs whose scores or rewards were set, by topic.
+func recordEmissionsEvents(
+	data *ResearchSimulationData,
+	writer *ResultsWriter,
+	trackedTopics map[uint64]bool,
+	events *lib.EmissionsEvents,
+) (map[uint64]int64, error) {
+	closedEpochs := make(map[uint64]int64)
+	write := func(topicId uint64, nonce int64, actorType, metric string, actors []string, values []alloramath.Dec) error {
+		if !trackedTopics[topicId] {
+			return nil
+		}
+		if len(actors) != len(values) {
+			return fmt.Errorf("got %d %s %s values for %d actors", len(values), actorType, metric, len(actors))
+		}
+		if nonce > closedEpochs[topicId] {
+			closedEpochs[topicId] = nonce
+		}
+
+		rows := make([]OnChainValue, len(actors))
+		for i, actor := range actors {
+			rows[i] = OnChainValue{ActorType: actorType, Metric: metric, Actor: actor, Value: values[i].String()}
+		}
+		return writer.WriteOnChainValues(getEpochKey(data, topicId, nonce), events.Height, rows)
+	}
+
+	for _, e := range events.Scores {
+		if err := write(e.TopicId, e.BlockHeight, getActorTypeName(e.ActorType), MetricScore, e.Addresses, e.Scores); err != nil {
+			return nil, err
+		}
+	}
+	for _, e := range events.Rewards {
+		if err := write(e.TopicId, e.BlockHeight, getActorTypeName(e.ActorType), MetricReward, e.Addresses, e.Rewards); err != nil {
+			return nil, err
+		}
+	}
+
+	// Topic emissions are not tied to an epoch of the topic, they are keyed by the block they were set at
+	for _, e := range events.TopicRewards {
+		if len(e.TopicIds) != len(e.Rewards) {
+			return nil, fmt.Errorf("got %d topic rewards for %d topics", len(e.Rewards), len(e.TopicIds))
+		}
+		for i, topicId := range e.TopicIds {
+			if !trackedTopics[topicId] {
+				continue
+			}
+			key := EpochKey{TopicId: topicId, Epoch: -1, BlockHeight: events.Height}
+			value := OnChainValue{ActorType: ActorTypeTopic, Metric: MetricTopicReward, Value: e.Rewards[i].String()}
+			if err := writer.WriteOnChainValues(key, events.Height, []OnChainValue{value}); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return closedEpochs, nil
+}
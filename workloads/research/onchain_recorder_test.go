@@ -0,0 +1,74 @@
+package research
+
+import (
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"testing"
+
+	alloramath "github.com/allora-network/allora-chain/math"
+	emissionstypes "github.com/allora-network/allora-chain/x/emissions/types"
+	"github.com/allora-network/allora-simulator/lib"
+)
+
+func TestRecordEmissionsEvents(t *testing.T) {
+	dir := t.TempDir()
+	writer, err := NewResultsWriter(dir)
+	if err != nil {
+		t.Fatalf("NewResultsWriter unexpected error: %v", err)
+	}
+
+	data := NewResearchSimulationData(nil, 10, nil)
+	data.RecordEpoch(1, 100, &EpochRecord{Epoch: 4})
+
+	events := &lib.EmissionsEvents{
+		Height: 112,
+		Rewards: []*emissionstypes.EventRewardsSettled{
+			{
+				ActorType:   emissionstypes.ActorType_ACTOR_TYPE_REPUTER,
+				TopicId:     1,
+				BlockHeight: 100,
+				Addresses:   []string{"reputer0"},
+				Rewards:     []alloramath.Dec{alloramath.MustNewDecFromString("2.5")},
+			},
+			// Topics of other runs on the same chain are ignored
+			{
+				TopicId:     7,
+				BlockHeight: 100,
+				Addresses:   []string{"inferer0"},
+				Rewards:     []alloramath.Dec{alloramath.MustNewDecFromString("1")},
+			},
+		},
+	}
+
+	closedEpochs, err := recordEmissionsEvents(data, writer, map[uint64]bool{1: true}, events)
+	if err != nil {
+		t.Fatalf("recordEmissionsEvents unexpected error: %v", err)
+	}
+	if len(closedEpochs) != 1 || closedEpochs[1] != 100 {
+		t.Errorf("closed epochs = %v, want map[1:100]", closedEpochs)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close unexpected error: %v", err)
+	}
+
+	file, err := os.Open(filepath.Join(dir, OnChainFile))
+	if err != nil {
+		t.Fatalf("failed to open %s: %v", OnChainFile, err)
+	}
+	defer file.Close()
+	rows, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", OnChainFile, err)
+	}
+
+	want := []string{"1", "4", "100", ActorTypeReputer, MetricReward, "reputer0", "", "2.5", "112"}
+	if len(rows) != 2 {
+		t.Fatalf("%s has %d rows, want 2", OnChainFile, len(rows))
+	}
+	for i, column := range want {
+		if rows[1][i] != column {
+			t.Errorf("column %s = %q, want %q", rows[0][i], rows[1][i], column)
+		}
+	}
+}
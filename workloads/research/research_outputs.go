@@ -16,6 +16,7 @@ import (
 func InitializeWorkerResearchParams(volatility float64) *types.ResearchParams {
 	params := &types.ResearchParams{
 		Volatility: volatility,
+		Index:      -1,
 	}
 
 	// errors = 10^(normal(log10(2.0*volatility), log10(1.5)))
@@ -33,7 +34,7 @@ func InitializeWorkerResearchParams(volatility float64) *types.ResearchParams {
 
 // InitializeReputerResearchParams generates research parameters for reputers
 func InitializeReputerResearchParams() *types.ResearchParams {
-	params := &types.ResearchParams{}
+	params := &types.ResearchParams{Index: -1}
 
 	// logErrors = 10^(normal(log10(0.1), log10(1.25)))
 	params.Error = math.Pow(10, rand.NormFloat64()*math.Log10(1.25)+math.Log10(0.1))
@@ -72,8 +73,14 @@ func GetNextGroundTruth(state *types.GroundTruthState, initialPrice float64, dri
 	return newState
 }
 
+// FreshNoise returns noise that no other draw replays
+func FreshNoise() *rand.Rand {
+	return rand.New(rand.NewSource(rand.Int63()))
+}
+
 // Generates inferer output
 func GetInfererOutput(
+	noise *rand.Rand,
 	config *types.ResearchConfig,
 	groundTruth float64,
 	error float64,
@@ -89,7 +96,7 @@ func GetInfererOutput(
 	adjustedBias := factor * xp * bias
 
 	// Generate random normal difference
-	difference := rand.NormFloat64()*adjustedError + adjustedBias
+	difference := noise.NormFloat64()*adjustedError + adjustedBias
 
 	// Calculate prediction
 	prediction := groundTruth + difference
@@ -99,6 +106,7 @@ func GetInfererOutput(
 
 // Generates forecaster output
 func GetForecasterOutput(
+	noise *rand.Rand,
 	config *types.ResearchConfig,
 	lossObs []LossObs,
 	logError float64,
@@ -113,7 +121,7 @@ func GetForecasterOutput(
 	forecastElements := make([]*emissionstypes.InputForecastElement, 0)
 	// Generate random log differences
 	for _, loss := range lossObs {
-		logDiff := rand.NormFloat64()*adjustedLogError + adjustedLogBias
+		logDiff := noise.NormFloat64()*adjustedLogError + adjustedLogBias
 
 		// Calculate no-outperformance loss
 		lossNoOutperformance := loss.Loss
@@ -146,7 +154,7 @@ func GetForecasterOutput(
 	return forecastElements
 }
 
-func GetReputerOutput(sourceTruth float64, vb *emissionstypes.ValueBundle, logError, logBias float64) (emissionstypes.InputValueBundle, error) {
+func GetReputerOutput(noise *rand.Rand, lossFn LossFunction, sourceTruth float64, vb *emissionstypes.ValueBundle, logError, logBias float64) (emissionstypes.InputValueBundle, error) {
 	losses := emissionstypes.InputValueBundle{
 		TopicId:             vb.TopicId,
 		ReputerRequestNonce: vb.ReputerRequestNonce,
@@ -161,10 +169,10 @@ func GetReputerOutput(sourceTruth float64, vb *emissionstypes.ValueBundle, logEr
 		}
 
 		// Calculate base loss
-		baseLoss := GetLosses(sourceTruth, valueFloat)
+		baseLoss := GetLosses(lossFn, sourceTruth, valueFloat)
 
 		// Apply log perturbation
-		logDiff := rand.NormFloat64()*logError + logBias
+		logDiff := noise.NormFloat64()*logError + logBias
 		perturbedLoss := math.Pow(10, math.Log10(baseLoss)+logDiff)
 
 		return alloramath.MustNewCappedBoundedExp40DecFromString(fmt.Sprintf("%f", perturbedLoss)), nil
@@ -252,36 +260,24 @@ func GetReputerOutput(sourceTruth float64, vb *emissionstypes.ValueBundle, logEr
 	return losses, nil
 }
 
-// LossMSE calculates Mean Squared Error between observed and predicted values
-func LossMSE(yObs, yPred float64) float64 {
-	return math.Pow(yObs-yPred, 2)
-}
-
-// GetLosses calculates the loss between observed and predicted values
-// Simplified version that only handles MSE for regression
-func GetLosses(yObs, yPred float64) float64 {
-	return LossMSE(yObs, yPred)
-}
-
 // GetMeanLoss calculates the mean loss between observed and predicted losses
 // yObs: actual losses for each predictor at time i
 // yPred: aggregator's predicted losses for each predictor at time i
-func GetMeanLoss(yObs []LossObs, yPred []*emissionstypes.ForecastElement) float64 {
+func GetMeanLoss(lossFn LossFunction, yObs []LossObs, yPred []*emissionstypes.ForecastElement) float64 {
 	if len(yObs) != len(yPred) {
 		panic("GetMeanLoss: length mismatch between observed and predicted values")
 	}
-	// Convert yPred to float64
 
 	var sumLoss float64
 	n := len(yObs)
 
 	for i := 0; i < n; i++ {
 		// Convert yPred to float64
-		output, err := strconv.ParseFloat(yPred[i].String(), 64)
+		output, err := strconv.ParseFloat(yPred[i].Value.String(), 64)
 		if err != nil {
 			panic(err)
 		}
-		sumLoss += LossMSE(yObs[i].Loss, output)
+		sumLoss += lossFn(yObs[i].Loss, output)
 	}
 
 	return sumLoss / float64(n)
@@ -1,13 +1,20 @@
 package research
 
 import (
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"math"
 	"math/rand"
+	"sort"
 	"strconv"
 	"sync"
 
 	alloramath "github.com/allora-network/allora-chain/math"
 	emissionstypes "github.com/allora-network/allora-chain/x/emissions/types"
+	"github.com/allora-network/allora-simulator/lib"
 	"github.com/allora-network/allora-simulator/types"
+	"github.com/allora-network/allora-simulator/workloads/common"
 	"github.com/rs/zerolog/log"
 )
 
@@ -24,6 +31,23 @@ type ResearchSimulationData struct {
 	InfererOutperformers         map[uint64]string
 	ForecasterSimulatedValues    map[uint64]map[string][]*emissionstypes.InputForecastElement
 	ForecasterOutperformers      map[uint64]string
+	TopicConfigs                 map[uint64]*types.ResearchConfig
+	ResearchParams               map[uint64]map[string]*types.ResearchParams
+	Epochs                       map[uint64]map[int64]*EpochRecord
+	TargetInferers               map[uint64]string             // inferer favoured by shill forecasters, per topic
+	LatestCombinedValues         map[uint64]float64            // latest network inference observed, resubmitted by copycats
+	SpareActors                  []*types.Actor                // funded actors outside the initial population, joined to topics by churn
+	Delegators                   []*types.Actor                // funded actors delegating stake to reputers
+	GasPrice                     *lib.GasPriceOracle           // gas price the actors pay, refreshed by the actor loops
+	GroundTruths                 map[uint64]*GroundTruthStream // ground truth of every topic, shared by the arms of a topic
+	Seed                         int64                         // seed of the per-epoch noise of the actors
+	NoiseTopics                  map[uint64]uint64             // topic whose noise a topic replays, the first arm of its group
+}
+
+// EpochRecord is what workers were asked to predict at a worker nonce
+type EpochRecord struct {
+	Epoch       int64
+	GroundTruth *types.GroundTruthState
 }
 
 type Registration struct {
@@ -55,6 +79,196 @@ func (s *ResearchSimulationData) AddReputerRegistration(topicId uint64, actor *t
 	s.RegisteredReputersByTopic[topicId] = append(s.RegisteredReputersByTopic[topicId], actor)
 }
 
+func (s *ResearchSimulationData) RemoveInfererRegistration(topicId uint64, actor *types.Actor) {
+	s.Mu.Lock()
+	defer s.Mu.Unlock()
+	s.RegisteredInferersByTopic[topicId] = common.RemoveActor(s.RegisteredInferersByTopic[topicId], actor.Addr)
+}
+
+func (s *ResearchSimulationData) RemoveForecasterRegistration(topicId uint64, actor *types.Actor) {
+	s.Mu.Lock()
+	defer s.Mu.Unlock()
+	s.RegisteredForecastersByTopic[topicId] = common.RemoveActor(s.RegisteredForecastersByTopic[topicId], actor.Addr)
+}
+
+func (s *ResearchSimulationData) RemoveReputerRegistration(topicId uint64, actor *types.Actor) {
+	s.Mu.Lock()
+	defer s.Mu.Unlock()
+	s.RegisteredReputersByTopic[topicId] = common.RemoveActor(s.RegisteredReputersByTopic[topicId], actor.Addr)
+}
+
+// Add the research model of a topic to the simulation data
+func (s *ResearchSimulationData) AddTopic(topicId uint64, config *types.ResearchConfig) {
+	s.Mu.Lock()
+	defer s.Mu.Unlock()
+	s.TopicConfigs[topicId] = config
+}
+
+func (s *ResearchSimulationData) GetTopicConfig(topicId uint64) *types.ResearchConfig {
+	s.Mu.RLock()
+	defer s.Mu.RUnlock()
+	return s.TopicConfigs[topicId]
+}
+
+// Epoch length of a topic, the epoch length of the simulation for topics without a research config
+func (s *ResearchSimulationData) GetEpochLength(topicId uint64) int64 {
+	s.Mu.RLock()
+	defer s.Mu.RUnlock()
+	if config := s.TopicConfigs[topicId]; config != nil && config.Topic.EpochLength > 0 {
+		return config.Topic.EpochLength
+	}
+	return s.EpochLength
+}
+
+// Set the hidden skill parameters of an actor in a topic
+func (s *ResearchSimulationData) SetResearchParams(topicId uint64, addr string, params *types.ResearchParams) {
+	s.Mu.Lock()
+	defer s.Mu.Unlock()
+	if s.ResearchParams[topicId] == nil {
+		s.ResearchParams[topicId] = make(map[string]*types.ResearchParams)
+	}
+	s.ResearchParams[topicId][addr] = params
+}
+
+func (s *ResearchSimulationData) GetResearchParams(topicId uint64, addr string) *types.ResearchParams {
+	s.Mu.RLock()
+	defer s.Mu.RUnlock()
+	return s.ResearchParams[topicId][addr]
+}
+
+// Record the epoch number and ground truth of a worker nonce, so reputers score against the same truth
+func (s *ResearchSimulationData) RecordEpoch(topicId uint64, nonce int64, record *EpochRecord) {
+	s.Mu.Lock()
+	defer s.Mu.Unlock()
+	if s.Epochs[topicId] == nil {
+		s.Epochs[topicId] = make(map[int64]*EpochRecord)
+	}
+	s.Epochs[topicId][nonce] = record
+}
+
+func (s *ResearchSimulationData) GetEpoch(topicId uint64, nonce int64) (*EpochRecord, bool) {
+	s.Mu.RLock()
+	defer s.Mu.RUnlock()
+	record, ok := s.Epochs[topicId][nonce]
+	return record, ok
+}
+
+// Ground truth of a topic, drawn from the process of its config on first use
+func (s *ResearchSimulationData) GetGroundTruthStream(topicId uint64) (*GroundTruthStream, error) {
+	s.Mu.Lock()
+	defer s.Mu.Unlock()
+	if stream, ok := s.GroundTruths[topicId]; ok {
+		return stream, nil
+	}
+	config := s.TopicConfigs[topicId]
+	if config == nil {
+		return nil, fmt.Errorf("no research config for topic: %d", topicId)
+	}
+	process, err := NewGroundTruthProcess(config)
+	if err != nil {
+		return nil, err
+	}
+	stream := NewGroundTruthStream(process)
+	s.GroundTruths[topicId] = stream
+	return stream, nil
+}
+
+// Make a topic follow the ground truth of another, so both predict the same series
+func (s *ResearchSimulationData) ShareGroundTruth(fromTopicId uint64, toTopicId uint64) error {
+	stream, err := s.GetGroundTruthStream(fromTopicId)
+	if err != nil {
+		return err
+	}
+	s.Mu.Lock()
+	defer s.Mu.Unlock()
+	s.GroundTruths[toTopicId] = stream
+	return nil
+}
+
+// Make a topic replay the per-epoch noise of another, so the actors at the same index draw the same values
+func (s *ResearchSimulationData) ShareNoise(fromTopicId uint64, toTopicId uint64) {
+	s.Mu.Lock()
+	defer s.Mu.Unlock()
+	s.NoiseTopics[toTopicId] = s.noiseTopic(fromTopicId)
+}
+
+func (s *ResearchSimulationData) noiseTopic(topicId uint64) uint64 {
+	if from, ok := s.NoiseTopics[topicId]; ok {
+		return from
+	}
+	return topicId
+}
+
+// Noise of a draw of an epoch, seeded from the topic whose noise the topic replays, the epoch, the draw and an index.
+// A negative index, such as that of an actor joining through churn, gets noise of its own.
+func (s *ResearchSimulationData) noise(topicId uint64, epoch int64, draw string, idx int) *rand.Rand {
+	if idx < 0 {
+		return FreshNoise()
+	}
+	s.Mu.RLock()
+	seed, noiseTopic := s.Seed, s.noiseTopic(topicId)
+	s.Mu.RUnlock()
+
+	h := fnv.New64a()
+	for _, v := range []uint64{uint64(seed), noiseTopic, uint64(epoch), uint64(idx)} {
+		h.Write(binary.BigEndian.AppendUint64(nil, v))
+	}
+	h.Write([]byte(draw))
+	return rand.New(rand.NewSource(int64(h.Sum64())))
+}
+
+// Noise of an actor in an epoch, actors without research params get noise of their own
+func (s *ResearchSimulationData) actorNoise(topicId uint64, epoch int64, actorType string, params *types.ResearchParams) *rand.Rand {
+	if params == nil {
+		return FreshNoise()
+	}
+	return s.noise(topicId, epoch, actorType, params.Index)
+}
+
+// Index of an actor in its role population, actors without one sort last
+func (s *ResearchSimulationData) actorIndex(topicId uint64, addr string) int {
+	if params := s.GetResearchParams(topicId, addr); params != nil && params.Index >= 0 {
+		return params.Index
+	}
+	return math.MaxInt
+}
+
+// Actors of a topic ordered by their index, the order the arms of a topic have in common
+func (s *ResearchSimulationData) byIndex(topicId uint64, actors []*types.Actor) []*types.Actor {
+	sorted := append([]*types.Actor(nil), actors...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return s.actorIndex(topicId, sorted[i].Addr) < s.actorIndex(topicId, sorted[j].Addr)
+	})
+	return sorted
+}
+
+// Set the inferer shill forecasters of a topic favour
+func (s *ResearchSimulationData) SetTargetInferer(topicId uint64, addr string) {
+	s.Mu.Lock()
+	defer s.Mu.Unlock()
+	s.TargetInferers[topicId] = addr
+}
+
+func (s *ResearchSimulationData) GetTargetInferer(topicId uint64) string {
+	s.Mu.RLock()
+	defer s.Mu.RUnlock()
+	return s.TargetInferers[topicId]
+}
+
+// Record the combined value of the latest network inference of a topic
+func (s *ResearchSimulationData) SetLatestCombinedValue(topicId uint64, value float64) {
+	s.Mu.Lock()
+	defer s.Mu.Unlock()
+	s.LatestCombinedValues[topicId] = value
+}
+
+func (s *ResearchSimulationData) GetLatestCombinedValue(topicId uint64) (float64, bool) {
+	s.Mu.RLock()
+	defer s.Mu.RUnlock()
+	value, ok := s.LatestCombinedValues[topicId]
+	return value, ok
+}
+
 func (s *ResearchSimulationData) GetActorFromAddr(addr string) (*types.Actor, bool) {
 	s.Mu.RLock()
 	defer s.Mu.RUnlock()
@@ -96,6 +310,12 @@ func (s *ResearchSimulationData) GetInfererSimulatedValue(topicId uint64, addr s
 	return s.InfererSimulatedValues[topicId][addr]
 }
 
+func (s *ResearchSimulationData) GetForecasterSimulatedValues(topicId uint64) map[string][]*emissionstypes.InputForecastElement {
+	s.Mu.RLock()
+	defer s.Mu.RUnlock()
+	return s.ForecasterSimulatedValues[topicId]
+}
+
 func (s *ResearchSimulationData) GetForecasterSimulatedValue(topicId uint64, addr string) []*emissionstypes.InputForecastElement {
 	s.Mu.RLock()
 	defer s.Mu.RUnlock()
@@ -126,50 +346,81 @@ func (s *ResearchSimulationData) SetForecasterSimulatedValues(topicId uint64, va
 	s.ForecasterSimulatedValues[topicId] = values
 }
 
-func (s *ResearchSimulationData) SetForecasterOutperformer(topicId uint64, forecasters []*types.Actor) {
+// Randomly select the outperformer of an epoch among forecasters ordered by index
+func (s *ResearchSimulationData) SetForecasterOutperformer(topicId uint64, epoch int64, forecasters []*types.Actor) {
+	outperformer := s.noise(topicId, epoch, "forecaster_outperformer", 0).Intn(len(forecasters))
+
 	s.Mu.Lock()
 	defer s.Mu.Unlock()
-
-	// Randomly select an outperformer
-	outperformer := rand.Intn(len(forecasters))
 	s.ForecasterOutperformers[topicId] = forecasters[outperformer].Addr
 }
 
-func (s *ResearchSimulationData) SetInfererOutperformer(config *types.ResearchConfig, topicId uint64, inferers []*types.Actor) {
-	s.Mu.Lock()
-	defer s.Mu.Unlock()
-
-	if config.ConsistentOutperformer {
-		// Select the first inferer as the outperformer
-		s.InfererOutperformers[topicId] = inferers[0].Addr
-	} else {
+// Select the outperformer of an epoch among inferers ordered by index
+func (s *ResearchSimulationData) SetInfererOutperformer(config *types.ResearchConfig, topicId uint64, epoch int64, inferers []*types.Actor) {
+	// Select the first inferer as the outperformer
+	outperformer := 0
+	if !config.ConsistentOutperformer {
 		// Randomly select an outperformer
-		outperformer := rand.Intn(len(inferers))
-		s.InfererOutperformers[topicId] = inferers[outperformer].Addr
+		outperformer = s.noise(topicId, epoch, "inferer_outperformer", 0).Intn(len(inferers))
 	}
+
+	s.Mu.Lock()
+	defer s.Mu.Unlock()
+	s.InfererOutperformers[topicId] = inferers[outperformer].Addr
 	log.Info().Msgf("Inferer %s is the outperformer", s.InfererOutperformers[topicId])
 }
 
 // Generate inferer simulated values for next epoch
 func (s *ResearchSimulationData) GenerateInfererSimulatedValuesForNextEpoch(config *types.ResearchConfig, topicId uint64, numberOfActiveEpochs int64, groundTruthState *types.GroundTruthState) {
-	inferers := s.GetInferersForTopic(topicId)
-	s.SetInfererOutperformer(config, topicId, inferers)
+	// The arms of a topic draw the same values for the actors at the same index
+	inferers := s.byIndex(topicId, s.GetInferersForTopic(topicId))
+	if len(inferers) == 0 {
+		// Every inferer may have left the topic through churn
+		s.SetInfererSimulatedValues(topicId, map[string]*alloramath.BoundedExp40Dec{})
+		return
+	}
+	s.SetInfererOutperformer(config, topicId, numberOfActiveEpochs, inferers)
 
 	infererSimulatedValues := map[string]*alloramath.BoundedExp40Dec{}
+	// Every member of a sybil cluster submits the value drawn for the first of them
+	sybilValues := map[int]*alloramath.BoundedExp40Dec{}
 	for _, inferer := range inferers {
 		outperformer := s.GetInfererOutperformer(topicId)
 		if inferer.Addr == outperformer {
 			log.Info().Msgf("Inferer %s is the outperformer", inferer.Addr)
 		}
+		params := s.GetResearchParams(topicId, inferer.Addr)
+		noise := s.actorNoise(topicId, numberOfActiveEpochs, ActorTypeInferer, params)
+		if !submitsThisEpoch(noise, config.Adversaries, params) {
+			continue
+		}
+		switch params.Archetype {
+		case ArchetypeCopycat:
+			// Copycats predict honestly until a network inference is known
+			if latest, ok := s.GetLatestCombinedValue(topicId); ok {
+				value := alloramath.MustNewCappedBoundedExp40DecFromString(formatFloat(latest))
+				infererSimulatedValues[inferer.Addr] = &value
+				continue
+			}
+		case ArchetypeSybil:
+			if value, ok := sybilValues[params.SybilCluster]; ok {
+				infererSimulatedValues[inferer.Addr] = value
+				continue
+			}
+		}
 		simulatedValue := GetInfererOutput(
-			&inferer.TxParams.Config.Research,
+			noise,
+			config,
 			groundTruthState.CurrentPrice,
-			inferer.ResearchParams.Error,
-			inferer.ResearchParams.Bias,
+			params.Error,
+			params.Bias,
 			int(numberOfActiveEpochs),
 			inferer.Addr == outperformer,
 		)
 		infererSimulatedValues[inferer.Addr] = &simulatedValue
+		if params.Archetype == ArchetypeSybil {
+			sybilValues[params.SybilCluster] = &simulatedValue
+		}
 	}
 	s.SetInfererSimulatedValues(topicId, infererSimulatedValues)
 }
@@ -180,17 +431,32 @@ func (s *ResearchSimulationData) GenerateForecasterSimulatedValuesForNextEpoch(
 	numberOfActiveEpochs int64,
 	groundTruthState *types.GroundTruthState,
 ) {
-	forecasters := s.GetForecastersForTopic(topicId)
+	forecasters := s.byIndex(topicId, s.GetForecastersForTopic(topicId))
 	if len(forecasters) > 0 {
-		s.SetForecasterOutperformer(topicId, forecasters)
+		s.SetForecasterOutperformer(topicId, numberOfActiveEpochs, forecasters)
+	}
+
+	lossFn, err := GetLossFunction(config.Topic.LossMethod, PreviousPrice(groundTruthState))
+	if err != nil {
+		log.Error().Err(err).Msgf("Error getting loss function for topic: %d", topicId)
+		return
 	}
 
 	// Get inferer simulated values
 	infererSimulatedValues := s.GetInfererSimulatedValues(topicId)
 
-	// Get losses
+	// Get losses, in the order of the inferers so that forecasters draw the same noise for each of them
+	addresses := make([]string, 0, len(infererSimulatedValues))
+	for address := range infererSimulatedValues {
+		addresses = append(addresses, address)
+	}
+	sort.Slice(addresses, func(i, j int) bool {
+		a, b := s.actorIndex(topicId, addresses[i]), s.actorIndex(topicId, addresses[j])
+		return a < b || (a == b && addresses[i] < addresses[j])
+	})
 	lossObs := make([]LossObs, 0)
-	for address, inferer := range infererSimulatedValues {
+	for _, address := range addresses {
+		inferer := infererSimulatedValues[address]
 		// convert inferer to float64
 		infererFloat := inferer.String()
 		// convert infererFloat to float64
@@ -199,6 +465,7 @@ func (s *ResearchSimulationData) GenerateForecasterSimulatedValuesForNextEpoch(
 			panic(err)
 		}
 		loss := GetLosses(
+			lossFn,
 			groundTruthState.CurrentPrice,
 			infererFloat64,
 		)
@@ -210,14 +477,23 @@ func (s *ResearchSimulationData) GenerateForecasterSimulatedValuesForNextEpoch(
 
 	forecasterSimulatedValues := map[string][]*emissionstypes.InputForecastElement{}
 	for _, forecaster := range forecasters {
+		params := s.GetResearchParams(topicId, forecaster.Addr)
+		noise := s.actorNoise(topicId, numberOfActiveEpochs, ActorTypeForecaster, params)
+		if !submitsThisEpoch(noise, config.Adversaries, params) {
+			continue
+		}
 		simulatedValue := GetForecasterOutput(
+			noise,
 			config,
 			lossObs,
-			forecaster.ResearchParams.Error,
-			forecaster.ResearchParams.Bias,
-			forecaster.ResearchParams.ContextSensitivity,
+			params.Error,
+			params.Bias,
+			params.ContextSensitivity,
 			int(numberOfActiveEpochs),
 		)
+		if params.Archetype == ArchetypeShill {
+			simulatedValue = favourTarget(simulatedValue, s.GetTargetInferer(topicId))
+		}
 		forecasterSimulatedValues[forecaster.Addr] = simulatedValue
 	}
 	s.SetForecasterSimulatedValues(topicId, forecasterSimulatedValues)
@@ -0,0 +1,370 @@
+package research
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+
+	alloramath "github.com/allora-network/allora-chain/math"
+	emissionstypes "github.com/allora-network/allora-chain/x/emissions/types"
+	"github.com/allora-network/allora-simulator/types"
+)
+
+// Files written by the ResultsWriter and their columns
+const (
+	GroundTruthFile       = "ground_truth.csv"
+	InferencesFile        = "inferences.csv"
+	ForecastsFile         = "forecasts.csv"
+	NetworkInferencesFile = "network_inferences.csv"
+	LossesFile            = "losses.csv"
+	OnChainFile           = "onchain.csv"
+	TopicsFile            = "topics.csv"
+	ActorsFile            = "actors.csv"
+	MembershipFile        = "membership.csv"
+)
+
+var resultsFileHeaders = map[string][]string{
+	GroundTruthFile:       {"topic_id", "epoch", "block_height", "price", "return"},
+	InferencesFile:        {"topic_id", "epoch", "block_height", "inferer", "value", "outperform"},
+	ForecastsFile:         {"topic_id", "epoch", "block_height", "forecaster", "inferer", "value"},
+	NetworkInferencesFile: {"topic_id", "epoch", "block_height", "combined_value", "naive_value"},
+	LossesFile:            {"topic_id", "epoch", "block_height", "reputer", "kind", "worker", "loss"},
+	OnChainFile:           {"topic_id", "epoch", "block_height", "actor_type", "metric", "actor", "other_actor", "value", "event_height"},
+	TopicsFile:            {"topic_id", "name", "group", "arm", "loss_method", "epoch_length", "ground_truth_process", "initial_price", "volatility"},
+	ActorsFile:            {"topic_id", "actor", "actor_type", "error", "bias", "bias_with_volatility", "context_sensitivity", "archetype", "sybil_cluster"},
+	MembershipFile:        {"topic_id", "block_height", "actor", "actor_type", "event"},
+}
+
+// Membership events of churning actors, as written in the membership file
+const (
+	MembershipJoin   = "join"
+	MembershipLeave  = "leave"
+	MembershipReturn = "return"
+)
+
+// Kinds of losses reported by reputers, as written in the losses file
+const (
+	LossKindCombined         = "combined"
+	LossKindNaive            = "naive"
+	LossKindInferer          = "inferer"
+	LossKindForecaster       = "forecaster"
+	LossKindOneOutInferer    = "one_out_inferer"
+	LossKindOneOutForecaster = "one_out_forecaster"
+	LossKindOneInForecaster  = "one_in_forecaster"
+)
+
+// Metrics queried from the chain, as written in the on-chain file
+const (
+	MetricScore                  = "score"
+	MetricEmaScore               = "ema_score"
+	MetricReward                 = "reward"
+	MetricTopicReward            = "topic_reward"
+	MetricRegret                 = "regret"
+	MetricNaiveRegret            = "naive_regret"
+	MetricOneInForecasterRegret  = "one_in_forecaster_regret"
+	MetricOneOutInfererRegret    = "one_out_inferer_regret"
+	MetricOneOutForecasterRegret = "one_out_forecaster_regret"
+	MetricStake                  = "stake"
+	MetricSelfStake              = "self_stake"
+	MetricDelegatedStake         = "delegated_stake"
+)
+
+// Actor types of the on-chain file
+const (
+	ActorTypeInferer    = "inferer"
+	ActorTypeForecaster = "forecaster"
+	ActorTypeReputer    = "reputer"
+	ActorTypeTopic      = "topic"
+)
+
+// OnChainValue is a metric the chain holds for an actor.
+// OtherActor is set for pairwise metrics such as one-in and one-out regrets.
+type OnChainValue struct {
+	ActorType  string
+	Metric     string
+	Actor      string
+	OtherActor string
+	Value      string
+}
+
+// EpochKey identifies the epoch of a topic a row belongs to
+type EpochKey struct {
+	TopicId     uint64
+	Epoch       int64
+	BlockHeight int64
+}
+
+func (k EpochKey) columns() []string {
+	return []string{
+		strconv.FormatUint(k.TopicId, 10),
+		strconv.FormatInt(k.Epoch, 10),
+		strconv.FormatInt(k.BlockHeight, 10),
+	}
+}
+
+type resultsFile struct {
+	file   *os.File
+	writer *csv.Writer
+}
+
+// ResultsWriter appends per-epoch research data to CSV files in an output directory.
+// It is safe for concurrent use by the topic loops.
+type ResultsWriter struct {
+	dir   string
+	mu    sync.Mutex
+	files map[string]*resultsFile
+}
+
+// NewResultsWriter creates the output directory and opens every results file, writing headers to new files
+func NewResultsWriter(dir string) (*ResultsWriter, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create results directory: %w", err)
+	}
+
+	w := &ResultsWriter{
+		dir:   dir,
+		files: make(map[string]*resultsFile),
+	}
+	for name, header := range resultsFileHeaders {
+		if err := w.open(name, header); err != nil {
+			w.Close()
+			return nil, err
+		}
+	}
+	return w, nil
+}
+
+func (w *ResultsWriter) open(name string, header []string) error {
+	path := filepath.Join(w.dir, name)
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open results file %s: %w", path, err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to stat results file %s: %w", path, err)
+	}
+
+	writer := csv.NewWriter(file)
+	if info.Size() == 0 {
+		if err := writer.Write(header); err != nil {
+			file.Close()
+			return fmt.Errorf("failed to write header of %s: %w", path, err)
+		}
+	}
+	w.files[name] = &resultsFile{file: file, writer: writer}
+	return nil
+}
+
+// Dir returns the directory results are written to
+func (w *ResultsWriter) Dir() string {
+	return w.dir
+}
+
+func (w *ResultsWriter) writeRows(name string, rows [][]string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	f, ok := w.files[name]
+	if !ok {
+		return fmt.Errorf("unknown results file: %s", name)
+	}
+	if err := f.writer.WriteAll(rows); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return nil
+}
+
+// WriteGroundTruth records the ground truth of an epoch
+func (w *ResultsWriter) WriteGroundTruth(key EpochKey, state *types.GroundTruthState) error {
+	row := append(key.columns(), formatFloat(state.CurrentPrice), formatFloat(state.LastReturn))
+	return w.writeRows(GroundTruthFile, [][]string{row})
+}
+
+// WriteInferences records the value submitted by each inferer, and which of them was the outperformer
+func (w *ResultsWriter) WriteInferences(key EpochKey, values map[string]*alloramath.BoundedExp40Dec, outperformer string) error {
+	rows := make([][]string, 0, len(values))
+	for _, inferer := range sortedKeys(values) {
+		rows = append(rows, append(key.columns(), inferer, values[inferer].String(), strconv.FormatBool(inferer == outperformer)))
+	}
+	return w.writeRows(InferencesFile, rows)
+}
+
+// WriteForecasts records every forecast element submitted by each forecaster
+func (w *ResultsWriter) WriteForecasts(key EpochKey, values map[string][]*emissionstypes.InputForecastElement) error {
+	rows := make([][]string, 0)
+	for _, forecaster := range sortedKeys(values) {
+		for _, element := range values[forecaster] {
+			rows = append(rows, append(key.columns(), forecaster, element.Inferer, element.Value.String()))
+		}
+	}
+	return w.writeRows(ForecastsFile, rows)
+}
+
+// WriteNetworkInferences records the combined and naive network values
+func (w *ResultsWriter) WriteNetworkInferences(key EpochKey, vb *emissionstypes.ValueBundle) error {
+	row := append(key.columns(), vb.CombinedValue.String(), vb.NaiveValue.String())
+	return w.writeRows(NetworkInferencesFile, [][]string{row})
+}
+
+// WriteLosses records every loss reported by a reputer
+func (w *ResultsWriter) WriteLosses(key EpochKey, reputer string, losses *emissionstypes.InputValueBundle) error {
+	row := func(kind, worker string, loss alloramath.BoundedExp40Dec) []string {
+		return append(key.columns(), reputer, kind, worker, loss.String())
+	}
+
+	rows := [][]string{
+		row(LossKindCombined, "", losses.CombinedValue),
+		row(LossKindNaive, "", losses.NaiveValue),
+	}
+	for _, v := range losses.InfererValues {
+		rows = append(rows, row(LossKindInferer, v.Worker, v.Value))
+	}
+	for _, v := range losses.ForecasterValues {
+		rows = append(rows, row(LossKindForecaster, v.Worker, v.Value))
+	}
+	for _, v := range losses.OneOutInfererValues {
+		rows = append(rows, row(LossKindOneOutInferer, v.Worker, v.Value))
+	}
+	for _, v := range losses.OneOutForecasterValues {
+		rows = append(rows, row(LossKindOneOutForecaster, v.Worker, v.Value))
+	}
+	for _, v := range losses.OneInForecasterValues {
+		rows = append(rows, row(LossKindOneInForecaster, v.Worker, v.Value))
+	}
+	return w.writeRows(LossesFile, rows)
+}
+
+// WriteOnChainValues records values the chain computed for an epoch.
+// height is the block at which the chain emitted or was queried for the values.
+func (w *ResultsWriter) WriteOnChainValues(key EpochKey, height int64, values []OnChainValue) error {
+	rows := make([][]string, 0, len(values))
+	for _, v := range values {
+		rows = append(rows, append(key.columns(), v.ActorType, v.Metric, v.Actor, v.OtherActor, v.Value, strconv.FormatInt(height, 10)))
+	}
+	return w.writeRows(OnChainFile, rows)
+}
+
+// WriteTopic records the research model of a topic and the experiment arm it runs
+func (w *ResultsWriter) WriteTopic(topicId uint64, topic ResearchTopic) error {
+	config := topic.Config
+	process := config.GroundTruth.Process
+	if process == "" {
+		process = GroundTruthRandomWalk
+	}
+	row := []string{
+		strconv.FormatUint(topicId, 10),
+		topic.Name,
+		strconv.Itoa(topic.Group),
+		topic.ArmName,
+		config.Topic.LossMethod,
+		strconv.FormatInt(config.Topic.EpochLength, 10),
+		process,
+		formatFloat(config.InitialPrice),
+		formatFloat(config.Volatility),
+	}
+	return w.writeRows(TopicsFile, [][]string{row})
+}
+
+func actorRow(topicId uint64, actor *types.Actor, actorType string, params *types.ResearchParams) []string {
+	archetype, cluster := params.Archetype, ""
+	if archetype == "" {
+		archetype = ArchetypeHonest
+	}
+	if archetype == ArchetypeSybil {
+		cluster = strconv.Itoa(params.SybilCluster)
+	}
+	return []string{
+		strconv.FormatUint(topicId, 10),
+		actor.Addr,
+		actorType,
+		formatFloat(params.Error),
+		formatFloat(params.Bias),
+		formatFloat(params.BiasWithVolatility),
+		formatFloat(params.ContextSensitivity),
+		archetype,
+		cluster,
+	}
+}
+
+// WriteActors records the hidden research params and archetype of every actor of a topic
+func (w *ResultsWriter) WriteActors(data *ResearchSimulationData, topicId uint64) error {
+	rows := make([][]string, 0)
+	addActors := func(actorType string, actors []*types.Actor) {
+		for _, actor := range actors {
+			params := data.GetResearchParams(topicId, actor.Addr)
+			if params == nil {
+				continue
+			}
+			rows = append(rows, actorRow(topicId, actor, actorType, params))
+		}
+	}
+	addActors(ActorTypeInferer, data.GetInferersForTopic(topicId))
+	addActors(ActorTypeForecaster, data.GetForecastersForTopic(topicId))
+	addActors(ActorTypeReputer, data.GetReputersForTopic(topicId))
+	return w.writeRows(ActorsFile, rows)
+}
+
+// WriteActor records the hidden research params of an actor that joined a topic after the start of the run
+func (w *ResultsWriter) WriteActor(topicId uint64, actor *types.Actor, actorType string, params *types.ResearchParams) error {
+	return w.writeRows(ActorsFile, [][]string{actorRow(topicId, actor, actorType, params)})
+}
+
+// WriteMembership records an actor joining, leaving or returning to a topic
+func (w *ResultsWriter) WriteMembership(topicId uint64, height int64, actor *types.Actor, actorType string, event string) error {
+	row := []string{
+		strconv.FormatUint(topicId, 10),
+		strconv.FormatInt(height, 10),
+		actor.Addr,
+		actorType,
+		event,
+	}
+	return w.writeRows(MembershipFile, [][]string{row})
+}
+
+// Flush writes buffered rows of every file to disk
+func (w *ResultsWriter) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for name, f := range w.files {
+		f.writer.Flush()
+		if err := f.writer.Error(); err != nil {
+			return fmt.Errorf("failed to flush %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// Close flushes and closes every results file
+func (w *ResultsWriter) Close() error {
+	err := w.Flush()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, f := range w.files {
+		if closeErr := f.file.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+// Keys of a map in a stable order so that files are reproducible
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,379 @@
+package sweep
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	cosmosmath "cosmossdk.io/math"
+	alloramath "github.com/allora-network/allora-chain/math"
+	emissionstypes "github.com/allora-network/allora-chain/x/emissions/types"
+	"github.com/allora-network/allora-simulator/types"
+)
+
+// Designs of a sweep
+const (
+	DesignGrid   = "grid"
+	DesignRandom = "random"
+)
+
+// Scopes of a param name
+const (
+	scopeGlobal = "global"
+	scopeTopic  = "topic"
+)
+
+// Points of a grid run before it is deemed a mistake
+const maxGridPoints = 10000
+
+// Topic params sent as text, the other string params of a topic are decimals
+var topicTextParams = map[string]bool{"loss_method": true}
+
+// ParamValue is the value a param takes at a point
+type ParamValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// Point is a setting of the params of a sweep
+type Point struct {
+	Index  int          `json:"index"`
+	Params []ParamValue `json:"params"`
+}
+
+// Tag names a point by its param values, e.g. [topic.alpha_regret=0.1 topic.p_norm=3]
+func (p Point) Tag() string {
+	values := make([]string, len(p.Params))
+	for i, param := range p.Params {
+		values[i] = param.Name + "=" + param.Value
+	}
+	return "[" + strings.Join(values, " ") + "]"
+}
+
+// ValidateSweepConfig checks the design, that every param exists and that its values parse
+func ValidateSweepConfig(config types.SweepConfig) error {
+	switch config.Design {
+	case "", DesignGrid:
+	case DesignRandom:
+		if config.Points <= 0 {
+			return fmt.Errorf("random design needs points, got %d", config.Points)
+		}
+	default:
+		return fmt.Errorf("unknown sweep design %q, expected %s or %s", config.Design, DesignGrid, DesignRandom)
+	}
+	if config.Epochs <= 0 {
+		return fmt.Errorf("sweep epochs must be positive, got %d", config.Epochs)
+	}
+	if len(config.Params) == 0 {
+		return fmt.Errorf("sweep needs at least one param")
+	}
+
+	seen := make(map[string]bool)
+	gridPoints := 1
+	for _, param := range config.Params {
+		if seen[param.Name] {
+			return fmt.Errorf("sweep param %s is listed twice", param.Name)
+		}
+		seen[param.Name] = true
+		field, err := paramType(param.Name)
+		if err != nil {
+			return err
+		}
+		for _, value := range param.Values {
+			if err := checkValue(param.Name, field, value); err != nil {
+				return err
+			}
+		}
+		if len(param.Values) > 0 {
+			gridPoints *= len(param.Values)
+			if gridPoints > maxGridPoints {
+				return fmt.Errorf("sweep grid has more than %d points", maxGridPoints)
+			}
+			continue
+		}
+		if config.Design != DesignRandom {
+			return fmt.Errorf("sweep param %s needs values in a grid", param.Name)
+		}
+		if field.Kind() == reflect.Bool || field.Kind() == reflect.String && topicTextParams[paramKey(param.Name)] {
+			return fmt.Errorf("sweep param %s is drawn from values only", param.Name)
+		}
+		if param.Min >= param.Max {
+			return fmt.Errorf("sweep param %s needs min below max, got [%v, %v]", param.Name, param.Min, param.Max)
+		}
+		if param.Log && param.Min <= 0 {
+			return fmt.Errorf("sweep param %s needs a positive min to draw log uniformly, got %v", param.Name, param.Min)
+		}
+	}
+	return nil
+}
+
+// GetPoints lists the points of a validated sweep
+func GetPoints(config types.SweepConfig) []Point {
+	if config.Design == DesignRandom {
+		seed := config.Seed
+		if seed == 0 {
+			seed = time.Now().UnixNano()
+		}
+		return randomPoints(config, rand.New(rand.NewSource(seed)))
+	}
+	return gridPoints(config)
+}
+
+// Every combination of the values, the last param varying fastest
+func gridPoints(config types.SweepConfig) []Point {
+	points := []Point{{}}
+	for _, param := range config.Params {
+		next := make([]Point, 0, len(points)*len(param.Values))
+		for _, point := range points {
+			for _, value := range param.Values {
+				params := append(append([]ParamValue{}, point.Params...), ParamValue{Name: param.Name, Value: value})
+				next = append(next, Point{Params: params})
+			}
+		}
+		points = next
+	}
+	for i := range points {
+		points[i].Index = i + 1
+	}
+	return points
+}
+
+func randomPoints(config types.SweepConfig, r *rand.Rand) []Point {
+	points := make([]Point, config.Points)
+	for i := range points {
+		points[i] = Point{Index: i + 1, Params: make([]ParamValue, len(config.Params))}
+		for j, param := range config.Params {
+			points[i].Params[j] = ParamValue{Name: param.Name, Value: drawValue(param, r)}
+		}
+	}
+	return points
+}
+
+func drawValue(param types.SweepParam, r *rand.Rand) string {
+	if len(param.Values) > 0 {
+		return param.Values[r.Intn(len(param.Values))]
+	}
+	var v float64
+	if param.Log {
+		v = math.Exp(math.Log(param.Min) + r.Float64()*(math.Log(param.Max)-math.Log(param.Min)))
+	} else {
+		v = param.Min + r.Float64()*(param.Max-param.Min)
+	}
+	field, _ := paramType(param.Name)
+	if isInteger(field) {
+		return strconv.FormatFloat(math.Round(v), 'f', 0, 64)
+	}
+	// Six significant digits are plenty for a param and keep the tags readable. Decimals are
+	// written without exponent, which the chain does not parse.
+	rounded, _ := strconv.ParseFloat(strconv.FormatFloat(v, 'g', 6, 64), 64)
+	return strconv.FormatFloat(rounded, 'f', -1, 64)
+}
+
+func paramScope(name string) string {
+	scope, _, _ := strings.Cut(name, ".")
+	return scope
+}
+
+func paramKey(name string) string {
+	_, key, _ := strings.Cut(name, ".")
+	return key
+}
+
+// Struct field of a param, by the json name of its scope
+func paramField(name string) (reflect.StructField, bool, error) {
+	var t reflect.Type
+	switch paramScope(name) {
+	case scopeGlobal:
+		t = reflect.TypeOf(emissionstypes.OptionalParams{})
+	case scopeTopic:
+		t = reflect.TypeOf(types.TopicConfig{})
+	default:
+		return reflect.StructField{}, false, fmt.Errorf("sweep param %s must be named %s.<name> or %s.<name>", name, scopeGlobal, scopeTopic)
+	}
+	key := paramKey(name)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if tag == key {
+			return field, true, nil
+		}
+	}
+	return reflect.StructField{}, false, nil
+}
+
+// Type of the value of a param. Global params are lists of one value, the type is of the element.
+func paramType(name string) (reflect.Type, error) {
+	field, ok, err := paramField(name)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("unknown sweep param %s", name)
+	}
+	if paramScope(name) == scopeGlobal {
+		return field.Type.Elem(), nil
+	}
+	return field.Type, nil
+}
+
+var (
+	decType = reflect.TypeOf(alloramath.Dec{})
+	intType = reflect.TypeOf(cosmosmath.Int{})
+)
+
+func isInteger(t reflect.Type) bool {
+	switch t {
+	case intType:
+		return true
+	case decType:
+		return false
+	}
+	switch t.Kind() {
+	case reflect.Int64, reflect.Uint64:
+		return true
+	}
+	return false
+}
+
+// Parse the value of a param into its type
+func parseValue(name string, t reflect.Type, value string) (reflect.Value, error) {
+	switch t {
+	case decType:
+		dec, err := alloramath.NewDecFromString(value)
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("invalid decimal %q for %s: %w", value, name, err)
+		}
+		return reflect.ValueOf(dec), nil
+	case intType:
+		i, ok := cosmosmath.NewIntFromString(value)
+		if !ok {
+			return reflect.Value{}, fmt.Errorf("invalid integer %q for %s", value, name)
+		}
+		return reflect.ValueOf(i), nil
+	}
+
+	v := reflect.New(t).Elem()
+	switch t.Kind() {
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("invalid bool %q for %s: %w", value, name, err)
+		}
+		v.SetBool(b)
+	case reflect.Int64:
+		i, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("invalid integer %q for %s: %w", value, name, err)
+		}
+		v.SetInt(i)
+	case reflect.Uint64:
+		u, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("invalid integer %q for %s: %w", value, name, err)
+		}
+		v.SetUint(u)
+	case reflect.String:
+		v.SetString(value)
+	default:
+		return reflect.Value{}, fmt.Errorf("sweep param %s of type %s is not supported", name, t)
+	}
+	return v, nil
+}
+
+func checkValue(name string, t reflect.Type, value string) error {
+	if _, err := parseValue(name, t, value); err != nil {
+		return err
+	}
+	// Decimal topic params are kept as strings and parsed when the topic is created
+	if paramScope(name) == scopeTopic && t.Kind() == reflect.String && !topicTextParams[paramKey(name)] {
+		if _, err := alloramath.NewDecFromString(value); err != nil {
+			return fmt.Errorf("invalid decimal %q for %s: %w", value, name, err)
+		}
+	}
+	return nil
+}
+
+// OptionalParams builds the emissions params update of the global params of a point.
+// Returns nil when the point sets no global param.
+func OptionalParams(point Point) (*emissionstypes.OptionalParams, error) {
+	params := &emissionstypes.OptionalParams{}
+	set := false
+	target := reflect.ValueOf(params).Elem()
+	for _, param := range point.Params {
+		if paramScope(param.Name) != scopeGlobal {
+			continue
+		}
+		field, _, err := paramField(param.Name)
+		if err != nil {
+			return nil, err
+		}
+		value, err := parseValue(param.Name, field.Type.Elem(), param.Value)
+		if err != nil {
+			return nil, err
+		}
+		list := reflect.MakeSlice(field.Type, 0, 1)
+		target.FieldByIndex(field.Index).Set(reflect.Append(list, value))
+		set = true
+	}
+	if !set {
+		return nil, nil
+	}
+	return params, nil
+}
+
+// CheckParamsApplied checks that the emissions params read from the chain have the value of every param
+// of an update, so that a point never runs with params the chain did not apply
+func CheckParamsApplied(params emissionstypes.Params, update *emissionstypes.OptionalParams) error {
+	current := reflect.ValueOf(params)
+	want := reflect.ValueOf(update).Elem()
+	for i := 0; i < want.NumField(); i++ {
+		field := want.Type().Field(i)
+		if !field.IsExported() || field.Type.Kind() != reflect.Slice || want.Field(i).Len() == 0 {
+			continue
+		}
+		tag, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		value := want.Field(i).Index(0)
+		got := current.FieldByName(field.Name)
+		if !got.IsValid() {
+			return fmt.Errorf("no emissions param %s on chain", tag)
+		}
+		if !paramEqual(got, value) {
+			return fmt.Errorf("emissions param %s is %v on chain, want %v", tag, got.Interface(), value.Interface())
+		}
+	}
+	return nil
+}
+
+func paramEqual(a, b reflect.Value) bool {
+	switch a.Type() {
+	case decType:
+		return a.Interface().(alloramath.Dec).Equal(b.Interface().(alloramath.Dec))
+	case intType:
+		return a.Interface().(cosmosmath.Int).Equal(b.Interface().(cosmosmath.Int))
+	}
+	return reflect.DeepEqual(a.Interface(), b.Interface())
+}
+
+// ApplyTopicParams sets the topic params of a point on a topic config
+func ApplyTopicParams(topic *types.TopicConfig, point Point) error {
+	target := reflect.ValueOf(topic).Elem()
+	for _, param := range point.Params {
+		if paramScope(param.Name) != scopeTopic {
+			continue
+		}
+		field, _, err := paramField(param.Name)
+		if err != nil {
+			return err
+		}
+		value, err := parseValue(param.Name, field.Type, param.Value)
+		if err != nil {
+			return err
+		}
+		target.FieldByIndex(field.Index).Set(value)
+	}
+	return nil
+}
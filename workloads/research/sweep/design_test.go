@@ -0,0 +1,134 @@
+package sweep
+
+import (
+	"math/rand"
+	"strconv"
+	"testing"
+
+	alloramath "github.com/allora-network/allora-chain/math"
+	emissionstypes "github.com/allora-network/allora-chain/x/emissions/types"
+	"github.com/allora-network/allora-simulator/types"
+)
+
+func TestValidateSweepConfig(t *testing.T) {
+	valid := types.SweepConfig{
+		Epochs: 10,
+		Params: []types.SweepParam{
+			{Name: "topic.alpha_regret", Values: []string{"0.1", "0.5"}},
+			{Name: "global.max_samples_to_scale_scores", Values: []string{"5", "10"}},
+		},
+	}
+	if err := ValidateSweepConfig(valid); err != nil {
+		t.Errorf("expected a valid config, got %v", err)
+	}
+
+	invalid := map[string]types.SweepConfig{
+		"unknown param":      {Epochs: 10, Params: []types.SweepParam{{Name: "topic.nope", Values: []string{"1"}}}},
+		"unknown scope":      {Epochs: 10, Params: []types.SweepParam{{Name: "alpha_regret", Values: []string{"1"}}}},
+		"bad decimal":        {Epochs: 10, Params: []types.SweepParam{{Name: "topic.p_norm", Values: []string{"three"}}}},
+		"bad integer":        {Epochs: 10, Params: []types.SweepParam{{Name: "global.max_samples_to_scale_scores", Values: []string{"1.5"}}}},
+		"grid without value": {Epochs: 10, Params: []types.SweepParam{{Name: "topic.p_norm", Min: 1, Max: 4}}},
+		"no epochs":          {Params: valid.Params},
+		"random no points":   {Design: DesignRandom, Epochs: 10, Params: valid.Params},
+		"empty range":        {Design: DesignRandom, Points: 3, Epochs: 10, Params: []types.SweepParam{{Name: "topic.p_norm", Min: 4, Max: 4}}},
+	}
+	for name, config := range invalid {
+		if err := ValidateSweepConfig(config); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestGridPoints(t *testing.T) {
+	points := GetPoints(types.SweepConfig{
+		Params: []types.SweepParam{
+			{Name: "topic.alpha_regret", Values: []string{"0.1", "0.5"}},
+			{Name: "topic.p_norm", Values: []string{"2", "3", "4"}},
+		},
+	})
+	if len(points) != 6 {
+		t.Fatalf("expected 6 points, got %d", len(points))
+	}
+	if points[0].Index != 1 || points[0].Tag() != "[topic.alpha_regret=0.1 topic.p_norm=2]" {
+		t.Errorf("unexpected first point %+v", points[0])
+	}
+	if points[5].Tag() != "[topic.alpha_regret=0.5 topic.p_norm=4]" {
+		t.Errorf("unexpected last point %+v", points[5])
+	}
+}
+
+func TestRandomPoints(t *testing.T) {
+	config := types.SweepConfig{
+		Design: DesignRandom,
+		Points: 50,
+		Params: []types.SweepParam{
+			{Name: "topic.merit_sortition_alpha", Min: 0.01, Max: 1, Log: true},
+			{Name: "global.max_samples_to_scale_scores", Min: 2, Max: 20},
+			{Name: "topic.loss_method", Values: []string{"mse", "mae"}},
+		},
+	}
+	points := randomPoints(config, rand.New(rand.NewSource(1)))
+	if len(points) != 50 {
+		t.Fatalf("expected 50 points, got %d", len(points))
+	}
+	for _, point := range points {
+		alpha, err := strconv.ParseFloat(point.Params[0].Value, 64)
+		if err != nil || alpha < 0.01 || alpha > 1 {
+			t.Errorf("alpha %s out of range", point.Params[0].Value)
+		}
+		samples, err := strconv.ParseUint(point.Params[1].Value, 10, 64)
+		if err != nil || samples < 2 || samples > 20 {
+			t.Errorf("samples %s is not an integer in range", point.Params[1].Value)
+		}
+		if method := point.Params[2].Value; method != "mse" && method != "mae" {
+			t.Errorf("loss method %s is not one of the values", method)
+		}
+		if err := ValidateSweepConfig(types.SweepConfig{Epochs: 1, Params: []types.SweepParam{
+			{Name: point.Params[0].Name, Values: []string{point.Params[0].Value}},
+		}}); err != nil {
+			t.Errorf("drawn value does not parse: %v", err)
+		}
+	}
+}
+
+func TestApplyPoint(t *testing.T) {
+	point := Point{Index: 1, Params: []ParamValue{
+		{Name: "topic.alpha_regret", Value: "0.25"},
+		{Name: "topic.epoch_length", Value: "20"},
+		{Name: "global.max_samples_to_scale_scores", Value: "7"},
+		{Name: "global.p_reward_inference", Value: "1.5"},
+	}}
+
+	topic := types.TopicConfig{AlphaRegret: "0.1", PNorm: "3", EpochLength: 12}
+	if err := ApplyTopicParams(&topic, point); err != nil {
+		t.Fatal(err)
+	}
+	if topic.AlphaRegret != "0.25" || topic.EpochLength != 20 || topic.PNorm != "3" {
+		t.Errorf("unexpected topic config %+v", topic)
+	}
+
+	params, err := OptionalParams(point)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(params.MaxSamplesToScaleScores) != 1 || params.MaxSamplesToScaleScores[0] != 7 {
+		t.Errorf("unexpected max samples %v", params.MaxSamplesToScaleScores)
+	}
+	if len(params.PRewardInference) != 1 || params.PRewardInference[0].String() != "1.5" {
+		t.Errorf("unexpected p reward inference %v", params.PRewardInference)
+	}
+
+	applied := emissionstypes.Params{MaxSamplesToScaleScores: 7, PRewardInference: alloramath.MustNewDecFromString("1.50")}
+	if err := CheckParamsApplied(applied, params); err != nil {
+		t.Errorf("CheckParamsApplied unexpected error: %v", err)
+	}
+	rejected := emissionstypes.Params{MaxSamplesToScaleScores: 10, PRewardInference: alloramath.MustNewDecFromString("1.5")}
+	if err := CheckParamsApplied(rejected, params); err == nil {
+		t.Errorf("expected an error when the chain kept its max samples")
+	}
+
+	topicOnly, err := OptionalParams(Point{Params: point.Params[:2]})
+	if err != nil || topicOnly != nil {
+		t.Errorf("expected no update without global params, got %v and %v", topicOnly, err)
+	}
+}
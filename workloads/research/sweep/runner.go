@@ -0,0 +1,152 @@
+package sweep
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/allora-network/allora-simulator/lib"
+	"github.com/allora-network/allora-simulator/types"
+	"github.com/allora-network/allora-simulator/workloads/research"
+	"github.com/allora-network/allora-simulator/workloads/research/analysis"
+	"github.com/rs/zerolog/log"
+)
+
+// File of every point directory with the params of the point
+const PointFile = "point.json"
+
+// Run runs the research topics at every point of the sweep, one point after the other on the same
+// chain and actors. Each point applies its global params, creates new topics with its topic params,
+// runs config.Sweep.Epochs epochs and analyzes its results. The table comparing the points is
+// written to the sweep directory, whose path is returned.
+func Run(
+	faucet *types.Actor,
+	config *types.Config,
+	data *research.ResearchSimulationData,
+	topics []research.ResearchTopic,
+	topicActors []research.TopicActors,
+	points []Point,
+) (string, error) {
+	dir := research.GetSweepDir(config)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create sweep directory: %w", err)
+	}
+	log.Info().Msgf("Writing sweep results to %s", dir)
+
+	// Every point runs the same number of epochs
+	pointConfig := *config
+	pointConfig.Research.Epochs = config.Sweep.Epochs
+
+	rows := make([]Row, 0)
+	for _, point := range points {
+		log.Info().Msgf("Running sweep point %d/%d %s", point.Index, len(points), point.Tag())
+		pointRows, err := runPoint(faucet, &pointConfig, data, topics, topicActors, point, filepath.Join(dir, pointDirName(point)))
+		if err != nil {
+			return dir, fmt.Errorf("sweep point %d %s failed: %w", point.Index, point.Tag(), err)
+		}
+		rows = append(rows, pointRows...)
+		// Rewritten after every point, so that the table of an interrupted sweep is not lost
+		if err := WriteTable(dir, config.Sweep.Params, rows); err != nil {
+			return dir, err
+		}
+	}
+	return dir, nil
+}
+
+func pointDirName(point Point) string {
+	return fmt.Sprintf("point_%03d", point.Index)
+}
+
+func runPoint(
+	faucet *types.Actor,
+	config *types.Config,
+	data *research.ResearchSimulationData,
+	topics []research.ResearchTopic,
+	topicActors []research.TopicActors,
+	point Point,
+	dir string,
+) ([]Row, error) {
+	optionalParams, err := OptionalParams(point)
+	if err != nil {
+		return nil, err
+	}
+	if optionalParams != nil {
+		log.Info().Msgf("Updating emissions params of point %d", point.Index)
+		if err := research.UpdateChainParams(faucet, optionalParams); err != nil {
+			return nil, err
+		}
+		// A rejected update is not an error of the send, the params are read back
+		params, err := lib.GetEmissionsParams(config)
+		if err != nil {
+			return nil, fmt.Errorf("error reading back the emissions params of point %d: %w", point.Index, err)
+		}
+		if err := CheckParamsApplied(params, optionalParams); err != nil {
+			return nil, err
+		}
+	}
+
+	pointTopics := make([]research.ResearchTopic, len(topics))
+	for i, topic := range topics {
+		topicConfig := *topic.Config
+		if err := ApplyTopicParams(&topicConfig.Topic, point); err != nil {
+			return nil, err
+		}
+		topic.Config = &topicConfig
+		// The topic metadata on chain carries the params of the point
+		topic.Name = topic.Name + " " + point.Tag()
+		pointTopics[i] = topic
+	}
+	topicIds, err := research.SetupResearchTopics(faucet, config, data, pointTopics, topicActors)
+	if err != nil {
+		return nil, err
+	}
+
+	writer, err := research.NewResultsWriter(dir)
+	if err != nil {
+		return nil, fmt.Errorf("error creating results writer: %w", err)
+	}
+	if err := writePoint(dir, point); err != nil {
+		writer.Close()
+		return nil, err
+	}
+	for i, topicId := range topicIds {
+		if err := writer.WriteTopic(topicId, pointTopics[i]); err != nil {
+			writer.Close()
+			return nil, fmt.Errorf("error writing topic %d: %w", topicId, err)
+		}
+		if err := writer.WriteActors(data, topicId); err != nil {
+			writer.Close()
+			return nil, fmt.Errorf("error writing actors of topic %d: %w", topicId, err)
+		}
+	}
+
+	err = research.StartActorLoops(data, config, writer, topicIds)
+	if closeErr := writer.Close(); closeErr != nil {
+		log.Error().Msgf("Error closing results writer: %v", closeErr)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	run, err := analysis.LoadRun(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the results of the point: %w", err)
+	}
+	result, err := analysis.Analyze(run)
+	if err != nil {
+		return nil, fmt.Errorf("failed to analyze the results of the point: %w", err)
+	}
+	return pointRows(point, result), nil
+}
+
+func writePoint(dir string, point Point) error {
+	data, err := json.MarshalIndent(point, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal point: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, PointFile), data, 0o644); err != nil {
+		return fmt.Errorf("failed to write point: %w", err)
+	}
+	return nil
+}
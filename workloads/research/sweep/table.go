@@ -0,0 +1,171 @@
+package sweep
+
+import (
+	"encoding/csv"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/allora-network/allora-simulator/types"
+	"github.com/allora-network/allora-simulator/workloads/research"
+	"github.com/allora-network/allora-simulator/workloads/research/analysis"
+)
+
+// Files of the comparison table of a sweep
+const (
+	TableFile  = "sweep.csv"
+	ReportFile = "sweep.md"
+)
+
+// Row is the outcome of a topic at a point of the sweep
+type Row struct {
+	Point   Point
+	TopicId uint64
+	Topic   string
+	Epochs  int
+	// RMSE of the network values against the ground truth, and the reduction of the combined over the naive one
+	CombinedRMSE float64
+	NaiveRMSE    float64
+	Improvement  float64
+	// Rank correlation of the skill of the actors of a role, minus their realised loss, with their reward
+	InfererLossVsReward    float64
+	ForecasterLossVsReward float64
+	ReputerLossVsReward    float64
+	// Concentration of the reputer stake at the last epoch
+	StakeHHI float64
+}
+
+var metricColumns = []string{
+	"topic_id", "topic", "epochs", "combined_rmse", "naive_rmse", "improvement",
+	"inferer_loss_vs_reward", "forecaster_loss_vs_reward", "reputer_loss_vs_reward", "stake_hhi",
+}
+
+// Rows of the topics of a point, from the analysis of its results
+func pointRows(point Point, result *analysis.Analysis) []Row {
+	rows := make([]Row, 0, len(result.Run.Topics))
+	for _, topic := range result.Run.Topics {
+		row := Row{
+			Point:                  point,
+			TopicId:                topic.Id,
+			Topic:                  strings.TrimSuffix(topic.Name, " "+point.Tag()),
+			CombinedRMSE:           math.NaN(),
+			NaiveRMSE:              math.NaN(),
+			Improvement:            math.NaN(),
+			InfererLossVsReward:    math.NaN(),
+			ForecasterLossVsReward: math.NaN(),
+			ReputerLossVsReward:    math.NaN(),
+			StakeHHI:               math.NaN(),
+		}
+		for _, network := range result.Networks {
+			if network.TopicId == topic.Id {
+				row.Epochs = network.Epochs
+				row.CombinedRMSE = network.CombinedRMSE
+				row.NaiveRMSE = network.NaiveRMSE
+				row.Improvement = network.Improvement
+			}
+		}
+		for _, correlation := range result.Correlations {
+			if correlation.TopicId != topic.Id {
+				continue
+			}
+			switch correlation.ActorType {
+			case research.ActorTypeInferer:
+				row.InfererLossVsReward = correlation.LossVsReward
+			case research.ActorTypeForecaster:
+				row.ForecasterLossVsReward = correlation.LossVsReward
+			case research.ActorTypeReputer:
+				row.ReputerLossVsReward = correlation.LossVsReward
+			}
+		}
+		for _, stake := range result.Stakes {
+			if stake.TopicId == topic.Id {
+				row.StakeHHI = stake.HHI
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+func (r Row) values(format func(float64) string) []string {
+	values := []string{strconv.Itoa(r.Point.Index)}
+	for _, param := range r.Point.Params {
+		values = append(values, param.Value)
+	}
+	return append(values,
+		strconv.FormatUint(r.TopicId, 10),
+		r.Topic,
+		strconv.Itoa(r.Epochs),
+		format(r.CombinedRMSE),
+		format(r.NaiveRMSE),
+		format(r.Improvement),
+		format(r.InfererLossVsReward),
+		format(r.ForecasterLossVsReward),
+		format(r.ReputerLossVsReward),
+		format(r.StakeHHI),
+	)
+}
+
+func tableHeader(params []types.SweepParam) []string {
+	header := []string{"point"}
+	for _, param := range params {
+		header = append(header, param.Name)
+	}
+	return append(header, metricColumns...)
+}
+
+func formatCSV(v float64) string {
+	if math.IsNaN(v) {
+		return ""
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func formatMarkdown(v float64) string {
+	if math.IsNaN(v) {
+		return "n/a"
+	}
+	return strconv.FormatFloat(v, 'g', 4, 64)
+}
+
+// WriteTable writes the rows of a sweep as CSV and as a Markdown table, one row per point and topic
+func WriteTable(dir string, params []types.SweepParam, rows []Row) error {
+	header := tableHeader(params)
+
+	f, err := os.Create(filepath.Join(dir, TableFile))
+	if err != nil {
+		return fmt.Errorf("failed to create sweep table: %w", err)
+	}
+	defer f.Close()
+	w := csv.NewWriter(f)
+	if err := w.Write(header); err != nil {
+		return fmt.Errorf("failed to write sweep table: %w", err)
+	}
+	for _, row := range rows {
+		if err := w.Write(row.values(formatCSV)); err != nil {
+			return fmt.Errorf("failed to write sweep table: %w", err)
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return fmt.Errorf("failed to write sweep table: %w", err)
+	}
+
+	var b strings.Builder
+	b.WriteString("# Parameter sweep\n\n")
+	b.WriteString("Outcome of every topic at every point. Improvement is the relative RMSE reduction of the combined network value over the naive one. ")
+	b.WriteString("The loss vs reward columns are the rank correlations of the skill of the actors of a role with their total reward. ")
+	b.WriteString("The results of each point are in its `point_<index>` directory.\n")
+	b.WriteString("\n| " + strings.Join(header, " | ") + " |\n")
+	b.WriteString("|" + strings.Repeat("---|", len(header)) + "\n")
+	for _, row := range rows {
+		b.WriteString("| " + strings.Join(row.values(formatMarkdown), " | ") + " |\n")
+	}
+	if err := os.WriteFile(filepath.Join(dir, ReportFile), []byte(b.String()), 0o644); err != nil {
+		return fmt.Errorf("failed to write sweep report: %w", err)
+	}
+	return nil
+}
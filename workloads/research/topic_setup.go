@@ -1,7 +1,10 @@
 package research
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/rs/zerolog/log"
 
@@ -15,10 +18,145 @@ import (
 
 const topicFunds int64 = 1e6
 
+// ResearchTopic is the resolved model and actor population of one research topic
+type ResearchTopic struct {
+	Name        string
+	Config      *types.ResearchConfig
+	Inferers    int
+	Forecasters int
+	Reputers    int
+	// Index of the topic in the config, shared by its arms, and the arm the topic runs.
+	// The first arm of a topic is at index i - Arm of the topics list.
+	Group   int
+	Arm     int
+	ArmName string
+}
+
+// GetResearchTopics resolves the topics to simulate from the config.
+// Without a topics list, a single topic is built from the top level research params.
+// With arms, every topic is run once per arm, see ValidateResearchArms.
+func GetResearchTopics(config *types.Config) []ResearchTopic {
+	if len(config.Research.Topics) == 0 {
+		return expandArms([]ResearchTopic{
+			{
+				Name:        "Research Topic",
+				Config:      &config.Research,
+				Inferers:    config.InferersPerTopic,
+				Forecasters: config.ForecastersPerTopic,
+				Reputers:    config.ReputersPerTopic,
+			},
+		}, config.Research.Arms)
+	}
+
+	topics := make([]ResearchTopic, len(config.Research.Topics))
+	for i, topic := range config.Research.Topics {
+		// Experience and outperformance params stay shared, the market model is per topic
+		topicConfig := config.Research
+		topicConfig.InitialPrice = topic.InitialPrice
+		topicConfig.Drift = topic.Drift
+		topicConfig.Volatility = topic.Volatility
+		topicConfig.GroundTruth = topic.GroundTruth
+		topicConfig.Topic = topic.Topic
+		topicConfig.Topics = nil
+		if topic.Adversaries != nil {
+			topicConfig.Adversaries = *topic.Adversaries
+		}
+
+		name := topic.Name
+		if name == "" {
+			name = fmt.Sprintf("Research Topic %d", i+1)
+		}
+		topics[i] = ResearchTopic{
+			Name:        name,
+			Config:      &topicConfig,
+			Inferers:    topic.Inferers,
+			Forecasters: topic.Forecasters,
+			Reputers:    topic.Reputers,
+			Group:       i,
+		}
+	}
+	return expandArms(topics, config.Research.Arms)
+}
+
+// ValidateResearchArms checks that the arms have distinct names and that their topic params parse
+func ValidateResearchArms(arms []types.ArmConfig) error {
+	names := make(map[string]bool, len(arms))
+	for i, arm := range arms {
+		name := armName(arm, i)
+		if names[name] {
+			return fmt.Errorf("arm %s is listed twice", name)
+		}
+		names[name] = true
+		if _, err := applyArm(types.TopicConfig{}, arm); err != nil {
+			return fmt.Errorf("invalid topic params of arm %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func armName(arm types.ArmConfig, i int) string {
+	if arm.Name == "" {
+		return fmt.Sprintf("Arm %d", i+1)
+	}
+	return arm.Name
+}
+
+// Overlay the topic params of an arm on the params of a topic, the params the arm leaves out are kept
+func applyArm(topic types.TopicConfig, arm types.ArmConfig) (types.TopicConfig, error) {
+	if len(arm.Topic) == 0 {
+		return topic, nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(arm.Topic))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&topic); err != nil {
+		return topic, err
+	}
+	return topic, nil
+}
+
+// One topic per arm of every topic, the arms of a topic next to each other
+func expandArms(topics []ResearchTopic, arms []types.ArmConfig) []ResearchTopic {
+	if len(arms) == 0 {
+		return topics
+	}
+	expanded := make([]ResearchTopic, 0, len(topics)*len(arms))
+	for _, topic := range topics {
+		for i, arm := range arms {
+			armConfig := *topic.Config
+			armConfig.Arms = nil
+			// Checked by ValidateResearchArms before the topics are resolved
+			armConfig.Topic, _ = applyArm(armConfig.Topic, arm)
+
+			armTopic := topic
+			armTopic.Name = topic.Name + " / " + armName(arm, i)
+			armTopic.Config = &armConfig
+			armTopic.Arm = i
+			armTopic.ArmName = armName(arm, i)
+			expanded = append(expanded, armTopic)
+		}
+	}
+	return expanded
+}
+
 func CreateAndFundResearchTopic(
 	actor *types.Actor,
 	config *types.Config,
+	topic ResearchTopic,
 ) (uint64, error) {
+	topicConfig := topic.Config.Topic
+
+	// Losses are computed locally with the topic loss method, make sure it is supported
+	if _, err := GetLossFunction(topicConfig.LossMethod, 0); err != nil {
+		return 0, err
+	}
+	// Fail before creating the topic if its ground truth process is misconfigured
+	if _, err := NewGroundTruthProcess(topic.Config); err != nil {
+		return 0, fmt.Errorf("invalid ground truth for topic %s: %w", topic.Name, err)
+	}
+	if err := ValidateAdversaries(topic.Config.Adversaries, topic); err != nil {
+		return 0, fmt.Errorf("invalid adversaries for topic %s: %w", topic.Name, err)
+	}
+
 	// Get Next Topic Id
 	topicId, err := lib.GetNextTopicId(config)
 	if err != nil {
@@ -27,19 +165,19 @@ func CreateAndFundResearchTopic(
 
 	request := &emissionstypes.CreateNewTopicRequest{
 		Creator:                  actor.Addr,
-		Metadata:                 "Research Topic",
-		LossMethod:               config.Research.Topic.LossMethod,
-		EpochLength:              config.Research.Topic.EpochLength,
-		GroundTruthLag:           config.Research.Topic.GroundTruthLag,
-		WorkerSubmissionWindow:   config.Research.Topic.WorkerSubmissionWindow,
-		PNorm:                    alloramath.MustNewDecFromString(config.Research.Topic.PNorm),
-		AlphaRegret:              alloramath.MustNewDecFromString(config.Research.Topic.AlphaRegret),
-		AllowNegative:            config.Research.Topic.AllowNegative,
-		Epsilon:                  alloramath.MustNewDecFromString(config.Research.Topic.Epsilon),
-		MeritSortitionAlpha:      alloramath.MustNewDecFromString(config.Research.Topic.MeritSortitionAlpha),
-		ActiveInfererQuantile:    alloramath.MustNewDecFromString(config.Research.Topic.ActiveInfererQuantile),
-		ActiveForecasterQuantile: alloramath.MustNewDecFromString(config.Research.Topic.ActiveForecasterQuantile),
-		ActiveReputerQuantile:    alloramath.MustNewDecFromString(config.Research.Topic.ActiveReputerQuantile),
+		Metadata:                 topic.Name,
+		LossMethod:               topicConfig.LossMethod,
+		EpochLength:              topicConfig.EpochLength,
+		GroundTruthLag:           topicConfig.GroundTruthLag,
+		WorkerSubmissionWindow:   topicConfig.WorkerSubmissionWindow,
+		PNorm:                    alloramath.MustNewDecFromString(topicConfig.PNorm),
+		AlphaRegret:              alloramath.MustNewDecFromString(topicConfig.AlphaRegret),
+		AllowNegative:            topicConfig.AllowNegative,
+		Epsilon:                  alloramath.MustNewDecFromString(topicConfig.Epsilon),
+		MeritSortitionAlpha:      alloramath.MustNewDecFromString(topicConfig.MeritSortitionAlpha),
+		ActiveInfererQuantile:    alloramath.MustNewDecFromString(topicConfig.ActiveInfererQuantile),
+		ActiveForecasterQuantile: alloramath.MustNewDecFromString(topicConfig.ActiveForecasterQuantile),
+		ActiveReputerQuantile:    alloramath.MustNewDecFromString(topicConfig.ActiveReputerQuantile),
 		EnableWorkerWhitelist:    false,
 		EnableReputerWhitelist:   false,
 	}
@@ -66,3 +204,79 @@ func CreateAndFundResearchTopic(
 	log.Info().Msgf("Created and funded topic: %d", topicId)
 	return topicId, nil
 }
+
+// SetupResearchTopics creates and funds every research topic, then registers the reputers, inferers
+// and forecasters of all topics, waiting between the steps for the chain to settle.
+// The arms of a topic share the research params and stakes of their actors, by index, and the
+// ground truth of the first arm. Returns the ids of the topics.
+func SetupResearchTopics(
+	faucet *types.Actor,
+	config *types.Config,
+	data *ResearchSimulationData,
+	topics []ResearchTopic,
+	topicActors []TopicActors,
+) ([]uint64, error) {
+	topicIds := make([]uint64, len(topics))
+	stakes := make([][]math.Int, len(topics))
+	for i, topic := range topics {
+		log.Info().Msgf("Creating research topic %s...", topic.Name)
+		topicId, err := CreateAndFundResearchTopic(faucet, config, topic)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create research topic %s: %w", topic.Name, err)
+		}
+		data.AddTopic(topicId, topic.Config)
+		topicIds[i] = topicId
+		log.Info().Msgf("Successfully created research topic with ID: %d", topicId)
+		log.Info().Msgf("Actor roles assigned for topic %d - Inferers: %d, Forecasters: %d, Reputers: %d",
+			topicId, len(topicActors[i].Inferers), len(topicActors[i].Forecasters), len(topicActors[i].Reputers))
+
+		if topic.Arm > 0 {
+			first := i - topic.Arm
+			stakes[i] = stakes[first]
+			if err := data.ShareGroundTruth(topicIds[first], topicId); err != nil {
+				return nil, err
+			}
+		} else {
+			stakes[i] = DrawReputerStakes(topicActors[i].Reputers)
+		}
+	}
+
+	// Register actors with delays between registrations
+	time.Sleep(20 * time.Second)
+	for i, topicId := range topicIds {
+		reputers := topicActors[i].Reputers
+		log.Info().Msgf("Starting reputer registration process for topic %d (%d reputers)...", topicId, len(reputers))
+		if err := RegisterReputersAndStake(reputers, topicId, data, len(reputers), stakes[i]); err != nil {
+			return nil, fmt.Errorf("error registering reputers: %w", err)
+		}
+	}
+	log.Info().Msgf("Successfully registered all reputers")
+
+	time.Sleep(20 * time.Second)
+	for i, topicId := range topicIds {
+		inferers := topicActors[i].Inferers
+		log.Info().Msgf("Starting inferer registration process for topic %d (%d inferers)...", topicId, len(inferers))
+		if err := RegisterWorkers(inferers, topicId, data, len(inferers), true); err != nil {
+			return nil, fmt.Errorf("error registering inferers: %w", err)
+		}
+	}
+	log.Info().Msgf("Successfully registered all inferers")
+
+	time.Sleep(20 * time.Second)
+	for i, topicId := range topicIds {
+		forecasters := topicActors[i].Forecasters
+		log.Info().Msgf("Starting forecaster registration process for topic %d (%d forecasters)...", topicId, len(forecasters))
+		if err := RegisterWorkers(forecasters, topicId, data, len(forecasters), false); err != nil {
+			return nil, fmt.Errorf("error registering forecasters: %w", err)
+		}
+	}
+	log.Info().Msgf("Successfully registered all forecasters")
+
+	for i, topic := range topics {
+		if topic.Arm > 0 {
+			first := i - topic.Arm
+			cloneArmParams(data, topicIds[first], topicActors[first], topicIds[i], topicActors[i])
+		}
+	}
+	return topicIds, nil
+}
@@ -0,0 +1,109 @@
+package research
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/allora-network/allora-simulator/types"
+)
+
+func TestResearchArms(t *testing.T) {
+	arms := []types.ArmConfig{
+		{Name: "baseline"},
+		{Name: "low regret", Topic: json.RawMessage(`{"alpha_regret": "0.01", "loss_method": "mae"}`)},
+	}
+	config := &types.Config{
+		InferersPerTopic:    3,
+		ForecastersPerTopic: 2,
+		ReputersPerTopic:    2,
+		Research: types.ResearchConfig{
+			InitialPrice: 100,
+			Volatility:   0.05,
+			Topic:        types.TopicConfig{LossMethod: LossMethodMSE, EpochLength: 12, AlphaRegret: "0.1", PNorm: "3"},
+			Arms:         arms,
+			Offline:      types.OfflineConfig{Epochs: 4, Weighting: OfflineWeightingMean, OutputDir: t.TempDir()},
+		},
+	}
+
+	if err := ValidateResearchArms(arms); err != nil {
+		t.Fatalf("ValidateResearchArms unexpected error: %v", err)
+	}
+	invalid := map[string][]types.ArmConfig{
+		"duplicate name": {{Name: "a"}, {Name: "a"}},
+		"default name":   {{}, {Name: "Arm 1"}},
+		"unknown param":  {{Topic: json.RawMessage(`{"alpha": "0.1"}`)}},
+		"bad value":      {{Topic: json.RawMessage(`{"epoch_length": "12"}`)}},
+	}
+	for name, arms := range invalid {
+		if err := ValidateResearchArms(arms); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+
+	topics := GetResearchTopics(config)
+	if len(topics) != 2 {
+		t.Fatalf("got %d topics, want one per arm", len(topics))
+	}
+	if topics[0].Name != "Research Topic / baseline" || topics[1].Name != "Research Topic / low regret" || topics[1].Arm != 1 {
+		t.Errorf("unexpected arm topics %+v and %+v", topics[0], topics[1])
+	}
+	low := topics[1].Config.Topic
+	if low.AlphaRegret != "0.01" || low.LossMethod != LossMethodMAE || low.PNorm != "3" || low.EpochLength != 12 {
+		t.Errorf("arm params not overlaid on the topic params: %+v", low)
+	}
+	if topics[0].Config.Topic.AlphaRegret != "0.1" || config.Research.Topic.AlphaRegret != "0.1" {
+		t.Errorf("arm params leaked into the other arm or the config")
+	}
+
+	// Offline, topics are numbered from 1 in the order of the arms
+	actors := newOfflineActors(GetNumActors(topics, false))
+	data := NewResearchSimulationData(nil, 12, actors)
+	topicActors := AssignTopicActors(actors, topics, false)
+	for i, topic := range topics {
+		data.AddTopic(uint64(i+1), topic.Config)
+		registerOfflineActors(data, uint64(i+1), topic.Config, topicActors[i])
+	}
+	data.SetTargetInferer(1, topicActors[0].Inferers[1].Addr)
+	cloneArmParams(data, 1, topicActors[0], 2, topicActors[1])
+	if err := data.ShareGroundTruth(1, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	for i, inferer := range topicActors[1].Inferers {
+		first := data.GetResearchParams(1, topicActors[0].Inferers[i].Addr)
+		cloned := data.GetResearchParams(2, inferer.Addr)
+		if *first != *cloned || first == cloned {
+			t.Errorf("inferer %d of the second arm has params %+v, want a copy of %+v", i, cloned, first)
+		}
+	}
+	first, _ := data.GetGroundTruthStream(1)
+	second, _ := data.GetGroundTruthStream(2)
+	// The second arm reads ahead of the first, both see the same series
+	if second.At(3) != first.At(3) || first.At(1) != second.At(1) {
+		t.Errorf("arms do not share their ground truth")
+	}
+	if data.GetTargetInferer(2) != topicActors[1].Inferers[1].Addr {
+		t.Errorf("shill target of the second arm is %s, want the inferer at the index of the first arm's target", data.GetTargetInferer(2))
+	}
+
+	// The inferers at the same index draw the same values and the same outperformer in both arms
+	for epoch := int64(0); epoch < 3; epoch++ {
+		for topicId := uint64(1); topicId <= 2; topicId++ {
+			data.GenerateInfererSimulatedValuesForNextEpoch(data.GetTopicConfig(topicId), topicId, epoch, first.At(epoch))
+		}
+		for i, inferer := range topicActors[1].Inferers {
+			firstValue := data.GetInfererSimulatedValue(1, topicActors[0].Inferers[i].Addr)
+			armValue := data.GetInfererSimulatedValue(2, inferer.Addr)
+			if firstValue.String() != armValue.String() {
+				t.Errorf("epoch %d: inferer %d drew %s in the second arm, want %s", epoch, i, armValue, firstValue)
+			}
+			if (data.GetInfererOutperformer(1) == topicActors[0].Inferers[i].Addr) != (data.GetInfererOutperformer(2) == inferer.Addr) {
+				t.Errorf("epoch %d: arms picked outperformers at different indices", epoch)
+			}
+		}
+	}
+
+	if err := RunOfflineSimulation(config); err != nil {
+		t.Fatalf("RunOfflineSimulation with arms unexpected error: %v", err)
+	}
+}
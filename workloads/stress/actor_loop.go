@@ -13,6 +13,8 @@ import (
 	"github.com/allora-network/allora-simulator/lib"
 	"github.com/allora-network/allora-simulator/types"
 	"github.com/allora-network/allora-simulator/workloads/common"
+	sdktypes "github.com/cosmos/cosmos-sdk/types"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
 	"github.com/rs/zerolog/log"
 )
 
@@ -24,6 +26,12 @@ func StartActorLoops(
 	log.Info().Msgf("Starting submission loop for %d topics", len(topicIds))
 
 	totalRoutines := len(topicIds)*2 + 1 // 2 routines per topic (worker + reputer) + 1 for gas routine
+	if config.Churn.Enabled {
+		totalRoutines++ // + 1 for churn routine
+	}
+	if config.Staking.Enabled {
+		totalRoutines++ // + 1 for staking routine
+	}
 	errChan := make(chan error, totalRoutines)
 
 	// Create wait group to track all goroutines
@@ -33,9 +41,25 @@ func StartActorLoops(
 	// Run gas routine
 	go func() {
 		defer wg.Done()
-		common.RunGasRoutine(config)
+		data.GasPrice.Run()
 	}()
 
+	// Run churn routine
+	if config.Churn.Enabled {
+		go func() {
+			defer wg.Done()
+			runChurnLoop(data, config, topicIds)
+		}()
+	}
+
+	// Run staking routine
+	if config.Staking.Enabled {
+		go func() {
+			defer wg.Done()
+			common.RunStakingLoop(config, data.getEpochLength, data.Delegators, topicIds, data.GetReputersForTopic, nil)
+		}()
+	}
+
 	// For each topic, start a worker routine and a reputer routine
 	for _, topicId := range topicIds {
 		log.Info().Msgf("Starting submission loop for topic: %d", topicId)
@@ -117,14 +141,25 @@ func runTopicWorkersLoop(
 					return err
 				}
 
+				// Forecasts cover the previously active inferers, or every registered one
+				forecastInferersAddresses := forecastInferers(config.Payload, previousActiveWorkersAddresses, workers)
+				if data.Values != nil {
+					data.Values.NextEpoch(topicId, latestOpenWorkerNonce, workers, forecastInferersAddresses)
+				}
+
 				log.Info().Msgf("Building and committing worker payload for topic: %d", topicId)
-				wasError := createAndSendWorkerPayloads(topicId, workers, latestOpenWorkerNonce, previousActiveWorkersAddresses)
+				wasError := createAndSendWorkerPayloads(data, config, topicId, workers, latestOpenWorkerNonce, previousActiveSetNonce, forecastInferersAddresses)
 				if wasError {
 					log.Error().Err(err).Msgf("Error building and committing worker payload for topic: %d", topicId)
 				}
 				log.Info().Msgf("Successfully built and committed worker payload for topic: %d for %v workers", topicId, len(workers))
 			}
 		}
+		if config.Faults.FailOnUnexpected {
+			if unexpected := data.FaultStats.Unexpected(); unexpected > 0 {
+				return fmt.Errorf("%d faulty payloads were not rejected as expected", unexpected)
+			}
+		}
 		time.Sleep(4 * time.Second)
 	}
 }
@@ -155,7 +190,7 @@ func runReputersProcess(
 				}
 
 				log.Info().Msgf("Building and committing reputer payload for topic: %d", topicId)
-				wasError := createAndSendReputerPayloads(topicId, reputers, activeWorkersAddresses, latestOpenReputerNonce)
+				wasError := createAndSendReputerPayloads(data, config, topicId, reputers, activeWorkersAddresses, latestOpenReputerNonce)
 				if wasError {
 					log.Error().Msgf("Error building and committing reputer payload for topic: %d", topicId)
 				}
@@ -168,10 +203,13 @@ func runReputersProcess(
 
 // Create and send worker payloads
 func createAndSendWorkerPayloads(
+	data *StressSimulationData,
+	config *types.Config,
 	topicId uint64,
 	workers []*types.Actor,
 	workerNonce int64,
-	previousActiveInferersAddresses []string,
+	previousNonce int64,
+	forecastInferersAddresses []string,
 ) bool {
 	completed := atomic.Int32{}
 	start := time.Now()
@@ -192,20 +230,40 @@ func createAndSendWorkerPayloads(
 				}
 			}()
 
-			workerData, err := createWorkerDataBundle(topicId, workerNonce, worker, previousActiveInferersAddresses)
+			values := data.workerValues(config.Payload, topicId, worker.Addr, forecastInferersAddresses)
+			workerData, err := createWorkerDataBundle(topicId, workerNonce, worker, values)
 			if err != nil {
 				log.Error().Msgf("Error creating worker data bundle: %v", err.Error())
 				return
 			}
 
-			_, updatedSeq, err := common.SendDataWithRetry(worker.TxParams, false, &emissionstypes.InsertWorkerPayloadRequest{
+			payload := &emissionstypes.InsertWorkerPayloadRequest{
 				Sender:           worker.Addr,
 				WorkerDataBundle: workerData,
-			})
-			if err != nil {
-				log.Error().Msgf("Error sending worker payload: %v", err.Error())
 			}
-			worker.TxParams.Sequence = updatedSeq
+			// Passing CheckTx does not make a payload delivered, with faults the payload waits for its block
+			// so that only a delivered payload is duplicated
+			waitForTx := injectsFaults(config.Faults)
+			accepted := false
+			if fillers := config.Batch.StressFillerMsgs; fillers > 0 {
+				// Many messages per tx: the payload goes with filler bank sends, batched within the limits
+				msgs := append([]common.SignedMsg{{Signer: worker, Msg: payload}}, fillerMsgs(config, worker, data.Faucet, fillers)...)
+				accepted = !common.ContainsMsg(data.Batcher.Send(waitForTx, msgs...), payload)
+			} else {
+				_, err := common.SendFromActor(worker, waitForTx, payload)
+				if err != nil {
+					log.Error().Msgf("Error sending worker payload: %v", err.Error())
+				}
+				accepted = err == nil
+			}
+
+			if injectsFaults(config.Faults) {
+				if !accepted {
+					// Only a payload the chain accepted can be duplicated
+					payload = nil
+				}
+				injectFaults(config.Faults, data.FaultStats, worker, topicId, workerNonce, previousNonce, forecastInferersAddresses, payload)
+			}
 		}(worker)
 	}
 
@@ -215,12 +273,28 @@ func createAndSendWorkerPayloads(
 	return false
 }
 
+// Bank sends of 1 denom from a worker to the faucet, to load the chain with many messages per tx
+func fillerMsgs(config *types.Config, worker *types.Actor, faucet *types.Actor, n int) []common.SignedMsg {
+	msgs := make([]common.SignedMsg, n)
+	for i := range msgs {
+		msgs[i] = common.SignedMsg{
+			Signer: worker,
+			Msg: &banktypes.MsgSend{
+				FromAddress: worker.Addr,
+				ToAddress:   faucet.Addr,
+				Amount:      sdktypes.NewCoins(sdktypes.NewInt64Coin(config.Denom, 1)),
+			},
+		}
+	}
+	return msgs
+}
+
 // Create inferences and forecasts for a worker
 func createWorkerDataBundle(
 	topicId uint64,
 	blockHeight int64,
 	inferer *types.Actor,
-	previousActiveInferersAddresses []string,
+	values workerValues,
 ) (*emissionstypes.InputWorkerDataBundle, error) {
 	workerDataBundle := &emissionstypes.InputWorkerDataBundle{
 		Worker: inferer.Addr,
@@ -233,9 +307,9 @@ func createWorkerDataBundle(
 				TopicId:     topicId,
 				BlockHeight: blockHeight,
 				Inferer:     inferer.Addr,
-				Value:       alloramath.MustNewCappedBoundedExp40DecFromString(fmt.Sprintf("%d", rand.Intn(300)+3000)),
-				ExtraData:   nil,
-				Proof:       "",
+				Value:       values.inference,
+				ExtraData:   values.extraData,
+				Proof:       values.proof,
 			},
 			Forecast: nil,
 		},
@@ -243,44 +317,45 @@ func createWorkerDataBundle(
 		Pubkey:                             "",
 	}
 
-	forecastElements := make([]*emissionstypes.InputForecastElement, 0)
-	for _, previousActiveInfererAddress := range previousActiveInferersAddresses {
-		forecastElements = append(forecastElements, &emissionstypes.InputForecastElement{
-			Inferer: previousActiveInfererAddress,
-			Value:   alloramath.MustNewCappedBoundedExp40DecFromString(fmt.Sprintf("%d", rand.Intn(51)+50)),
-		})
-	}
 	// If there are forecast elements, create a forecast
-	if len(forecastElements) != 0 {
+	if len(values.forecast) != 0 {
 		workerDataBundle.InferenceForecastsBundle.Forecast = &emissionstypes.InputForecast{
 			TopicId:          topicId,
 			BlockHeight:      blockHeight,
 			Forecaster:       inferer.Addr,
-			ForecastElements: forecastElements,
-			ExtraData:        nil,
+			ForecastElements: values.forecast,
+			ExtraData:        values.extraData,
 		}
 	}
 
-	// Sign transaction
+	if err := signWorkerDataBundle(workerDataBundle, inferer); err != nil {
+		return nil, err
+	}
+	return workerDataBundle, nil
+}
+
+// Sign the inferences and forecasts of a worker data bundle
+func signWorkerDataBundle(workerDataBundle *emissionstypes.InputWorkerDataBundle, worker *types.Actor) error {
 	src := make([]byte, 0)
 	src, err := workerDataBundle.InferenceForecastsBundle.XXX_Marshal(src, true)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	sig, err := inferer.TxParams.PrivKey.Sign(src)
+	sig, err := worker.TxParams.PrivKey.Sign(src)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	workerPublicKeyBytes := inferer.TxParams.PubKey.Bytes()
+	workerPublicKeyBytes := worker.TxParams.PubKey.Bytes()
 	workerDataBundle.InferencesForecastsBundleSignature = sig
 	workerDataBundle.Pubkey = hex.EncodeToString(workerPublicKeyBytes)
-
-	return workerDataBundle, nil
+	return nil
 }
 
 // Create and send reputer payloads
 func createAndSendReputerPayloads(
+	data *StressSimulationData,
+	config *types.Config,
 	topicId uint64,
 	reputers []*types.Actor,
 	workers []string,
@@ -294,6 +369,20 @@ func createAndSendReputerPayloads(
 
 	log.Info().Msgf("Starting reputer payload creation for %d reputers in topic: %d", len(reputers), topicId)
 
+	// With a value model, all reputers score the same network inferences against the ground truth
+	var networkInferences *emissionstypes.ValueBundle
+	var groundTruth float64
+	if data.Values != nil {
+		truth, ok := data.Values.GroundTruth(topicId, workerNonce)
+		if !ok {
+			log.Warn().Msgf("No ground truth for topic: %d at height: %d, reputers send random losses", topicId, workerNonce)
+		} else if inferences, err := lib.GetNetworkInferencesAtBlock(config, topicId, workerNonce); err != nil {
+			log.Error().Err(err).Msgf("Error getting network inferences for topic: %d at height: %d, reputers send random losses", topicId, workerNonce)
+		} else {
+			networkInferences, groundTruth = inferences, truth
+		}
+	}
+
 	for _, reputer := range reputers {
 		go func(reputer *types.Actor) {
 			defer func() {
@@ -307,48 +396,48 @@ func createAndSendReputerPayloads(
 				}
 			}()
 
-			valueBundle, err := createReputerValueBundle(topicId, reputer, workers, reputerNonce)
+			var losses emissionstypes.InputValueBundle
+			if networkInferences != nil {
+				var err error
+				losses, err = data.Values.ReputerLosses(reputer.Addr, groundTruth, networkInferences)
+				if err != nil {
+					log.Error().Err(err).Msgf("Error computing reputer losses: %v", err.Error())
+					return
+				}
+			} else {
+				losses = randomReputerLosses(workers)
+			}
+			valueBundle, err := createReputerValueBundle(topicId, reputer, losses, reputerNonce)
 			if err != nil {
 				log.Error().Err(err).Msgf("Error creating reputer value bundle: %v", err.Error())
 				return
 			}
 
-			_, updatedSeq, err := common.SendDataWithRetry(reputer.TxParams, false, &emissionstypes.InsertReputerPayloadRequest{
+			_, err = common.SendFromActor(reputer, false, &emissionstypes.InsertReputerPayloadRequest{
 				Sender:             reputer.Addr,
 				ReputerValueBundle: valueBundle,
 			})
 			if err != nil {
 				log.Error().Err(err).Msgf("Error sending reputer payload: %v", err.Error())
 			}
-			reputer.TxParams.Sequence = updatedSeq
 		}(reputer)
 	}
 
 	return false
 }
 
-// Generate the same valueBundle for a reputer
+// Sign the losses of a reputer
 func createReputerValueBundle(
 	topicId uint64,
 	reputer *types.Actor,
-	workers []string,
+	losses emissionstypes.InputValueBundle,
 	reputerNonce *emissionstypes.Nonce,
 ) (*emissionstypes.InputReputerValueBundle, error) {
-	valueBundle := emissionstypes.InputValueBundle{
-		TopicId:                topicId,
-		Reputer:                reputer.Addr,
-		ExtraData:              nil,
-		CombinedValue:          alloramath.MustNewCappedBoundedExp40DecFromString("100"),
-		InfererValues:          generateWorkerAttributedValueLosses(workers, 3000, 3500),
-		ForecasterValues:       generateWorkerAttributedValueLosses(workers, 50, 50),
-		NaiveValue:             alloramath.MustNewCappedBoundedExp40DecFromString("100"),
-		OneOutInfererValues:    generateWithheldWorkerAttributedValueLosses(workers, 50, 50),
-		OneOutForecasterValues: generateWithheldWorkerAttributedValueLosses(workers, 50, 50),
-		OneInForecasterValues:  generateWorkerAttributedValueLosses(workers, 50, 50),
-		ReputerRequestNonce: &emissionstypes.ReputerRequestNonce{
-			ReputerNonce: reputerNonce,
-		},
-		OneOutInfererForecasterValues: generateOneOutInfererForecasterValues(workers),
+	valueBundle := losses
+	valueBundle.TopicId = topicId
+	valueBundle.Reputer = reputer.Addr
+	valueBundle.ReputerRequestNonce = &emissionstypes.ReputerRequestNonce{
+		ReputerNonce: reputerNonce,
 	}
 
 	// Sign transaction
@@ -3,21 +3,19 @@ package stress
 import (
 	"io"
 	"sync"
-	"sync/atomic"
 
 	"github.com/rs/zerolog/log"
 
-	cosmosmath "cosmossdk.io/math"
 	emissionstypes "github.com/allora-network/allora-chain/x/emissions/types"
 
+	"github.com/allora-network/allora-simulator/lib"
 	"github.com/allora-network/allora-simulator/types"
 	"github.com/allora-network/allora-simulator/workloads/common"
 )
 
-const stakeToAdd uint64 = 9e4
-
 func CreateAndFundActors(
 	config *types.Config,
+	gasPrice *lib.GasPriceOracle,
 	faucetMnemonic []byte,
 	numActors int,
 	epochLength int64,
@@ -26,7 +24,7 @@ func CreateAndFundActors(
 	faucet *types.Actor,
 	simulationData *StressSimulationData,
 ) {
-	faucet, actorsList, _ := common.CreateAndFundActors(config, faucetMnemonic, numActors, rand)
+	faucet, actorsList, _ := common.CreateAndFundActors(config, gasPrice, faucetMnemonic, numActors, rand)
 
 	data := StressSimulationData{
 		Faucet:                    faucet,
@@ -36,6 +34,9 @@ func CreateAndFundActors(
 		RegisteredReputersByTopic: map[uint64][]*types.Actor{},
 		FailOnErr:                 false,
 		Mu:                        sync.RWMutex{},
+		FaultStats:                NewFaultStats(),
+		Batcher:                   common.NewBatcher(config),
+		GasPrice:                  gasPrice,
 	}
 
 	return faucet, &data
@@ -48,52 +49,28 @@ func RegisterWorkers(
 	data *StressSimulationData,
 	numWorkers int,
 ) error {
-	maxConcurrent := 1000
-	sem := make(chan struct{}, maxConcurrent)
-	completed := atomic.Int32{}
-
-	var wg sync.WaitGroup
 	log.Info().Msgf("Starting registration of %d workers in topic: %d\n", numWorkers, topicId)
 
-	// Process all workers without batching
-	for i := 0; i < numWorkers; i++ {
-		wg.Add(1)
-		worker := actors[i]
-
-		go func(worker *types.Actor, idx int) {
-			defer wg.Done()
-
-			sem <- struct{}{}
-			defer func() {
-				<-sem
-				count := completed.Add(1)
-				if int(count)%1000 == 0 || count == int32(numWorkers) {
-					log.Info().Msgf("Processed %d/%d worker registrations (%.2f%%) for topic: %d\n",
-						count, numWorkers,
-						float64(count)/float64(numWorkers)*100,
-						topicId,
-					)
-				}
-			}()
-
-			request := &emissionstypes.RegisterRequest{
+	msgs := make([]common.SignedMsg, numWorkers)
+	for i, worker := range actors[:numWorkers] {
+		msgs[i] = common.SignedMsg{
+			Signer: worker,
+			Msg: &emissionstypes.RegisterRequest{
 				Sender:    worker.Addr,
 				Owner:     worker.Addr,
 				IsReputer: false,
 				TopicId:   topicId,
-			}
-
-			_, updatedSeq, err := common.SendDataWithRetry(worker.TxParams, false, request)
-			if err != nil {
-				log.Error().Err(err).Msgf("Error sending worker registration: %v", err.Error())
-				return
-			}
-			worker.TxParams.Sequence = updatedSeq
-			data.AddWorkerRegistration(topicId, worker)
-		}(worker, i)
+			},
+		}
 	}
 
-	wg.Wait()
+	failed := data.Batcher.Send(false, msgs...)
+	for _, msg := range msgs {
+		if !common.ContainsMsg(failed, msg.Msg) {
+			data.AddWorkerRegistration(topicId, msg.Signer)
+		}
+	}
+	log.Info().Msgf("Registered %d/%d workers in topic: %d\n", numWorkers-len(failed), numWorkers, topicId)
 
 	return nil
 }
@@ -105,58 +82,40 @@ func RegisterReputersAndStake(
 	data *StressSimulationData,
 	numReputers int,
 ) error {
-	maxConcurrent := 1000
-	sem := make(chan struct{}, maxConcurrent)
-	completed := atomic.Int32{}
-
-	var wg sync.WaitGroup
 	log.Info().Msgf("Starting registration of %d reputers in topic: %d\n", numReputers, topicId)
 
-	// Process all reputers without batching
-	for i := 0; i < numReputers; i++ {
-		wg.Add(1)
-		reputer := actors[i]
-
-		go func(reputer *types.Actor, idx int) {
-			defer wg.Done()
-
-			sem <- struct{}{}
-			defer func() {
-				<-sem
-				count := completed.Add(1)
-				if int(count)%100 == 0 || count == int32(numReputers) {
-					log.Info().Msgf("Processed %d/%d reputer registrations (%.2f%%) for topic: %d\n",
-						count, numReputers,
-						float64(count)/float64(numReputers)*100,
-						topicId,
-					)
-				}
-			}()
-
-			registerRequest := &emissionstypes.RegisterRequest{
+	// The registration and stake of a reputer are batched together
+	msgs := make([]common.SignedMsg, 0, 2*numReputers)
+	registrations := make([]common.SignedMsg, numReputers)
+	for i, reputer := range actors[:numReputers] {
+		registrations[i] = common.SignedMsg{
+			Signer: reputer,
+			Msg: &emissionstypes.RegisterRequest{
 				Sender:    reputer.Addr,
 				Owner:     reputer.Addr,
 				IsReputer: true,
 				TopicId:   topicId,
-			}
-			stakeRequest := &emissionstypes.AddStakeRequest{
+			},
+		}
+		msgs = append(msgs, registrations[i], common.SignedMsg{
+			Signer: reputer,
+			Msg: &emissionstypes.AddStakeRequest{
 				Sender:  reputer.Addr,
 				TopicId: topicId,
-				Amount:  cosmosmath.NewIntFromUint64(stakeToAdd),
-			}
-
-			_, updatedSeq, err := common.SendDataWithRetry(reputer.TxParams, true, registerRequest, stakeRequest)
-			if err != nil {
-				log.Error().Err(err).Msgf("Error sending reputer stake: %v", err.Error())
-				return
-			}
-			reputer.TxParams.Sequence = updatedSeq
-
-			data.AddReputerRegistration(topicId, reputer)
-		}(reputer, i)
+				Amount:  common.DrawStake(reputer.TxParams.Config.Staking.Stake),
+			},
+		})
 	}
 
-	wg.Wait()
+	failed := data.Batcher.Send(true, msgs...)
+	registered := 0
+	for _, registration := range registrations {
+		if !common.ContainsMsg(failed, registration.Msg) {
+			data.AddReputerRegistration(topicId, registration.Signer)
+			registered++
+		}
+	}
+	log.Info().Msgf("Registered %d/%d reputers in topic: %d\n", registered, numReputers, topicId)
 
 	return nil
 }
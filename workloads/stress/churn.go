@@ -0,0 +1,42 @@
+package stress
+
+import (
+	cosmosmath "cosmossdk.io/math"
+	"github.com/allora-network/allora-simulator/types"
+	"github.com/allora-network/allora-simulator/workloads/common"
+)
+
+// Make workers and reputers join, leave and return to every topic while the loops run
+func runChurnLoop(
+	data *StressSimulationData,
+	config *types.Config,
+	topicIds []uint64,
+) {
+	state := common.NewChurnState(data.SpareActors)
+	common.RunRoundLoop(config, config.Churn.IntervalEpochs, topicIds, data.getEpochLength, nil, func(topicId uint64, height int64) {
+		addWorker := func(actor *types.Actor) { data.AddWorkerRegistration(topicId, actor) }
+		addReputer := func(actor *types.Actor) { data.AddReputerRegistration(topicId, actor) }
+
+		state.Round(config.Churn.Workers, common.ChurnRole{
+			TopicId:    topicId,
+			Name:       "worker",
+			Unregister: config.Churn.Unregister,
+			Join:       addWorker,
+			Remove:     func(actor *types.Actor) { data.RemoveWorkerRegistration(topicId, actor) },
+			Restore:    addWorker,
+			Return:     addWorker,
+		}, data.GetWorkersForTopic(topicId))
+
+		state.Round(config.Churn.Reputers, common.ChurnRole{
+			TopicId:    topicId,
+			Name:       "reputer",
+			IsReputer:  true,
+			Stake:      func() cosmosmath.Int { return common.DrawStake(config.Staking.Stake) },
+			Unregister: config.Churn.Unregister,
+			Join:       addReputer,
+			Remove:     func(actor *types.Actor) { data.RemoveReputerRegistration(topicId, actor) },
+			Restore:    addReputer,
+			Return:     addReputer,
+		}, data.GetReputersForTopic(topicId))
+	})
+}
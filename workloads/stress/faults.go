@@ -0,0 +1,318 @@
+package stress
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+
+	errorsmod "cosmossdk.io/errors"
+	alloramath "github.com/allora-network/allora-chain/math"
+	emissionstypes "github.com/allora-network/allora-chain/x/emissions/types"
+	"github.com/allora-network/allora-simulator/types"
+	"github.com/allora-network/allora-simulator/workloads/common"
+	coretypes "github.com/cometbft/cometbft/rpc/core/types"
+	"github.com/cosmos/cosmos-sdk/crypto/keys/secp256k1"
+	sdktypes "github.com/cosmos/cosmos-sdk/types"
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+	gogoproto "github.com/cosmos/gogoproto/proto"
+	"github.com/rs/zerolog/log"
+)
+
+// Kinds of faulty worker payloads
+const (
+	FaultLate                = "late"
+	FaultDuplicate           = "duplicate"
+	FaultBadSignature        = "bad_signature"
+	FaultMismatchedPubkey    = "mismatched_pubkey"
+	FaultWrongTopic          = "wrong_topic"
+	FaultUnregisteredInferer = "unregistered_inferer"
+	FaultOutOfBounds         = "out_of_bounds"
+)
+
+// Offset added to the topic id of wrong topic payloads, so they target a topic that does not exist
+const wrongTopicOffset uint64 = 1_000_000
+
+// An out of bounds value is written over a valid placeholder of the same length,
+// as BoundedExp40Dec refuses to encode it
+const (
+	outOfBoundsPlaceholder = "9E+39"
+	outOfBoundsValue       = "1E+41"
+)
+
+// rejection is the codespace and code of an ABCI error
+type rejection struct {
+	codespace string
+	code      uint32
+}
+
+func rejectionOf(err *errorsmod.Error) rejection {
+	return rejection{codespace: err.Codespace(), code: err.ABCICode()}
+}
+
+// Rejections each kind of faulty payload is expected to get. Any rejection is expected when empty.
+var expectedRejections = map[string][]rejection{
+	FaultLate: {
+		rejectionOf(emissionstypes.ErrWorkerNonceWindowNotAvailable),
+		rejectionOf(emissionstypes.ErrUnfulfilledNonceNotFound),
+	},
+	// A second inference in the same epoch fails with an unregistered error, which ABCI reports as undefined 1
+	FaultDuplicate: {
+		{codespace: errorsmod.UndefinedCodespace, code: 1},
+		rejectionOf(emissionstypes.ErrCantUpdateEmaMoreThanOncePerWindow),
+	},
+	FaultBadSignature:        {rejectionOf(sdkerrors.ErrUnauthorized)},
+	FaultMismatchedPubkey:    {rejectionOf(sdkerrors.ErrUnauthorized)},
+	FaultWrongTopic:          {rejectionOf(emissionstypes.ErrInvalidTopicId)},
+	FaultUnregisteredInferer: nil,
+	FaultOutOfBounds:         {rejectionOf(sdkerrors.ErrTxDecode)},
+}
+
+// Whether the chain rejected a faulty payload of a kind as expected
+func isExpectedRejection(kind string, codespace string, code uint32) bool {
+	if code == 0 {
+		return false
+	}
+	expected := expectedRejections[kind]
+	if len(expected) == 0 {
+		return true
+	}
+	for _, r := range expected {
+		if r.codespace == codespace && r.code == code {
+			return true
+		}
+	}
+	return false
+}
+
+// FaultOutcomes counts the outcomes of the faulty payloads of a kind
+type FaultOutcomes struct {
+	Sent     int
+	Rejected int // rejected with an expected code
+	Accepted int
+	// Rejected with another code
+	Unexpected int
+}
+
+// FaultStats tallies the outcomes of the faulty payloads sent during a run.
+// It is safe for concurrent use by the worker loops.
+type FaultStats struct {
+	mu       sync.Mutex
+	outcomes map[string]*FaultOutcomes
+}
+
+func NewFaultStats() *FaultStats {
+	return &FaultStats{outcomes: make(map[string]*FaultOutcomes)}
+}
+
+// Record the response of the chain to a faulty payload, returns whether it was rejected as expected
+func (s *FaultStats) record(kind string, resp *coretypes.ResultBroadcastTx) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	outcomes, ok := s.outcomes[kind]
+	if !ok {
+		outcomes = &FaultOutcomes{}
+		s.outcomes[kind] = outcomes
+	}
+	outcomes.Sent++
+	switch {
+	case resp.Code == 0:
+		outcomes.Accepted++
+		log.Error().Msgf("Faulty %s payload was accepted by the chain: %s", kind, resp.Hash)
+		return false
+	case isExpectedRejection(kind, resp.Codespace, resp.Code):
+		outcomes.Rejected++
+		return true
+	default:
+		outcomes.Unexpected++
+		log.Error().Msgf("Faulty %s payload was rejected with unexpected code %s/%d: %s", kind, resp.Codespace, resp.Code, resp.Log)
+		return false
+	}
+}
+
+// Unexpected returns how many faulty payloads were accepted or rejected with an unexpected code
+func (s *FaultStats) Unexpected() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	total := 0
+	for _, outcomes := range s.outcomes {
+		total += outcomes.Accepted + outcomes.Unexpected
+	}
+	return total
+}
+
+// Outcomes returns a copy of the outcomes of every kind of faulty payload sent
+func (s *FaultStats) Outcomes() map[string]FaultOutcomes {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	res := make(map[string]FaultOutcomes, len(s.outcomes))
+	for kind, outcomes := range s.outcomes {
+		res[kind] = *outcomes
+	}
+	return res
+}
+
+// LogSummary logs the outcomes of every kind of faulty payload sent
+func (s *FaultStats) LogSummary() {
+	outcomes := s.Outcomes()
+	kinds := make([]string, 0, len(outcomes))
+	for kind := range outcomes {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	for _, kind := range kinds {
+		o := outcomes[kind]
+		log.Info().Msgf("Faulty %s payloads: %d sent, %d rejected as expected, %d accepted, %d rejected with unexpected codes",
+			kind, o.Sent, o.Rejected, o.Accepted, o.Unexpected)
+	}
+}
+
+// Whether any kind of faulty payload is configured
+func injectsFaults(faults types.FaultConfig) bool {
+	return faults.Late > 0 || faults.Duplicate > 0 || faults.BadSignature > 0 || faults.MismatchedPubkey > 0 ||
+		faults.WrongTopic > 0 || faults.UnregisteredInferer > 0 || faults.OutOfBounds > 0
+}
+
+// Send the faulty payloads drawn for a worker in an epoch, after its valid payload.
+// payload is the valid payload of the epoch, nil when the chain did not accept it.
+func injectFaults(
+	faults types.FaultConfig,
+	stats *FaultStats,
+	worker *types.Actor,
+	topicId uint64,
+	workerNonce int64,
+	previousNonce int64,
+	forecastInferersAddresses []string,
+	payload *emissionstypes.InsertWorkerPayloadRequest,
+) {
+	rates := []struct {
+		kind string
+		rate float64
+	}{
+		{FaultLate, faults.Late},
+		{FaultDuplicate, faults.Duplicate},
+		{FaultBadSignature, faults.BadSignature},
+		{FaultMismatchedPubkey, faults.MismatchedPubkey},
+		{FaultWrongTopic, faults.WrongTopic},
+		{FaultUnregisteredInferer, faults.UnregisteredInferer},
+		{FaultOutOfBounds, faults.OutOfBounds},
+	}
+	for _, r := range rates {
+		if rand.Float64() >= r.rate {
+			continue
+		}
+		// Late payloads are sent for the previous nonce, whose window closed, and duplicates need an accepted payload
+		if (r.kind == FaultLate && previousNonce == 0) || (r.kind == FaultDuplicate && payload == nil) {
+			continue
+		}
+		msg, err := createFaultyWorkerPayload(r.kind, worker, topicId, workerNonce, previousNonce, forecastInferersAddresses, payload)
+		if err != nil {
+			log.Error().Err(err).Msgf("Error creating faulty %s payload", r.kind)
+			continue
+		}
+		unlock := common.LockActor(worker)
+		resp, updatedSeq, err := common.SendDataOnce(worker.TxParams, msg)
+		if err == nil {
+			worker.TxParams.Sequence = updatedSeq
+		}
+		unlock()
+		if err != nil {
+			log.Error().Err(err).Msgf("Error sending faulty %s payload", r.kind)
+			continue
+		}
+		stats.record(r.kind, resp)
+	}
+}
+
+// Create a faulty worker payload of a kind
+func createFaultyWorkerPayload(
+	kind string,
+	worker *types.Actor,
+	topicId uint64,
+	workerNonce int64,
+	previousNonce int64,
+	forecastInferersAddresses []string,
+	payload *emissionstypes.InsertWorkerPayloadRequest,
+) (sdktypes.Msg, error) {
+	if kind == FaultDuplicate {
+		return payload, nil
+	}
+
+	bundleTopicId, nonce := topicId, workerNonce
+	switch kind {
+	case FaultLate:
+		nonce = previousNonce
+	case FaultWrongTopic:
+		bundleTopicId = topicId + wrongTopicOffset
+	}
+	bundle, err := createWorkerDataBundle(bundleTopicId, nonce, worker, randomWorkerValues(forecastInferersAddresses))
+	if err != nil {
+		return nil, err
+	}
+
+	switch kind {
+	case FaultBadSignature:
+		bundle.InferencesForecastsBundleSignature[0] ^= 0xff
+	case FaultMismatchedPubkey:
+		bundle.Pubkey = hex.EncodeToString(secp256k1.GenPrivKey().PubKey().Bytes())
+	case FaultUnregisteredInferer:
+		// Forecast only, the inference of the epoch was already submitted
+		bundle.InferenceForecastsBundle.Inference = nil
+		bundle.InferenceForecastsBundle.Forecast = &emissionstypes.InputForecast{
+			TopicId:     topicId,
+			BlockHeight: workerNonce,
+			Forecaster:  worker.Addr,
+			ForecastElements: []*emissionstypes.InputForecastElement{{
+				Inferer: sdktypes.AccAddress(secp256k1.GenPrivKey().PubKey().Address()).String(),
+				Value:   alloramath.MustNewCappedBoundedExp40DecFromString("100"),
+			}},
+		}
+		if err := signWorkerDataBundle(bundle, worker); err != nil {
+			return nil, err
+		}
+	case FaultOutOfBounds:
+		bundle.InferenceForecastsBundle.Inference.Value = alloramath.MustNewBoundedExp40DecFromString(outOfBoundsPlaceholder)
+		if err := signWorkerDataBundle(bundle, worker); err != nil {
+			return nil, err
+		}
+		return newOutOfBoundsPayload(&emissionstypes.InsertWorkerPayloadRequest{
+			Sender:           worker.Addr,
+			WorkerDataBundle: bundle,
+		})
+	}
+
+	return &emissionstypes.InsertWorkerPayloadRequest{
+		Sender:           worker.Addr,
+		WorkerDataBundle: bundle,
+	}, nil
+}
+
+// rawMsg is a message sent with a given encoding, for payloads the chain types refuse to encode
+type rawMsg struct {
+	name  string
+	value []byte
+}
+
+func (m *rawMsg) Reset()                   {}
+func (m *rawMsg) String() string           { return string(m.value) }
+func (m *rawMsg) ProtoMessage()            {}
+func (m *rawMsg) XXX_MessageName() string  { return m.name }
+func (m *rawMsg) Marshal() ([]byte, error) { return m.value, nil }
+
+// Encode a payload holding the out of bounds placeholder, and write the out of bounds value over it
+func newOutOfBoundsPayload(payload *emissionstypes.InsertWorkerPayloadRequest) (*rawMsg, error) {
+	value, err := payload.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	if count := bytes.Count(value, []byte(outOfBoundsPlaceholder)); count != 1 {
+		return nil, fmt.Errorf("expected the out of bounds placeholder once in the payload, found it %d times", count)
+	}
+	return &rawMsg{
+		name:  gogoproto.MessageName(payload),
+		value: bytes.Replace(value, []byte(outOfBoundsPlaceholder), []byte(outOfBoundsValue), 1),
+	}, nil
+}
@@ -0,0 +1,72 @@
+package stress
+
+import (
+	"strings"
+	"testing"
+
+	alloramath "github.com/allora-network/allora-chain/math"
+	emissionstypes "github.com/allora-network/allora-chain/x/emissions/types"
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+)
+
+func TestIsExpectedRejection(t *testing.T) {
+	unauthorized := rejectionOf(sdkerrors.ErrUnauthorized)
+	windowClosed := rejectionOf(emissionstypes.ErrWorkerNonceWindowNotAvailable)
+
+	cases := []struct {
+		kind      string
+		codespace string
+		code      uint32
+		expected  bool
+	}{
+		{FaultBadSignature, unauthorized.codespace, unauthorized.code, true},
+		{FaultBadSignature, windowClosed.codespace, windowClosed.code, false},
+		{FaultBadSignature, "", 0, false},
+		{FaultLate, windowClosed.codespace, windowClosed.code, true},
+		// Same code in another codespace
+		{FaultLate, "other", windowClosed.code, false},
+		{FaultUnregisteredInferer, windowClosed.codespace, windowClosed.code, true},
+		{FaultUnregisteredInferer, "", 0, false},
+	}
+	for _, c := range cases {
+		if got := isExpectedRejection(c.kind, c.codespace, c.code); got != c.expected {
+			t.Errorf("isExpectedRejection(%s, %s, %d) = %v, expected %v", c.kind, c.codespace, c.code, got, c.expected)
+		}
+	}
+}
+
+func TestNewOutOfBoundsPayload(t *testing.T) {
+	payload := &emissionstypes.InsertWorkerPayloadRequest{
+		Sender: "worker",
+		WorkerDataBundle: &emissionstypes.InputWorkerDataBundle{
+			Worker: "worker",
+			InferenceForecastsBundle: &emissionstypes.InputInferenceForecastBundle{
+				Inference: &emissionstypes.InputInference{
+					TopicId: 1,
+					Inferer: "worker",
+					Value:   alloramath.MustNewBoundedExp40DecFromString(outOfBoundsPlaceholder),
+				},
+			},
+		},
+	}
+	msg, err := newOutOfBoundsPayload(payload)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The chain fails to decode the value
+	err = (&emissionstypes.InsertWorkerPayloadRequest{}).Unmarshal(msg.value)
+	if err == nil || !strings.Contains(err.Error(), "out of range") {
+		t.Errorf("expected an out of range error decoding the payload, got %v", err)
+	}
+
+	// The raw message packs as the payload type
+	anyMsg, err := codectypes.NewAnyWithValue(msg)
+	if err != nil {
+		t.Fatalf("unexpected error packing the payload: %v", err)
+	}
+	if anyMsg.TypeUrl != "/emissions.v9.InsertWorkerPayloadRequest" {
+		t.Errorf("unexpected type url %s", anyMsg.TypeUrl)
+	}
+}
@@ -0,0 +1,114 @@
+package stress
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+
+	alloramath "github.com/allora-network/allora-chain/math"
+	emissionstypes "github.com/allora-network/allora-chain/x/emissions/types"
+	"github.com/allora-network/allora-simulator/types"
+)
+
+// Inferers covered by the forecasts of stress workers
+const (
+	ForecastPreviousActive = "previous_active"
+	ForecastAllRegistered  = "all_registered"
+)
+
+const proofAlphabet = "abcdefghijklmnopqrstuvwxyz0123456789"
+
+// ValidatePayloadConfig checks the payload shape of the stress workload
+func ValidatePayloadConfig(payload types.PayloadConfig) error {
+	switch payload.ForecastInferers {
+	case "", ForecastPreviousActive, ForecastAllRegistered:
+	default:
+		return fmt.Errorf("unknown forecast inferers: %s", payload.ForecastInferers)
+	}
+	if payload.ForecastElements < 0 || payload.ExtraDataBytes < 0 || payload.ProofBytes < 0 || payload.ValueDecimals < 0 {
+		return fmt.Errorf("payload sizes cannot be negative")
+	}
+	return nil
+}
+
+// Addresses of the inferers the forecasts of an epoch cover
+func forecastInferers(payload types.PayloadConfig, previousActiveInferersAddresses []string, workers []*types.Actor) []string {
+	if payload.ForecastInferers != ForecastAllRegistered {
+		return previousActiveInferersAddresses
+	}
+	addresses := make([]string, len(workers))
+	for i, worker := range workers {
+		addresses[i] = worker.Addr
+	}
+	return addresses
+}
+
+// Shape the values of a worker to the configured payload size
+func shapeWorkerValues(payload types.PayloadConfig, values workerValues) workerValues {
+	shaped := workerValues{
+		inference: withDecimals(values.inference, payload.ValueDecimals),
+		forecast:  resizeForecast(values.forecast, payload.ForecastElements),
+	}
+	if payload.ValueDecimals > 0 {
+		// The elements may be shared with the value model, write new ones
+		forecast := make([]*emissionstypes.InputForecastElement, len(shaped.forecast))
+		for i, element := range shaped.forecast {
+			forecast[i] = &emissionstypes.InputForecastElement{
+				Inferer: element.Inferer,
+				Value:   withDecimals(element.Value, payload.ValueDecimals),
+			}
+		}
+		shaped.forecast = forecast
+	}
+	if payload.ExtraDataBytes > 0 {
+		shaped.extraData = make([]byte, payload.ExtraDataBytes)
+		rand.Read(shaped.extraData)
+	}
+	if payload.ProofBytes > 0 {
+		proof := make([]byte, payload.ProofBytes)
+		for i := range proof {
+			proof[i] = proofAlphabet[rand.Intn(len(proofAlphabet))]
+		}
+		shaped.proof = string(proof)
+	}
+	return shaped
+}
+
+// Cycle over the forecast elements until there are n of them, n = 0 keeps them as they are.
+// The chain ignores repeated inferers, they only make the payload larger.
+func resizeForecast(elements []*emissionstypes.InputForecastElement, n int) []*emissionstypes.InputForecastElement {
+	if n == 0 || len(elements) == 0 {
+		return elements
+	}
+	resized := make([]*emissionstypes.InputForecastElement, n)
+	for i := range resized {
+		resized[i] = elements[i%len(elements)]
+	}
+	return resized
+}
+
+// Write a value with exactly decimals digits after the decimal point, decimals = 0 keeps it as it is.
+// The digits a float64 cannot hold are random.
+func withDecimals(value alloramath.BoundedExp40Dec, decimals int) alloramath.BoundedExp40Dec {
+	if decimals == 0 {
+		return value
+	}
+	f, err := strconv.ParseFloat(value.String(), 64)
+	if err != nil {
+		return value
+	}
+	intPart, fracPart, _ := strings.Cut(strconv.FormatFloat(f, 'f', -1, 64), ".")
+	digits := []byte(fracPart)
+	if len(digits) > decimals {
+		digits = digits[:decimals]
+	}
+	for len(digits) < decimals {
+		digits = append(digits, byte('0'+rand.Intn(10)))
+	}
+	shaped, err := alloramath.NewCappedBoundedExp40DecFromString(intPart + "." + string(digits))
+	if err != nil {
+		return value
+	}
+	return shaped
+}
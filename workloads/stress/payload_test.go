@@ -0,0 +1,60 @@
+package stress
+
+import (
+	"strings"
+	"testing"
+
+	alloramath "github.com/allora-network/allora-chain/math"
+	emissionstypes "github.com/allora-network/allora-chain/x/emissions/types"
+	"github.com/allora-network/allora-simulator/types"
+)
+
+func TestShapeWorkerValues(t *testing.T) {
+	values := workerValues{
+		inference: alloramath.MustNewCappedBoundedExp40DecFromString("3100"),
+		forecast: []*emissionstypes.InputForecastElement{
+			{Inferer: "inferer0", Value: alloramath.MustNewCappedBoundedExp40DecFromString("0.5")},
+			{Inferer: "inferer1", Value: alloramath.MustNewCappedBoundedExp40DecFromString("0.25")},
+		},
+	}
+	shaped := shapeWorkerValues(types.PayloadConfig{
+		ForecastElements: 5,
+		ExtraDataBytes:   100,
+		ProofBytes:       50,
+		ValueDecimals:    30,
+	}, values)
+
+	if len(shaped.forecast) != 5 || shaped.forecast[2].Inferer != "inferer0" || shaped.forecast[3].Inferer != "inferer1" {
+		t.Errorf("expected 5 forecast elements cycling over the inferers, got %v", shaped.forecast)
+	}
+	if len(shaped.extraData) != 100 || len(shaped.proof) != 50 {
+		t.Errorf("expected 100 bytes of extra data and 50 of proof, got %d and %d", len(shaped.extraData), len(shaped.proof))
+	}
+	intPart, fracPart, _ := strings.Cut(shaped.inference.String(), ".")
+	if intPart != "3100" || len(fracPart) != 30 {
+		t.Errorf("expected 3100 with 30 decimals, got %s", shaped.inference.String())
+	}
+	if !strings.HasPrefix(shaped.forecast[1].Value.String(), "0.25") {
+		t.Errorf("expected the decimals of the value to be kept, got %s", shaped.forecast[1].Value.String())
+	}
+	if values.forecast[0].Value.String() != "0.5" {
+		t.Errorf("expected the original values to be left untouched, got %s", values.forecast[0].Value.String())
+	}
+
+	// Fewer elements than inferers
+	if shaped := shapeWorkerValues(types.PayloadConfig{ForecastElements: 1}, values); len(shaped.forecast) != 1 {
+		t.Errorf("expected 1 forecast element, got %d", len(shaped.forecast))
+	}
+}
+
+func TestValidatePayloadConfig(t *testing.T) {
+	if err := ValidatePayloadConfig(types.PayloadConfig{ForecastInferers: ForecastAllRegistered, ForecastElements: 10}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := ValidatePayloadConfig(types.PayloadConfig{ForecastInferers: "other"}); err == nil {
+		t.Errorf("expected an error for unknown forecast inferers")
+	}
+	if err := ValidatePayloadConfig(types.PayloadConfig{ProofBytes: -1}); err == nil {
+		t.Errorf("expected an error for a negative size")
+	}
+}
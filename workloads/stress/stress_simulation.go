@@ -3,7 +3,9 @@ package stress
 import (
 	"sync"
 
+	"github.com/allora-network/allora-simulator/lib"
 	"github.com/allora-network/allora-simulator/types"
+	"github.com/allora-network/allora-simulator/workloads/common"
 )
 
 type StressSimulationData struct {
@@ -14,6 +16,18 @@ type StressSimulationData struct {
 	RegisteredReputersByTopic map[uint64][]*types.Actor
 	FailOnErr                 bool
 	Mu                        sync.RWMutex
+	// Funded actors outside the initial population, joined to topics by churn
+	SpareActors []*types.Actor
+	// Funded actors delegating stake to reputers
+	Delegators []*types.Actor
+	// Outcomes of the faulty payloads sent by workers
+	FaultStats *FaultStats
+	// Model the values of workers and reputers derive from, nil when they are random
+	Values *ValueModel
+	// Packs the messages of each actor in transactions
+	Batcher *common.Batcher
+	// Gas price the actors pay, refreshed by the actor loops
+	GasPrice *lib.GasPriceOracle
 }
 
 type Registration struct {
@@ -21,6 +35,11 @@ type Registration struct {
 	Actor   *types.Actor
 }
 
+// Every stress topic is created with the same epoch length
+func (s *StressSimulationData) getEpochLength(topicId uint64) int64 {
+	return s.EpochLength
+}
+
 // Add a worker registration to the simulation data
 func (s *StressSimulationData) AddWorkerRegistration(topicId uint64, actor *types.Actor) {
 	s.Mu.Lock()
@@ -35,6 +54,20 @@ func (s *StressSimulationData) AddReputerRegistration(topicId uint64, actor *typ
 	s.RegisteredReputersByTopic[topicId] = append(s.RegisteredReputersByTopic[topicId], actor)
 }
 
+// Remove a worker registration from the simulation data
+func (s *StressSimulationData) RemoveWorkerRegistration(topicId uint64, actor *types.Actor) {
+	s.Mu.Lock()
+	defer s.Mu.Unlock()
+	s.RegisteredWorkersByTopic[topicId] = common.RemoveActor(s.RegisteredWorkersByTopic[topicId], actor.Addr)
+}
+
+// Remove a reputer registration from the simulation data
+func (s *StressSimulationData) RemoveReputerRegistration(topicId uint64, actor *types.Actor) {
+	s.Mu.Lock()
+	defer s.Mu.Unlock()
+	s.RegisteredReputersByTopic[topicId] = common.RemoveActor(s.RegisteredReputersByTopic[topicId], actor.Addr)
+}
+
 // Get an actor object from an address
 func (s *StressSimulationData) GetActorFromAddr(addr string) (*types.Actor, bool) {
 	s.Mu.RLock()
@@ -18,6 +18,9 @@ import (
 
 const topicFunds int64 = 1e6
 
+// Loss method of the stress topics, model values are scored with it
+const lossMethod = "mse"
+
 // Creates multiple topics in a single broadcast or separate broadcasts
 func CreateTopics(
 	actor *types.Actor,
@@ -42,7 +45,7 @@ func CreateTopics(
 			requests[i] = &emissionstypes.CreateNewTopicRequest{
 				Creator:                  actor.Addr,
 				Metadata:                 fmt.Sprintf("Created topic %d", i+1),
-				LossMethod:               "mse",
+				LossMethod:               lossMethod,
 				EpochLength:              epochLength,
 				GroundTruthLag:           epochLength,
 				WorkerSubmissionWindow:   10,
@@ -79,7 +82,7 @@ func CreateTopics(
 			request := &emissionstypes.CreateNewTopicRequest{
 				Creator:                  actor.Addr,
 				Metadata:                 fmt.Sprintf("Created topic %d", i+1),
-				LossMethod:               "mse",
+				LossMethod:               lossMethod,
 				EpochLength:              epochLength,
 				GroundTruthLag:           epochLength,
 				WorkerSubmissionWindow:   10,
@@ -113,31 +116,28 @@ func CreateTopics(
 	}
 }
 
-// broadcast a tx to fund a topic
+// broadcast the txs funding the topics, batched within the limits of the batcher
 func FundTopics(
 	actor *types.Actor,
 	topicIds []uint64,
+	batcher *common.Batcher,
 ) error {
-	requests := make([]*emissionstypes.FundTopicRequest, len(topicIds))
+	msgs := make([]common.SignedMsg, len(topicIds))
 	for i, topicId := range topicIds {
-		requests[i] = &emissionstypes.FundTopicRequest{
-			Sender:  actor.Addr,
-			TopicId: topicId,
-			Amount:  math.NewInt(topicFunds),
+		msgs[i] = common.SignedMsg{
+			Signer: actor,
+			Msg: &emissionstypes.FundTopicRequest{
+				Sender:  actor.Addr,
+				TopicId: topicId,
+				Amount:  math.NewInt(topicFunds),
+			},
 		}
 		log.Info().Msgf("Funding topic: %d with amount: %d from: %s", topicId, topicFunds, actor.Addr)
 	}
 
-	protoMsgs := make([]proto.Message, len(requests))
-	for i, req := range requests {
-		protoMsgs[i] = req
-	}
-
-	_, updatedSeq, err := common.SendDataWithRetry(actor.TxParams, true, protoMsgs...)
-	if err != nil {
-		return fmt.Errorf("failed to broadcast fund topic requests: %w", err)
+	if failed := batcher.Send(true, msgs...); len(failed) > 0 {
+		return fmt.Errorf("failed to broadcast %d fund topic requests", len(failed))
 	}
-	actor.TxParams.Sequence = updatedSeq
 
 	return nil
 }
@@ -0,0 +1,223 @@
+package stress
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"sync"
+
+	alloramath "github.com/allora-network/allora-chain/math"
+	emissionstypes "github.com/allora-network/allora-chain/x/emissions/types"
+	"github.com/allora-network/allora-simulator/types"
+	"github.com/allora-network/allora-simulator/workloads/research"
+)
+
+// Value modes of the stress workload
+const (
+	ValuesRandom = "random"
+	ValuesModel  = "model"
+)
+
+// Ground truths kept per topic for the reputers, which score epochs a ground truth lag after the workers
+const keptGroundTruths = 16
+
+// ValidateStressValuesConfig checks the value mode of the stress workload
+func ValidateStressValuesConfig(values types.StressValuesConfig) error {
+	switch values.Mode {
+	case "", ValuesRandom, ValuesModel:
+		return nil
+	default:
+		return fmt.Errorf("unknown stress value mode: %s", values.Mode)
+	}
+}
+
+// workerValues are the inference and forecast elements a worker submits in an epoch
+type workerValues struct {
+	inference alloramath.BoundedExp40Dec
+	forecast  []*emissionstypes.InputForecastElement
+	// Padding of the payload, unused by the chain
+	extraData []byte
+	proof     string
+}
+
+// Independent random values, the original stress workload
+func randomWorkerValues(forecastInferersAddresses []string) workerValues {
+	forecastElements := make([]*emissionstypes.InputForecastElement, 0)
+	for _, forecastInfererAddress := range forecastInferersAddresses {
+		forecastElements = append(forecastElements, &emissionstypes.InputForecastElement{
+			Inferer: forecastInfererAddress,
+			Value:   alloramath.MustNewCappedBoundedExp40DecFromString(fmt.Sprintf("%d", rand.Intn(51)+50)),
+		})
+	}
+	return workerValues{
+		inference: alloramath.MustNewCappedBoundedExp40DecFromString(fmt.Sprintf("%d", rand.Intn(300)+3000)),
+		forecast:  forecastElements,
+	}
+}
+
+// Independent random losses, the original stress workload
+func randomReputerLosses(workers []string) emissionstypes.InputValueBundle {
+	return emissionstypes.InputValueBundle{
+		CombinedValue:                 alloramath.MustNewCappedBoundedExp40DecFromString("100"),
+		InfererValues:                 generateWorkerAttributedValueLosses(workers, 3000, 3500),
+		ForecasterValues:              generateWorkerAttributedValueLosses(workers, 50, 50),
+		NaiveValue:                    alloramath.MustNewCappedBoundedExp40DecFromString("100"),
+		OneOutInfererValues:           generateWithheldWorkerAttributedValueLosses(workers, 50, 50),
+		OneOutForecasterValues:        generateWithheldWorkerAttributedValueLosses(workers, 50, 50),
+		OneInForecasterValues:         generateWorkerAttributedValueLosses(workers, 50, 50),
+		OneOutInfererForecasterValues: generateOneOutInfererForecasterValues(workers),
+	}
+}
+
+// Values a worker submits in an epoch, drawn by the value model when the run has one, in the configured payload shape
+func (s *StressSimulationData) workerValues(payload types.PayloadConfig, topicId uint64, addr string, forecastInferersAddresses []string) workerValues {
+	if s.Values != nil {
+		if values, ok := s.Values.WorkerValues(topicId, addr); ok {
+			return shapeWorkerValues(payload, values)
+		}
+	}
+	return shapeWorkerValues(payload, randomWorkerValues(forecastInferersAddresses))
+}
+
+// topicValues is the state of the value model for one topic
+type topicValues struct {
+	groundTruth *types.GroundTruthState
+	epochs      int64
+	// Ground truth of the latest worker nonces
+	groundTruths map[int64]float64
+	nonces       []int64
+	// Values of the workers for the latest worker nonce
+	values map[string]workerValues
+}
+
+// ValueModel derives the values of the stress workers and reputers from the research models:
+// every topic follows a ground truth process, workers predict it with their own noise and experience,
+// forecasters predict the losses of the inferences, and reputers score the network inferences against it.
+// It is safe for concurrent use by the actor loops.
+type ValueModel struct {
+	mu           sync.RWMutex
+	config       *types.ResearchConfig
+	groundTruth  research.GroundTruthProcess
+	lossFn       research.LossFunction
+	topics       map[uint64]*topicValues
+	actorsParams map[string]*types.ResearchParams
+}
+
+func NewValueModel(config *types.ResearchConfig) (*ValueModel, error) {
+	groundTruth, err := research.NewGroundTruthProcess(config)
+	if err != nil {
+		return nil, err
+	}
+	lossFn, err := research.GetLossFunction(lossMethod, 0)
+	if err != nil {
+		return nil, err
+	}
+	return &ValueModel{
+		config:       config,
+		groundTruth:  groundTruth,
+		lossFn:       lossFn,
+		topics:       make(map[uint64]*topicValues),
+		actorsParams: make(map[string]*types.ResearchParams),
+	}, nil
+}
+
+// Research params of an actor, drawn on first use
+func (m *ValueModel) params(addr string, reputer bool) *types.ResearchParams {
+	if params, ok := m.actorsParams[addr]; ok {
+		return params
+	}
+	params := research.InitializeWorkerResearchParams(m.config.Volatility)
+	if reputer {
+		params = research.InitializeReputerResearchParams()
+	}
+	m.actorsParams[addr] = params
+	return params
+}
+
+// NextEpoch advances the ground truth of a topic to a worker nonce and draws the values of its workers.
+// Forecasts predict the losses of the inferences of the forecasted inferers.
+func (m *ValueModel) NextEpoch(topicId uint64, workerNonce int64, workers []*types.Actor, forecastInferersAddresses []string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	topic, ok := m.topics[topicId]
+	if !ok {
+		topic = &topicValues{
+			groundTruth:  m.groundTruth.InitialState(),
+			groundTruths: make(map[int64]float64),
+		}
+		m.topics[topicId] = topic
+	} else {
+		topic.groundTruth = m.groundTruth.Next(topic.groundTruth)
+		topic.epochs++
+	}
+	truth := topic.groundTruth.CurrentPrice
+	topic.groundTruths[workerNonce] = truth
+	topic.nonces = append(topic.nonces, workerNonce)
+	if len(topic.nonces) > keptGroundTruths {
+		delete(topic.groundTruths, topic.nonces[0])
+		topic.nonces = topic.nonces[1:]
+	}
+
+	noise := research.FreshNoise()
+	inferences := make(map[string]alloramath.BoundedExp40Dec, len(workers))
+	for _, worker := range workers {
+		params := m.params(worker.Addr, false)
+		inferences[worker.Addr] = research.GetInfererOutput(noise, m.config, truth, params.Error, params.Bias, int(topic.epochs), false)
+	}
+
+	lossObs := make([]research.LossObs, 0, len(forecastInferersAddresses))
+	for _, addr := range forecastInferersAddresses {
+		inference, ok := inferences[addr]
+		if !ok {
+			// Left the topic since
+			continue
+		}
+		value, err := strconv.ParseFloat(inference.String(), 64)
+		if err != nil {
+			continue
+		}
+		lossObs = append(lossObs, research.LossObs{InfererAddr: addr, Loss: research.GetLosses(m.lossFn, truth, value)})
+	}
+
+	topic.values = make(map[string]workerValues, len(workers))
+	for _, worker := range workers {
+		params := m.params(worker.Addr, false)
+		topic.values[worker.Addr] = workerValues{
+			inference: inferences[worker.Addr],
+			forecast:  research.GetForecasterOutput(noise, m.config, lossObs, params.Error, params.Bias, params.ContextSensitivity, int(topic.epochs)),
+		}
+	}
+}
+
+// WorkerValues returns the values drawn for a worker in the latest epoch of a topic
+func (m *ValueModel) WorkerValues(topicId uint64, addr string) (workerValues, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	topic, ok := m.topics[topicId]
+	if !ok {
+		return workerValues{}, false
+	}
+	values, ok := topic.values[addr]
+	return values, ok
+}
+
+// GroundTruth returns the ground truth the workers of a topic predicted at a worker nonce
+func (m *ValueModel) GroundTruth(topicId uint64, workerNonce int64) (float64, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	topic, ok := m.topics[topicId]
+	if !ok {
+		return 0, false
+	}
+	truth, ok := topic.groundTruths[workerNonce]
+	return truth, ok
+}
+
+// ReputerLosses scores the network inferences against the ground truth, with the noise of the reputer
+func (m *ValueModel) ReputerLosses(reputer string, groundTruth float64, networkInferences *emissionstypes.ValueBundle) (emissionstypes.InputValueBundle, error) {
+	m.mu.Lock()
+	params := m.params(reputer, true)
+	m.mu.Unlock()
+	return research.GetReputerOutput(research.FreshNoise(), m.lossFn, groundTruth, networkInferences, params.Error, params.Bias)
+}
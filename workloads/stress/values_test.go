@@ -0,0 +1,63 @@
+package stress
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/allora-network/allora-simulator/types"
+)
+
+func TestValueModel(t *testing.T) {
+	model, err := NewValueModel(&types.ResearchConfig{
+		InitialPrice:         1,
+		Volatility:           0.1,
+		BaseExperienceFactor: 0.5,
+		ExperienceGrowth:     0.1,
+		OutperformValue:      0.5,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	workers := make([]*types.Actor, 3)
+	for i := range workers {
+		workers[i] = &types.Actor{Addr: fmt.Sprintf("worker%d", i)}
+	}
+	// worker3 was active but left the topic
+	previousActive := []string{"worker0", "worker1", "worker3"}
+
+	for nonce := int64(1); nonce <= keptGroundTruths+1; nonce++ {
+		model.NextEpoch(1, nonce, workers, previousActive)
+	}
+
+	if _, ok := model.GroundTruth(1, 1); ok {
+		t.Errorf("expected the ground truth of the oldest nonce to be dropped")
+	}
+	if _, ok := model.GroundTruth(1, keptGroundTruths+1); !ok {
+		t.Errorf("expected the ground truth of the latest nonce to be kept")
+	}
+
+	for _, worker := range workers {
+		values, ok := model.WorkerValues(1, worker.Addr)
+		if !ok {
+			t.Fatalf("expected values for %s", worker.Addr)
+		}
+		if len(values.forecast) != 2 {
+			t.Errorf("expected forecasts for the 2 remaining previously active inferers, got %d", len(values.forecast))
+		}
+	}
+	if _, ok := model.WorkerValues(2, "worker0"); ok {
+		t.Errorf("expected no values for a topic without epochs")
+	}
+}
+
+func TestValidateStressValuesConfig(t *testing.T) {
+	for _, mode := range []string{"", ValuesRandom, ValuesModel} {
+		if err := ValidateStressValuesConfig(types.StressValuesConfig{Mode: mode}); err != nil {
+			t.Errorf("unexpected error for mode %q: %v", mode, err)
+		}
+	}
+	if err := ValidateStressValuesConfig(types.StressValuesConfig{Mode: "other"}); err == nil {
+		t.Errorf("expected an error for an unknown mode")
+	}
+}